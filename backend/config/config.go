@@ -0,0 +1,565 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/logging"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the application configuration. Values are resolved in order
+// of increasing precedence: built-in defaults, the YAML config file,
+// environment variables, then command line flags.
+type Config struct {
+	Server      ServerConfig   `yaml:"server"`
+	Database    DatabaseConfig `yaml:"database"`
+	CORS        CORSConfig     `yaml:"cors"`
+	Export      ExportConfig   `yaml:"export"`
+	SMTP        SMTPConfig     `yaml:"smtp"`
+	Webhook     WebhookConfig  `yaml:"webhook"`
+	Outbox      OutboxConfig   `yaml:"outbox"`
+	Storage     StorageConfig  `yaml:"storage"`
+	Log         LogConfig      `yaml:"log"`
+	AutoMigrate bool           `yaml:"auto_migrate"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port               string        `yaml:"port"`
+	GinMode            string        `yaml:"gin_mode"`
+	ReadTimeout        time.Duration `yaml:"read_timeout"`
+	WriteTimeout       time.Duration `yaml:"write_timeout"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout"`
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout"`
+}
+
+// DatabaseConfig configures the database connection and pool
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	Path            string        `yaml:"path"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+// CORSConfig lists the origins allowed to call the API. "*" allows any.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// ExportConfig configures background export jobs
+type ExportConfig struct {
+	Dir       string        `yaml:"dir"`
+	Workers   int           `yaml:"workers"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// SMTPConfig configures the mail server used for scheduled reports
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// WebhookConfig configures outbound webhook delivery. RetryBackoff is the
+// delay before the first retry and doubles with every later one.
+type WebhookConfig struct {
+	Workers      int           `yaml:"workers"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// OutboxConfig configures the relay of recorded events to their sinks:
+// webhook, log (the application log) and file (JSON lines appended to File).
+// RetryBackoff is the delay before the first retry of a failed event and
+// doubles with every later one; after MaxAttempts the event is dead.
+type OutboxConfig struct {
+	Sinks        []string      `yaml:"sinks"`
+	File         string        `yaml:"file"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+}
+
+// OutboxSinks lists the sinks the outbox can relay events to
+var OutboxSinks = []string{"webhook", "log", "file"}
+
+// StorageConfig configures the blob store holding uploaded files such as
+// employee photos. The local driver keeps them under Dir.
+type StorageConfig struct {
+	Driver string `yaml:"driver"`
+	Dir    string `yaml:"dir"`
+}
+
+// StorageDrivers lists the supported blob store drivers
+var StorageDrivers = []string{"local"}
+
+// LogConfig configures the JSON application log
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+// ValidationError lists every invalid or missing configuration field
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Default returns the built-in configuration. Database credentials have no
+// defaults and must be configured explicitly.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:               "8080",
+			GinMode:            "debug",
+			ReadTimeout:        15 * time.Second,
+			WriteTimeout:       30 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			ShutdownTimeout:    20 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          string(database.DialectMySQL),
+			Host:            "localhost",
+			Path:            "attendance.db",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		Export: ExportConfig{
+			Dir:       "exports",
+			Workers:   2,
+			Retention: 24 * time.Hour,
+		},
+		SMTP: SMTPConfig{
+			Host: "localhost",
+			Port: "25",
+			From: "attendance@localhost",
+		},
+		Webhook: WebhookConfig{
+			Workers:      2,
+			MaxAttempts:  8,
+			RetryBackoff: 30 * time.Second,
+			Timeout:      10 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			Sinks:        []string{"webhook"},
+			File:         "events.jsonl",
+			PollInterval: time.Second,
+			MaxAttempts:  20,
+			RetryBackoff: time.Second,
+		},
+		Storage: StorageConfig{
+			Driver: "local",
+			Dir:    "uploads",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		AutoMigrate: true,
+	}
+}
+
+// Load resolves the configuration from the config file, environment and the
+// flags in args, and validates it. The config file is named by the -config
+// flag or CONFIG_FILE. Arguments after the flags (such as "migrate up") are
+// returned unparsed.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("attendance-system", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	overrides := cfg.flagOverrides(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var problems []string
+	problems = append(problems, cfg.loadEnv()...)
+
+	// Apply only the flags given on the command line, after the environment
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := overrides[f.Name]; ok {
+			apply(f.Value.String())
+		}
+	})
+
+	cfg.applyDriverDefaults()
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, nil, &ValidationError{Problems: problems}
+	}
+
+	return &cfg, fs.Args(), nil
+}
+
+// Dialect returns the configured database backend
+func (c DatabaseConfig) Dialect() database.Dialect {
+	dialect, _ := database.ParseDialect(c.Driver)
+	return dialect
+}
+
+// loadFile reads a YAML config file over the current values
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides values from environment variables, returning a problem
+// for every variable that cannot be parsed
+func (c *Config) loadEnv() []string {
+	var problems []string
+
+	str := func(key string, dst *string) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			*dst = value
+		}
+	}
+	integer := func(key string, dst *int) {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a whole number", key, value))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(key string, dst *time.Duration) {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a duration (e.g. 30s, 5m)", key, value))
+				return
+			}
+			*dst = d
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if value := os.Getenv(key); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not true or false", key, value))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	str("PORT", &c.Server.Port)
+	str("GIN_MODE", &c.Server.GinMode)
+	duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	duration("HEALTH_CHECK_TIMEOUT", &c.Server.HealthCheckTimeout)
+
+	str("DB_DRIVER", &c.Database.Driver)
+	str("DB_HOST", &c.Database.Host)
+	str("DB_PORT", &c.Database.Port)
+	str("DB_USER", &c.Database.User)
+	str("DB_PASSWORD", &c.Database.Password)
+	str("DB_NAME", &c.Database.Name)
+	str("DB_PATH", &c.Database.Path)
+	str("DB_SSLMODE", &c.Database.SSLMode)
+	integer("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	integer("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	boolean("AUTO_MIGRATE", &c.AutoMigrate)
+
+	if value := os.Getenv("CORS_ALLOWED_ORIGINS"); value != "" {
+		c.CORS.AllowedOrigins = splitList(value)
+	}
+
+	str("EXPORT_DIR", &c.Export.Dir)
+	integer("EXPORT_WORKERS", &c.Export.Workers)
+	duration("EXPORT_RETENTION", &c.Export.Retention)
+
+	str("SMTP_HOST", &c.SMTP.Host)
+	str("SMTP_PORT", &c.SMTP.Port)
+	str("SMTP_USERNAME", &c.SMTP.Username)
+	str("SMTP_PASSWORD", &c.SMTP.Password)
+	str("SMTP_FROM", &c.SMTP.From)
+
+	integer("WEBHOOK_WORKERS", &c.Webhook.Workers)
+	integer("WEBHOOK_MAX_ATTEMPTS", &c.Webhook.MaxAttempts)
+	duration("WEBHOOK_RETRY_BACKOFF", &c.Webhook.RetryBackoff)
+	duration("WEBHOOK_TIMEOUT", &c.Webhook.Timeout)
+
+	if value := os.Getenv("OUTBOX_SINKS"); value != "" {
+		c.Outbox.Sinks = splitList(value)
+	}
+	str("OUTBOX_FILE", &c.Outbox.File)
+	duration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	integer("OUTBOX_MAX_ATTEMPTS", &c.Outbox.MaxAttempts)
+	duration("OUTBOX_RETRY_BACKOFF", &c.Outbox.RetryBackoff)
+
+	str("STORAGE_DRIVER", &c.Storage.Driver)
+	str("STORAGE_DIR", &c.Storage.Dir)
+
+	str("LOG_LEVEL", &c.Log.Level)
+
+	return problems
+}
+
+// flagOverrides registers the command line flags on fs and returns setters
+// that apply a flag's value to the configuration
+func (c *Config) flagOverrides(fs *flag.FlagSet) map[string]func(string) {
+	fs.String("port", "", "HTTP port")
+	fs.String("gin-mode", "", "gin mode (debug, release or test)")
+	fs.String("db-driver", "", "database driver (mysql, postgres or sqlite)")
+	fs.String("db-host", "", "database host")
+	fs.String("db-port", "", "database port")
+	fs.String("db-user", "", "database user")
+	fs.String("db-name", "", "database name")
+	fs.String("db-path", "", "SQLite database file")
+	fs.String("export-dir", "", "directory for export files")
+	fs.String("cors-allowed-origins", "", "comma separated list of allowed CORS origins")
+	fs.String("log-level", "", "log level (debug, info, warn or error)")
+
+	return map[string]func(string){
+		"port":                 func(v string) { c.Server.Port = v },
+		"gin-mode":             func(v string) { c.Server.GinMode = v },
+		"db-driver":            func(v string) { c.Database.Driver = v },
+		"db-host":              func(v string) { c.Database.Host = v },
+		"db-port":              func(v string) { c.Database.Port = v },
+		"db-user":              func(v string) { c.Database.User = v },
+		"db-name":              func(v string) { c.Database.Name = v },
+		"db-path":              func(v string) { c.Database.Path = v },
+		"export-dir":           func(v string) { c.Export.Dir = v },
+		"cors-allowed-origins": func(v string) { c.CORS.AllowedOrigins = splitList(v) },
+		"log-level":            func(v string) { c.Log.Level = v },
+	}
+}
+
+// applyDriverDefaults fills in settings whose default depends on the driver
+func (c *Config) applyDriverDefaults() {
+	if c.Database.Port != "" {
+		return
+	}
+	switch c.Database.Dialect() {
+	case database.DialectMySQL:
+		c.Database.Port = "3306"
+	case database.DialectPostgres:
+		c.Database.Port = "5432"
+	}
+}
+
+// validate returns a description of every invalid or missing field
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !validPort(c.Server.Port) {
+		add("server.port: %q is not a valid port", c.Server.Port)
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		add("server.gin_mode: %q must be debug, release or test", c.Server.GinMode)
+	}
+	for name, d := range map[string]time.Duration{
+		"server.read_timeout":         c.Server.ReadTimeout,
+		"server.write_timeout":        c.Server.WriteTimeout,
+		"server.idle_timeout":         c.Server.IdleTimeout,
+		"server.shutdown_timeout":     c.Server.ShutdownTimeout,
+		"server.health_check_timeout": c.Server.HealthCheckTimeout,
+	} {
+		if d <= 0 {
+			add("%s must be greater than zero", name)
+		}
+	}
+
+	db := c.Database
+	dialect, err := database.ParseDialect(db.Driver)
+	if err != nil {
+		add("database.driver: %v", err)
+	}
+	switch dialect {
+	case database.DialectSQLite:
+		if db.Path == "" {
+			add("database.path is required for sqlite")
+		}
+	case database.DialectMySQL, database.DialectPostgres:
+		if db.Host == "" {
+			add("database.host is required")
+		}
+		if !validPort(db.Port) {
+			add("database.port: %q is not a valid port", db.Port)
+		}
+		if db.User == "" {
+			add("database.user is required (DB_USER)")
+		}
+		if db.Name == "" {
+			add("database.name is required (DB_NAME)")
+		}
+	}
+	if dialect == database.DialectPostgres {
+		switch db.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			add("database.sslmode: %q is not a valid PostgreSQL sslmode", db.SSLMode)
+		}
+	}
+	if db.MaxOpenConns < 1 {
+		add("database.max_open_conns must be at least 1")
+	}
+	if db.MaxIdleConns < 0 || db.MaxIdleConns > db.MaxOpenConns {
+		add("database.max_idle_conns must be between 0 and max_open_conns")
+	}
+	if db.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime must not be negative")
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		add("cors.allowed_origins must list at least one origin or \"*\"")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			add("cors.allowed_origins: %q must be \"*\" or a scheme and host such as https://example.com", origin)
+		}
+	}
+
+	if c.Export.Dir == "" {
+		add("export.dir is required")
+	}
+	if c.Export.Workers < 1 {
+		add("export.workers must be at least 1")
+	}
+	if c.Export.Retention <= 0 {
+		add("export.retention must be greater than zero")
+	}
+
+	if c.SMTP.Host == "" {
+		add("smtp.host is required")
+	}
+	if !validPort(c.SMTP.Port) {
+		add("smtp.port: %q is not a valid port", c.SMTP.Port)
+	}
+	if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+		add("smtp.from: %q is not a valid email address", c.SMTP.From)
+	}
+
+	if c.Webhook.Workers < 1 {
+		add("webhook.workers must be at least 1")
+	}
+	if c.Webhook.MaxAttempts < 1 {
+		add("webhook.max_attempts must be at least 1")
+	}
+	if c.Webhook.RetryBackoff <= 0 {
+		add("webhook.retry_backoff must be greater than zero")
+	}
+	if c.Webhook.Timeout <= 0 {
+		add("webhook.timeout must be greater than zero")
+	}
+
+	if len(c.Outbox.Sinks) == 0 {
+		add("outbox.sinks must list at least one sink")
+	}
+	for _, sink := range c.Outbox.Sinks {
+		if !slices.Contains(OutboxSinks, sink) {
+			add("outbox.sinks: %q must be one of %s", sink, strings.Join(OutboxSinks, ", "))
+		}
+		if sink == "file" && c.Outbox.File == "" {
+			add("outbox.file is required by the file sink")
+		}
+	}
+	if c.Outbox.PollInterval <= 0 {
+		add("outbox.poll_interval must be greater than zero")
+	}
+	if c.Outbox.MaxAttempts < 1 {
+		add("outbox.max_attempts must be at least 1")
+	}
+	if c.Outbox.RetryBackoff <= 0 {
+		add("outbox.retry_backoff must be greater than zero")
+	}
+
+	if !slices.Contains(StorageDrivers, c.Storage.Driver) {
+		add("storage.driver: %q must be one of %s", c.Storage.Driver, strings.Join(StorageDrivers, ", "))
+	}
+	if c.Storage.Driver == "local" && c.Storage.Dir == "" {
+		add("storage.dir is required by the local driver")
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		add("log.level: %v", err)
+	}
+
+	return problems
+}
+
+// AllowAllOrigins reports whether CORS allows any origin
+func (c CORSConfig) AllowAllOrigins() bool {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
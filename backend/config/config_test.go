@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clearEnv unsets every variable Load reads so the host environment does not
+// leak into tests
+func clearEnv(t *testing.T) {
+	for _, key := range []string{
+		"CONFIG_FILE", "PORT", "GIN_MODE", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT", "HEALTH_CHECK_TIMEOUT",
+		"DB_DRIVER", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_PATH", "DB_SSLMODE",
+		"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME", "AUTO_MIGRATE",
+		"CORS_ALLOWED_ORIGINS", "EXPORT_DIR", "EXPORT_WORKERS", "EXPORT_RETENTION",
+		"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_FROM",
+		"WEBHOOK_WORKERS", "WEBHOOK_MAX_ATTEMPTS", "WEBHOOK_RETRY_BACKOFF", "WEBHOOK_TIMEOUT",
+		"OUTBOX_SINKS", "OUTBOX_FILE", "OUTBOX_POLL_INTERVAL", "OUTBOX_MAX_ATTEMPTS", "OUTBOX_RETRY_BACKOFF", "LOG_LEVEL",
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, `
+server:
+  port: "9000"
+  read_timeout: 5s
+database:
+  user: file_user
+  name: file_db
+  max_open_conns: 10
+export:
+  workers: 4
+`)
+	t.Setenv("DB_USER", "env_user")
+	t.Setenv("PORT", "9100")
+
+	cfg, args, err := Load([]string{"-config", path, "-port", "9200", "migrate", "up"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	assert.Equal(t, "9200", cfg.Server.Port, "flags override env")
+	assert.Equal(t, "env_user", cfg.Database.User, "env overrides the file")
+	assert.Equal(t, "file_db", cfg.Database.Name)
+	assert.Equal(t, 10, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 4, cfg.Export.Workers)
+	assert.Equal(t, "3306", cfg.Database.Port, "port defaults by driver")
+	assert.Equal(t, 24*time.Hour, cfg.Export.Retention, "unset values keep their defaults")
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_SSLMODE", "sometimes")
+	t.Setenv("EXPORT_WORKERS", "many")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com,not a url")
+	t.Setenv("SMTP_FROM", "nobody")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "0")
+	t.Setenv("OUTBOX_SINKS", "webhook,kafka")
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "-1")
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	_, _, err := Load(nil)
+
+	var validationErr *ValidationError
+	if !assert.True(t, errors.As(err, &validationErr)) {
+		return
+	}
+	assert.ElementsMatch(t, []string{
+		`EXPORT_WORKERS: "many" is not a whole number`,
+		"database.user is required (DB_USER)",
+		"database.name is required (DB_NAME)",
+		`database.sslmode: "sometimes" is not a valid PostgreSQL sslmode`,
+		`cors.allowed_origins: "not a url" must be "*" or a scheme and host such as https://example.com`,
+		`smtp.from: "nobody" is not a valid email address`,
+		"webhook.max_attempts must be at least 1",
+		`outbox.sinks: "kafka" must be one of webhook, log, file`,
+		"outbox.max_attempts must be at least 1",
+		`log.level: unknown log level "verbose": expected debug, info, warn or error`,
+	}, validationErr.Problems)
+}
+
+func TestLoadSQLiteNeedsNoCredentials(t *testing.T) {
+	clearEnv(t)
+
+	cfg, _, err := Load([]string{"-db-driver", "sqlite", "-db-path", "test.db"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "sqlite", string(cfg.Database.Dialect()))
+	assert.Equal(t, SQLiteDSN("test.db"), cfg.Database.DSN())
+}
+
+func TestLoadRejectsUnknownFileFields(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, "database:\n  usr: typo\n")
+
+	_, _, err := Load([]string{"-config", path})
+	assert.Error(t, err)
+}
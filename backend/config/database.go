@@ -3,57 +3,66 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"net"
+	"net/url"
+
+	"attendance-system/database"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
 )
 
 // InitDB initializes and returns a database connection
-func InitDB() (*sql.DB, error) {
-	// Get database configuration from environment variables
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
-	}
-
-	dbPort := os.Getenv("DB_PORT")
-	if dbPort == "" {
-		dbPort = "3306"
-	}
-
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		dbUser = "root"
-	}
-
-	dbPassword := os.Getenv("DB_PASSWORD")
-	if dbPassword == "" {
-		dbPassword = ""
-	}
-
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "attendance_system"
-	}
-
-	// Create connection string
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=Local", 
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+func InitDB(cfg DatabaseConfig) (*sql.DB, error) {
+	dialect := cfg.Dialect()
 
 	// Open database connection
-	db, err := sql.Open("mysql", dsn)
+	db, err := sql.Open(string(dialect), cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
 	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	if dialect == database.DialectSQLite {
+		// SQLite allows a single writer; one connection avoids "database is locked"
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db, nil
 }
+
+// DSN builds the driver connection string for the configured backend
+func (c DatabaseConfig) DSN() string {
+	switch c.Dialect() {
+	case database.DialectSQLite:
+		return SQLiteDSN(c.Path)
+	case database.DialectPostgres:
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     net.JoinHostPort(c.Host, c.Port),
+			Path:     "/" + c.Name,
+			RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+		}
+		return dsn.String()
+	default:
+		return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&loc=Local",
+			c.User, c.Password, net.JoinHostPort(c.Host, c.Port), c.Name)
+	}
+}
+
+// SQLiteDSN builds a connection string for a SQLite file with foreign keys
+// enforced and a busy timeout for concurrent access
+func SQLiteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
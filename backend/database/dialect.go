@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// Dialect identifies the SQL database backend
+type Dialect string
+
+// Supported dialects
+const (
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// ParseDialect converts a configured driver name into a Dialect
+func ParseDialect(name string) (Dialect, error) {
+	switch Dialect(name) {
+	case DialectMySQL, DialectSQLite, DialectPostgres:
+		return Dialect(name), nil
+	case "sqlite3":
+		return DialectSQLite, nil
+	case "postgresql", "pgx":
+		return DialectPostgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q: expected mysql, sqlite or postgres", name)
+	}
+}
+
+// ForUpdate returns the clause that locks the rows read by a SELECT until
+// the transaction ends. SQLite has no such clause; a transaction that has
+// written already holds the lock on the whole database.
+func ForUpdate(db *sql.DB) string {
+	if _, ok := db.Driver().(*sqlite.Driver); ok {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// OnConflictDoNothing returns the clause that makes an INSERT skip rows
+// that would violate a unique index instead of failing. MySQL has no such
+// clause; a no-op update of column on a duplicate key does the same without
+// INSERT IGNORE also turning every other error into a warning.
+func OnConflictDoNothing(db *sql.DB, column string) string {
+	switch db.Driver().(type) {
+	case *sqlite.Driver, *postgresDriver:
+		return " ON CONFLICT DO NOTHING"
+	}
+	return " ON DUPLICATE KEY UPDATE " + column + " = " + column
+}
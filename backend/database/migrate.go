@@ -0,0 +1,540 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+//go:embed seeds
+var seedFiles embed.FS
+
+// migrationLockName is the advisory lock held while migrations run so that
+// replicas starting at the same time do not race
+const migrationLockName = "attendance_system_schema_migrations"
+
+// postgresMigrationLockKey is the PostgreSQL advisory lock key, which must be
+// a number rather than a name
+const postgresMigrationLockKey int64 = 7461826351
+
+// migrationLockTimeout is how long to wait for another replica to finish
+const migrationLockTimeout = 60 * time.Second
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with its up and down SQL
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+	// Error is why the migration failed on MySQL, until it is resolved
+	Error string `json:"error,omitempty"`
+}
+
+// Migrator applies embedded migrations and records them in schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations of a dialect
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order. On SQLite and
+// PostgreSQL each migration runs in one transaction with its
+// schema_migrations row, so a failed migration leaves nothing behind. MySQL
+// commits DDL statement by statement: a failed migration is recorded in
+// schema_migration_failures and Up refuses to run again until the schema has
+// been repaired by hand and the failure cleared with Resolve.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		failed, err := m.failedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if cause, ok := failed[migration.Version]; ok {
+				return fmt.Errorf("migration %04d_%s failed earlier and may be partly applied (%s): "+
+					"repair the schema, then run \"migrate resolve %d\"", migration.Version, migration.Name, cause, migration.Version)
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migrations, up to steps of them.
+// As with Up, each one runs in a transaction except on MySQL.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			err := m.inTransaction(ctx, conn, func(q execer) error {
+				if err := execStatements(ctx, q, migration.Down); err != nil {
+					return err
+				}
+				_, err := q.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %v", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Resolve clears the failure recorded for a MySQL migration, once its
+// partial changes have been undone by hand, so that the next Up runs it again
+func (m *Migrator) Resolve(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		result, err := conn.ExecContext(ctx, "DELETE FROM schema_migration_failures WHERE version = ?", version)
+		if err != nil {
+			return fmt.Errorf("failed to clear migration failure %04d: %v", version, err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("migration %04d has no recorded failure", version)
+		}
+		return nil
+	})
+}
+
+// apply runs a migration and records it in schema_migrations. On MySQL a
+// failure is recorded in schema_migration_failures instead.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	run := func(q execer) error {
+		if err := execStatements(ctx, q, migration.Up); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to record migration: %v", err)
+		}
+		return nil
+	}
+
+	if m.dialect != DialectMySQL {
+		return m.inTransaction(ctx, conn, run)
+	}
+
+	err := run(conn)
+	if err != nil {
+		// Recorded even when ctx was cancelled halfway through
+		_, recordErr := conn.ExecContext(context.Background(),
+			"INSERT INTO schema_migration_failures (version, name, error, failed_at) VALUES (?, ?, ?, ?)",
+			migration.Version, migration.Name, err.Error(), time.Now())
+		if recordErr != nil {
+			return fmt.Errorf("%v (and failed to record the failure: %v)", err, recordErr)
+		}
+	}
+	return err
+}
+
+// inTransaction runs fn in a transaction on conn, or directly on conn for
+// MySQL, where DDL commits implicitly. SQLite ignores foreign_keys pragmas
+// inside a transaction, so enforcement is switched off around it, as
+// migrations rebuilding tables need, and the keys are checked before commit.
+func (m *Migrator) inTransaction(ctx context.Context, conn *sql.Conn, fn func(q execer) error) error {
+	if m.dialect == DialectMySQL {
+		return fn(conn)
+	}
+
+	if m.dialect == DialectSQLite {
+		var enforced bool
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enforced); err != nil {
+			return fmt.Errorf("failed to read foreign_keys: %v", err)
+		}
+		if enforced {
+			if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+				return fmt.Errorf("failed to disable foreign keys: %v", err)
+			}
+			defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if m.dialect == DialectSQLite {
+		if err := checkForeignKeys(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// checkForeignKeys fails if any row in tx references a missing parent
+func checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %v", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var index int
+		if err := rows.Scan(&table, &rowID, &parent, &index); err != nil {
+			return fmt.Errorf("failed to check foreign keys: %v", err)
+		}
+		return fmt.Errorf("row %d of %s references a missing %s", rowID.Int64, table, parent)
+	}
+	return rows.Err()
+}
+
+// Status lists every embedded migration and when it was applied. It only
+// reads the database, so it also works for read-only users; before the first
+// migration has created schema_migrations every migration is pending.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	exists, err := m.tableExists(ctx, conn, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int64]time.Time)
+	if exists {
+		done, err = m.appliedVersions(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	failed := make(map[int64]string)
+	exists, err = m.tableExists(ctx, conn, "schema_migration_failures")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		failed, err = m.failedVersions(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Error: failed[migration.Version]}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for i, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, m.migrations[i])
+		}
+	}
+	return pending, nil
+}
+
+// Seed loads the embedded sample data. It is kept apart from the schema
+// migrations and should only be run against development databases.
+func Seed(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	names, err := fs.Glob(seedFiles, path.Join("seeds", string(dialect), "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list seed files: %v", err)
+	}
+	sort.Strings(names)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	for _, name := range names {
+		data, err := seedFiles.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		if err := execStatements(ctx, conn, string(data)); err != nil {
+			return fmt.Errorf("seed %s failed: %v", path.Base(name), err)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock.
+// SQLite databases are local files used by a single process, so no advisory
+// lock is taken for them.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	switch m.dialect {
+	case DialectMySQL:
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)",
+			migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&acquired)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		if acquired.Int64 != 1 {
+			return fmt.Errorf("timed out waiting for migration lock")
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+	case DialectPostgres:
+		lockCtx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+		_, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(?)", postgresMigrationLockKey)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(?)", postgresMigrationLockKey)
+	}
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// appliedVersions returns the applied migration versions and their times
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// tableExists reports whether the current database, or schema on
+// PostgreSQL, has a table called name
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn, name string) (bool, error) {
+	var query string
+	switch m.dialect {
+	case DialectSQLite:
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case DialectPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+	default:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+
+	var count int
+	if err := conn.QueryRowContext(ctx, query, name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %v", name, err)
+	}
+	return count > 0, nil
+}
+
+// failedVersions returns the migrations recorded as failed and why
+func (m *Migrator) failedVersions(ctx context.Context, conn *sql.Conn) (map[int64]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, error FROM schema_migration_failures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migration_failures: %v", err)
+	}
+	defer rows.Close()
+
+	failed := make(map[int64]string)
+	for rows.Next() {
+		var version int64
+		var cause string
+		if err := rows.Scan(&version, &cause); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migration_failures: %v", err)
+		}
+		failed[version] = cause
+	}
+	return failed, rows.Err()
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migration_failures (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			error TEXT NOT NULL,
+			failed_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migration_failures: %v", err)
+	}
+	return nil
+}
+
+// loadMigrations pairs NNNN_name.up.sql and NNNN_name.down.sql files
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %v", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// execer is a connection or transaction that statements can run on
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// execStatements runs each semicolon-terminated statement in a SQL script
+func execStatements(ctx context.Context, q execer, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := q.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons that end a line, dropping
+// "--" comment lines
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSpace(current.String())
+			statements = append(statements, strings.TrimSuffix(statement, ";"))
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
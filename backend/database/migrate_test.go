@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	var versions []int64
+	for _, dialect := range []Dialect{DialectMySQL, DialectSQLite, DialectPostgres} {
+		migrations, err := loadMigrations(migrationFiles, "migrations/"+string(dialect))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+
+		var dialectVersions []int64
+		for i, migration := range migrations {
+			assert.Equal(t, int64(i+1), migration.Version, "migration versions must be contiguous")
+			assert.NotEmpty(t, splitStatements(migration.Up))
+			assert.NotEmpty(t, splitStatements(migration.Down))
+			dialectVersions = append(dialectVersions, migration.Version)
+		}
+
+		if versions == nil {
+			versions = dialectVersions
+		}
+		assert.Equal(t, versions, dialectVersions, "%s migrations must match the other dialects", dialect)
+	}
+}
+
+func TestSQLiteMigrateUpDownAndSeed(t *testing.T) {
+	db, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+
+	pending, err := migrator.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	assert.NoError(t, Seed(ctx, db, DialectSQLite))
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM employee").Scan(&count))
+	assert.Greater(t, count, 0)
+
+	reverted, err := migrator.Down(ctx, len(applied))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+}
+
+func TestStatusIsReadOnly(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// A fresh database has every migration pending and is left untouched
+	pending, err := migrator.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, len(migrator.migrations))
+	var tables int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables))
+	assert.Equal(t, 0, tables)
+
+	// As is one opened read-only
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	_, err = db.Exec("PRAGMA query_only = ON")
+	assert.NoError(t, err)
+	pending, err = migrator.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	db, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"migrations/0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY);")},
+		"migrations/0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"migrations/0002_create_b.up.sql": {Data: []byte(`
+CREATE TABLE b (id INTEGER PRIMARY KEY, a_id INTEGER REFERENCES a(id));
+INSERT INTO b (id, a_id) VALUES (1, 42);
+`)},
+		"migrations/0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+		"migrations/0003_fail.up.sql":       {Data: []byte("CREATE TABLE c (id INT); INSERT INTO missing VALUES (1);")},
+		"migrations/0003_fail.down.sql":     {Data: []byte("DROP TABLE c;")},
+	}
+	migrations, err := loadMigrations(fsys, "migrations")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+	tableExists := func(name string) bool {
+		var count int
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count))
+		return count > 0
+	}
+
+	// Migration 2 breaks a foreign key, which is only caught before commit
+	migrator := &Migrator{db: db, dialect: DialectSQLite, migrations: migrations[:2]}
+	applied, err := migrator.Up(ctx)
+	assert.ErrorContains(t, err, "0002_create_b")
+	assert.Len(t, applied, 1)
+	assert.True(t, tableExists("a"))
+	assert.False(t, tableExists("b"))
+
+	// Migration 3 fails halfway through
+	migrator = &Migrator{db: db, dialect: DialectSQLite, migrations: []Migration{migrations[0], migrations[2]}}
+	applied, err = migrator.Up(ctx)
+	assert.ErrorContains(t, err, "0003_fail")
+	assert.Empty(t, applied)
+	assert.False(t, tableExists("c"))
+
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 2) {
+		assert.NotNil(t, statuses[0].AppliedAt)
+		assert.Nil(t, statuses[1].AppliedAt)
+		assert.Empty(t, statuses[1].Error)
+	}
+
+	// Enforcement is back on once the migration is over
+	var enforced bool
+	assert.NoError(t, db.QueryRow("PRAGMA foreign_keys").Scan(&enforced))
+	assert.True(t, enforced)
+}
+
+func TestLoadMigrationsRequiresBothDirections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+	}
+	_, err := loadMigrations(fsys, "migrations")
+	assert.Error(t, err)
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+    id INT
+);
+
+INSERT INTO a (id) VALUES (1);
+`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n    id INT\n)",
+		"INSERT INTO a (id) VALUES (1)",
+	}, splitStatements(script))
+}
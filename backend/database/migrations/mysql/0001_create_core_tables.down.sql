@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS attendance_history;
+DROP TABLE IF EXISTS attendance;
+DROP TABLE IF EXISTS employee;
+DROP TABLE IF EXISTS departement;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS report_schedule (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    cron_expr VARCHAR(100) NOT NULL,
+    report_type VARCHAR(50) NOT NULL,
+    filters TEXT NOT NULL,
+    recipients TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL DEFAULT 'csv',
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    last_run_at TIMESTAMP NULL,
+    last_run_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS webhook (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT NOT NULL,
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhook(id) ON DELETE CASCADE,
+    INDEX idx_webhook_delivery_due (status, next_attempt_at)
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_webhook_delivery_event ON webhook_delivery;
+DROP TABLE IF EXISTS event_outbox;
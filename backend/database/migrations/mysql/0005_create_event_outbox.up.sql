@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    ordering_key VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL,
+    INDEX idx_event_outbox_pending (published_at, id),
+    INDEX idx_event_outbox_ordering (ordering_key, published_at, id)
+);
+
+CREATE INDEX idx_webhook_delivery_event ON webhook_delivery(event_id);
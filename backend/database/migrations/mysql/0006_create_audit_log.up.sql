@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before_data TEXT NULL,
+    after_data TEXT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    INDEX idx_audit_log_entity (entity_type, entity_id),
+    INDEX idx_audit_log_occurred_at (occurred_at),
+    INDEX idx_audit_log_actor (actor)
+);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id INT PRIMARY KEY,
+    entries INT NOT NULL DEFAULT 0,
+    last_hash CHAR(64) NOT NULL DEFAULT ''
+);
+
+INSERT INTO audit_chain (id, entries, last_hash) VALUES (1, 0, '');
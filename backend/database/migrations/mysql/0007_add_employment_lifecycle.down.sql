@@ -0,0 +1,16 @@
+ALTER TABLE attendance_history DROP FOREIGN KEY fk_attendance_history_employee, DROP FOREIGN KEY fk_attendance_history_attendance;
+ALTER TABLE attendance DROP FOREIGN KEY fk_attendance_employee;
+
+ALTER TABLE attendance
+    ADD CONSTRAINT attendance_ibfk_1 FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE;
+
+ALTER TABLE attendance_history
+    ADD CONSTRAINT attendance_history_ibfk_1 FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE,
+    ADD CONSTRAINT attendance_history_ibfk_2 FOREIGN KEY (attendance_id) REFERENCES attendance(attendance_id) ON DELETE CASCADE;
+
+ALTER TABLE employee
+    DROP INDEX idx_employee_status,
+    DROP COLUMN deleted_at,
+    DROP COLUMN termination_date,
+    DROP COLUMN hire_date,
+    DROP COLUMN employment_status;
@@ -0,0 +1,20 @@
+-- Employment status and dates. deleted_at marks employees removed through the
+-- API; their rows stay so attendance history keeps its employee.
+ALTER TABLE employee
+    ADD COLUMN employment_status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN hire_date DATE NULL,
+    ADD COLUMN termination_date DATE NULL,
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_employee_status (employment_status);
+
+-- Deleting an employee row must not wipe its attendance. The foreign keys of
+-- 0001 are unnamed, so InnoDB named them <table>_ibfk_<n> in declaration order.
+ALTER TABLE attendance_history DROP FOREIGN KEY attendance_history_ibfk_1, DROP FOREIGN KEY attendance_history_ibfk_2;
+ALTER TABLE attendance DROP FOREIGN KEY attendance_ibfk_1;
+
+ALTER TABLE attendance
+    ADD CONSTRAINT fk_attendance_employee FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT;
+
+ALTER TABLE attendance_history
+    ADD CONSTRAINT fk_attendance_history_employee FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_attendance_history_attendance FOREIGN KEY (attendance_id) REFERENCES attendance(attendance_id) ON DELETE RESTRICT;
@@ -0,0 +1,23 @@
+-- Effective-dated department assignments. starts_at and ends_at bound each
+-- assignment, usually at local midnight or, for a department changed on the
+-- employee record, at the time of the change, so punches can be matched
+-- against them directly; the first assignment has no start and covers older
+-- history, the current one has no end.
+CREATE TABLE IF NOT EXISTS department_assignment (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    departement_id INT NOT NULL,
+    effective_from DATE NOT NULL,
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_department_assignment_day (employee_id, effective_from),
+    INDEX idx_department_assignment_department (departement_id),
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    FOREIGN KEY (departement_id) REFERENCES departement(id) ON DELETE RESTRICT
+);
+
+-- Existing employees have always been in their current department
+INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+SELECT employee_id, departement_id, COALESCE(hire_date, DATE(created_at)), CURRENT_TIMESTAMP
+FROM employee;
@@ -0,0 +1,8 @@
+ALTER TABLE attendance_history
+    DROP INDEX idx_attendance_history_department,
+    DROP COLUMN early_minutes,
+    DROP COLUMN late_minutes,
+    DROP COLUMN is_on_time,
+    DROP COLUMN max_clock_out_time,
+    DROP COLUMN max_clock_in_time,
+    DROP COLUMN departement_id;
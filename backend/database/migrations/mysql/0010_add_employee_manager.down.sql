@@ -0,0 +1,5 @@
+ALTER TABLE employee DROP FOREIGN KEY fk_employee_manager;
+
+ALTER TABLE employee
+    DROP INDEX idx_employee_manager,
+    DROP COLUMN manager_id;
@@ -0,0 +1,5 @@
+-- Reporting line: the employee_id of the employee's manager
+ALTER TABLE employee
+    ADD COLUMN manager_id VARCHAR(50) NULL,
+    ADD INDEX idx_employee_manager (manager_id),
+    ADD CONSTRAINT fk_employee_manager FOREIGN KEY (manager_id) REFERENCES employee(employee_id) ON DELETE RESTRICT;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS employee_custom_value;
+DROP TABLE IF EXISTS custom_field;
+
+ALTER TABLE employee
+    DROP INDEX idx_employee_employment_type,
+    DROP INDEX idx_employee_email,
+    DROP COLUMN photo_key,
+    DROP COLUMN work_location,
+    DROP COLUMN employment_type,
+    DROP COLUMN job_title,
+    DROP COLUMN phone,
+    DROP COLUMN email;
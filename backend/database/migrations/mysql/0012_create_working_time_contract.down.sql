@@ -0,0 +1,3 @@
+ALTER TABLE attendance_history DROP COLUMN contract_id;
+
+DROP TABLE IF EXISTS working_time_contract;
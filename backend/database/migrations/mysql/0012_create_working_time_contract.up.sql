@@ -0,0 +1,26 @@
+-- Effective-dated working-time contracts. An employee under a contract is
+-- evaluated against it instead of their department's schedule: they may start
+-- within the start window, must be present during the optional core hours and
+-- owe weekly_hours spread evenly over their working_days. starts_at and
+-- ends_at are the local midnights bounding each contract; the current one has
+-- no end.
+CREATE TABLE IF NOT EXISTS working_time_contract (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    effective_from DATE NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NULL,
+    weekly_hours DECIMAL(5,2) NOT NULL,
+    working_days VARCHAR(30) NOT NULL DEFAULT 'mon,tue,wed,thu,fri',
+    start_window_start TIME NOT NULL,
+    start_window_end TIME NOT NULL,
+    core_start TIME NULL,
+    core_end TIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_working_time_contract_day (employee_id, effective_from),
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT
+);
+
+-- The contract, if any, each punch was evaluated against
+ALTER TABLE attendance_history ADD COLUMN contract_id INT NULL;
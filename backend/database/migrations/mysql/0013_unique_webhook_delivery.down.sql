@@ -0,0 +1,2 @@
+CREATE INDEX idx_webhook_delivery_event ON webhook_delivery(event_id);
+DROP INDEX idx_webhook_delivery_event_webhook ON webhook_delivery;
@@ -0,0 +1,8 @@
+-- One delivery per webhook and event, so that concurrent enqueues of the
+-- same event cannot queue it twice. Duplicates queued before are dropped,
+-- keeping the oldest.
+DELETE d FROM webhook_delivery d
+JOIN webhook_delivery k ON k.webhook_id = d.webhook_id AND k.event_id = d.event_id AND k.id < d.id;
+
+CREATE UNIQUE INDEX idx_webhook_delivery_event_webhook ON webhook_delivery(event_id, webhook_id);
+DROP INDEX idx_webhook_delivery_event ON webhook_delivery;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS attendance_history;
+DROP TABLE IF EXISTS attendance;
+DROP TABLE IF EXISTS employee;
+DROP TABLE IF EXISTS departement;
+DROP FUNCTION IF EXISTS set_updated_at();
@@ -0,0 +1,58 @@
+-- Core tables based on the provided ERD
+
+CREATE TABLE IF NOT EXISTS departement (
+    id SERIAL PRIMARY KEY,
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NOT NULL,
+    max_clock_out_time TIME NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS employee (
+    id SERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) UNIQUE NOT NULL,
+    departement_id INTEGER NOT NULL REFERENCES departement(id) ON DELETE RESTRICT,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS attendance (
+    id SERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id) ON DELETE CASCADE,
+    attendance_id VARCHAR(100) UNIQUE NOT NULL,
+    clock_in TIMESTAMPTZ NOT NULL,
+    clock_out TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- attendance_type: 1 = In, 2 = Out
+CREATE TABLE IF NOT EXISTS attendance_history (
+    id SERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id) ON DELETE CASCADE,
+    attendance_id VARCHAR(100) NOT NULL REFERENCES attendance(attendance_id) ON DELETE CASCADE,
+    date_attendance TIMESTAMPTZ NOT NULL,
+    attendance_type SMALLINT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_department ON employee(departement_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_employee ON attendance(employee_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_date ON attendance(clock_in);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_employee ON attendance_history(employee_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_date ON attendance_history(date_attendance);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_type ON attendance_history(attendance_type);
+
+-- PostgreSQL has no ON UPDATE CURRENT_TIMESTAMP; keep updated_at current for
+-- updates that do not set it explicitly
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$ BEGIN IF NEW.updated_at IS NOT DISTINCT FROM OLD.updated_at THEN NEW.updated_at = CURRENT_TIMESTAMP; END IF; RETURN NEW; END; $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_departement_updated_at BEFORE UPDATE ON departement FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_employee_updated_at BEFORE UPDATE ON employee FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_attendance_updated_at BEFORE UPDATE ON attendance FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_attendance_history_updated_at BEFORE UPDATE ON attendance_history FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS report_schedule (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    cron_expr VARCHAR(100) NOT NULL,
+    report_type VARCHAR(50) NOT NULL,
+    filters TEXT NOT NULL,
+    recipients TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL DEFAULT 'csv',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_run_at TIMESTAMPTZ NULL,
+    last_run_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER trg_report_schedule_updated_at BEFORE UPDATE ON report_schedule FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS webhook (
+    id SERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER trg_webhook_updated_at BEFORE UPDATE ON webhook FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NULL,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery(status, next_attempt_at);
@@ -0,0 +1,17 @@
+ALTER TABLE attendance_history
+    DROP CONSTRAINT IF EXISTS attendance_history_employee_id_fkey,
+    DROP CONSTRAINT IF EXISTS attendance_history_attendance_id_fkey,
+    ADD CONSTRAINT attendance_history_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE,
+    ADD CONSTRAINT attendance_history_attendance_id_fkey FOREIGN KEY (attendance_id) REFERENCES attendance(attendance_id) ON DELETE CASCADE;
+
+ALTER TABLE attendance
+    DROP CONSTRAINT IF EXISTS attendance_employee_id_fkey,
+    ADD CONSTRAINT attendance_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_employee_status;
+
+ALTER TABLE employee
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS termination_date,
+    DROP COLUMN IF EXISTS hire_date,
+    DROP COLUMN IF EXISTS employment_status;
@@ -0,0 +1,20 @@
+-- Employment status and dates. deleted_at marks employees removed through the
+-- API; their rows stay so attendance history keeps its employee.
+ALTER TABLE employee
+    ADD COLUMN IF NOT EXISTS employment_status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS hire_date DATE NULL,
+    ADD COLUMN IF NOT EXISTS termination_date DATE NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_employee_status ON employee(employment_status);
+
+-- Deleting an employee row must not wipe its attendance
+ALTER TABLE attendance
+    DROP CONSTRAINT IF EXISTS attendance_employee_id_fkey,
+    ADD CONSTRAINT attendance_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT;
+
+ALTER TABLE attendance_history
+    DROP CONSTRAINT IF EXISTS attendance_history_employee_id_fkey,
+    DROP CONSTRAINT IF EXISTS attendance_history_attendance_id_fkey,
+    ADD CONSTRAINT attendance_history_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    ADD CONSTRAINT attendance_history_attendance_id_fkey FOREIGN KEY (attendance_id) REFERENCES attendance(attendance_id) ON DELETE RESTRICT;
@@ -0,0 +1,22 @@
+-- Effective-dated department assignments. starts_at and ends_at bound each
+-- assignment, usually at local midnight or, for a department changed on the
+-- employee record, at the time of the change, so punches can be matched
+-- against them directly; the first assignment has no start and covers older
+-- history, the current one has no end.
+CREATE TABLE IF NOT EXISTS department_assignment (
+    id SERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    departement_id INTEGER NOT NULL REFERENCES departement(id) ON DELETE RESTRICT,
+    effective_from DATE NOT NULL,
+    starts_at TIMESTAMPTZ NULL,
+    ends_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_department_assignment_department ON department_assignment(departement_id);
+
+-- Existing employees have always been in their current department
+INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+SELECT employee_id, departement_id, COALESCE(hire_date, created_at::date), CURRENT_TIMESTAMP
+FROM employee;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_attendance_history_department;
+
+ALTER TABLE attendance_history
+    DROP COLUMN IF EXISTS early_minutes,
+    DROP COLUMN IF EXISTS late_minutes,
+    DROP COLUMN IF EXISTS is_on_time,
+    DROP COLUMN IF EXISTS max_clock_out_time,
+    DROP COLUMN IF EXISTS max_clock_in_time,
+    DROP COLUMN IF EXISTS departement_id;
@@ -0,0 +1,38 @@
+-- Each attendance history row keeps the schedule it was evaluated against and
+-- the result, so later schedule changes and transfers leave history alone
+-- until it is deliberately recomputed.
+ALTER TABLE attendance_history
+    ADD COLUMN departement_id INTEGER NULL,
+    ADD COLUMN max_clock_in_time TIME NULL,
+    ADD COLUMN max_clock_out_time TIME NULL,
+    ADD COLUMN is_on_time BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN late_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN early_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_attendance_history_department ON attendance_history(departement_id);
+
+-- Existing rows take the schedule of the department they fall in today. Their
+-- status comes from the description written at punch time.
+UPDATE attendance_history ah SET departement_id = (
+    SELECT a.departement_id FROM department_assignment a
+    WHERE a.employee_id = ah.employee_id
+        AND (a.starts_at IS NULL OR a.starts_at <= ah.date_attendance)
+        AND (a.ends_at IS NULL OR a.ends_at > ah.date_attendance)
+);
+
+UPDATE attendance_history ah
+SET max_clock_in_time = d.max_clock_in_time,
+    max_clock_out_time = d.max_clock_out_time
+FROM departement d
+WHERE ah.departement_id = d.id;
+
+UPDATE attendance_history
+SET is_on_time = (description IS NULL OR (description NOT LIKE '%(Late)%' AND description NOT LIKE '%(Early)%'));
+
+UPDATE attendance_history
+SET late_minutes = GREATEST(0, CEIL(EXTRACT(EPOCH FROM (date_trunc('second', date_attendance)::time - max_clock_in_time)) / 60))
+WHERE attendance_type = 1 AND NOT is_on_time AND max_clock_in_time IS NOT NULL;
+
+UPDATE attendance_history
+SET early_minutes = GREATEST(0, CEIL(EXTRACT(EPOCH FROM (max_clock_out_time - date_trunc('second', date_attendance)::time)) / 60))
+WHERE attendance_type = 2 AND NOT is_on_time AND max_clock_out_time IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Reporting line: the employee_id of the employee's manager
+ALTER TABLE employee
+    ADD COLUMN manager_id VARCHAR(50) NULL REFERENCES employee(employee_id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_employee_manager ON employee(manager_id);
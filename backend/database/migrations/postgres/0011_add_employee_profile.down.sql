@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS employee_custom_value;
+DROP TABLE IF EXISTS custom_field;
+
+DROP INDEX IF EXISTS idx_employee_employment_type;
+DROP INDEX IF EXISTS idx_employee_email;
+
+ALTER TABLE employee
+    DROP COLUMN IF EXISTS photo_key,
+    DROP COLUMN IF EXISTS work_location,
+    DROP COLUMN IF EXISTS employment_type,
+    DROP COLUMN IF EXISTS job_title,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS email;
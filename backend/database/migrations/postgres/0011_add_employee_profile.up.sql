@@ -0,0 +1,34 @@
+-- Contact and job details. photo_key names the employee's photo in the blob
+-- store.
+ALTER TABLE employee
+    ADD COLUMN email VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN phone VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN job_title VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN employment_type VARCHAR(20) NOT NULL DEFAULT 'full_time',
+    ADD COLUMN work_location VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN photo_key VARCHAR(255) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_employee_email ON employee(email);
+CREATE INDEX IF NOT EXISTS idx_employee_employment_type ON employee(employment_type);
+
+-- Custom fields defined for this deployment. options lists, as a JSON array,
+-- the values a select field accepts.
+CREATE TABLE IF NOT EXISTS custom_field (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    field_type VARCHAR(20) NOT NULL,
+    options TEXT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Custom field values, stored as text in the canonical form of their type
+CREATE TABLE IF NOT EXISTS employee_custom_value (
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id) ON DELETE CASCADE,
+    custom_field_id INTEGER NOT NULL REFERENCES custom_field(id) ON DELETE CASCADE,
+    value TEXT NOT NULL,
+    PRIMARY KEY (employee_id, custom_field_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_custom_value_field ON employee_custom_value(custom_field_id);
@@ -0,0 +1,25 @@
+-- Effective-dated working-time contracts. An employee under a contract is
+-- evaluated against it instead of their department's schedule: they may start
+-- within the start window, must be present during the optional core hours and
+-- owe weekly_hours spread evenly over their working_days. starts_at and
+-- ends_at are the local midnights bounding each contract; the current one has
+-- no end.
+CREATE TABLE IF NOT EXISTS working_time_contract (
+    id SERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    effective_from DATE NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NULL,
+    weekly_hours NUMERIC(5,2) NOT NULL,
+    working_days VARCHAR(30) NOT NULL DEFAULT 'mon,tue,wed,thu,fri',
+    start_window_start TIME NOT NULL,
+    start_window_end TIME NOT NULL,
+    core_start TIME NULL,
+    core_end TIME NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, effective_from)
+);
+
+-- The contract, if any, each punch was evaluated against
+ALTER TABLE attendance_history ADD COLUMN contract_id INTEGER NULL;
@@ -0,0 +1,8 @@
+-- One delivery per webhook and event, so that concurrent enqueues of the
+-- same event cannot queue it twice. Duplicates queued before are dropped,
+-- keeping the oldest.
+DELETE FROM webhook_delivery
+WHERE id NOT IN (SELECT MIN(id) FROM webhook_delivery GROUP BY webhook_id, event_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_event_webhook ON webhook_delivery(event_id, webhook_id);
+DROP INDEX IF EXISTS idx_webhook_delivery_event;
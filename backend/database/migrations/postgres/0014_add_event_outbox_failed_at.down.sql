@@ -0,0 +1 @@
+ALTER TABLE event_outbox DROP COLUMN failed_at;
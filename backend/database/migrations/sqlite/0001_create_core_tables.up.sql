@@ -0,0 +1,57 @@
+-- Core tables based on the provided ERD
+
+CREATE TABLE IF NOT EXISTS departement (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NOT NULL,
+    max_clock_out_time TIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS employee (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id VARCHAR(50) UNIQUE NOT NULL,
+    departement_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (departement_id) REFERENCES departement(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS attendance (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id VARCHAR(50) NOT NULL,
+    attendance_id VARCHAR(100) UNIQUE NOT NULL,
+    clock_in TIMESTAMP NOT NULL,
+    clock_out TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE
+);
+
+-- attendance_type: 1 = In, 2 = Out
+CREATE TABLE IF NOT EXISTS attendance_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id VARCHAR(50) NOT NULL,
+    attendance_id VARCHAR(100) NOT NULL,
+    date_attendance TIMESTAMP NOT NULL,
+    attendance_type TINYINT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE CASCADE,
+    FOREIGN KEY (attendance_id) REFERENCES attendance(attendance_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_department ON employee(departement_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_employee ON attendance(employee_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_date ON attendance(clock_in);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_employee ON attendance_history(employee_id);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_date ON attendance_history(date_attendance);
+CREATE INDEX IF NOT EXISTS idx_attendance_history_type ON attendance_history(attendance_type);
+
+-- SQLite has no ON UPDATE CURRENT_TIMESTAMP; departement rows are updated
+-- without setting updated_at explicitly
+CREATE TRIGGER IF NOT EXISTS trg_departement_updated_at AFTER UPDATE ON departement FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE departement SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id; END;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS export_job (
+    id VARCHAR(36) PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    filters TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    progress INTEGER NOT NULL DEFAULT 0,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    file_name VARCHAR(255),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_job_status ON export_job(status);
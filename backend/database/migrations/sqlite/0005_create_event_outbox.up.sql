@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id VARCHAR(36) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    ordering_key VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(published_at, id);
+CREATE INDEX IF NOT EXISTS idx_event_outbox_ordering ON event_outbox(ordering_key, published_at, id);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_event ON webhook_delivery(event_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurred_at TIMESTAMP NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before_data TEXT NULL,
+    after_data TEXT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id INTEGER PRIMARY KEY,
+    entries INTEGER NOT NULL DEFAULT 0,
+    last_hash CHAR(64) NOT NULL DEFAULT ''
+);
+
+INSERT INTO audit_chain (id, entries, last_hash) VALUES (1, 0, '');
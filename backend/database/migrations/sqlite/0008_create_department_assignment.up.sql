@@ -0,0 +1,26 @@
+-- Effective-dated department assignments. starts_at and ends_at bound each
+-- assignment, usually at local midnight or, for a department changed on the
+-- employee record, at the time of the change, so punches can be matched
+-- against them directly; the first assignment has no start and covers older
+-- history, the current one has no end.
+CREATE TABLE IF NOT EXISTS department_assignment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id VARCHAR(50) NOT NULL,
+    departement_id INTEGER NOT NULL,
+    effective_from DATE NOT NULL,
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, effective_from),
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id) ON DELETE RESTRICT,
+    FOREIGN KEY (departement_id) REFERENCES departement(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_department_assignment_department ON department_assignment(departement_id);
+
+-- Existing employees have always been in their current department. Timestamps
+-- are stored as text starting with the local date, which date() would shift
+-- to UTC.
+INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+SELECT employee_id, departement_id, COALESCE(hire_date, substr(created_at, 1, 10)), CURRENT_TIMESTAMP
+FROM employee;
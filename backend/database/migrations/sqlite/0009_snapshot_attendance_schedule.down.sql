@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_attendance_history_department;
+
+ALTER TABLE attendance_history DROP COLUMN early_minutes;
+ALTER TABLE attendance_history DROP COLUMN late_minutes;
+ALTER TABLE attendance_history DROP COLUMN is_on_time;
+ALTER TABLE attendance_history DROP COLUMN max_clock_out_time;
+ALTER TABLE attendance_history DROP COLUMN max_clock_in_time;
+ALTER TABLE attendance_history DROP COLUMN departement_id;
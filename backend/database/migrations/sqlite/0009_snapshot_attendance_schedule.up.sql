@@ -0,0 +1,34 @@
+-- Each attendance history row keeps the schedule it was evaluated against and
+-- the result, so later schedule changes and transfers leave history alone
+-- until it is deliberately recomputed.
+ALTER TABLE attendance_history ADD COLUMN departement_id INTEGER NULL;
+ALTER TABLE attendance_history ADD COLUMN max_clock_in_time TIME NULL;
+ALTER TABLE attendance_history ADD COLUMN max_clock_out_time TIME NULL;
+ALTER TABLE attendance_history ADD COLUMN is_on_time BOOLEAN NOT NULL DEFAULT 1;
+ALTER TABLE attendance_history ADD COLUMN late_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE attendance_history ADD COLUMN early_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_attendance_history_department ON attendance_history(departement_id);
+
+-- Existing rows take the schedule of the department they fall in today. Their
+-- status comes from the description written at punch time.
+UPDATE attendance_history SET departement_id = (
+    SELECT a.departement_id FROM department_assignment a
+    WHERE a.employee_id = attendance_history.employee_id
+        AND (a.starts_at IS NULL OR a.starts_at <= attendance_history.date_attendance)
+        AND (a.ends_at IS NULL OR a.ends_at > attendance_history.date_attendance)
+);
+
+UPDATE attendance_history SET
+    max_clock_in_time = (SELECT d.max_clock_in_time FROM departement d WHERE d.id = attendance_history.departement_id),
+    max_clock_out_time = (SELECT d.max_clock_out_time FROM departement d WHERE d.id = attendance_history.departement_id),
+    is_on_time = (description IS NULL OR (description NOT LIKE '%(Late)%' AND description NOT LIKE '%(Early)%'));
+
+-- Timestamps are stored as text starting with the local date and time
+UPDATE attendance_history
+SET late_minutes = MAX(0, (strftime('%s', '2000-01-01 ' || substr(date_attendance, 12, 8)) - strftime('%s', '2000-01-01 ' || max_clock_in_time) + 59) / 60)
+WHERE attendance_type = 1 AND is_on_time = 0 AND max_clock_in_time IS NOT NULL;
+
+UPDATE attendance_history
+SET early_minutes = MAX(0, (strftime('%s', '2000-01-01 ' || max_clock_out_time) - strftime('%s', '2000-01-01 ' || substr(date_attendance, 12, 8)) + 59) / 60)
+WHERE attendance_type = 2 AND is_on_time = 0 AND max_clock_out_time IS NOT NULL;
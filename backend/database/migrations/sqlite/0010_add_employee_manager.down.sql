@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_employee_manager;
+
+ALTER TABLE employee DROP COLUMN manager_id;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS employee_custom_value;
+DROP TABLE IF EXISTS custom_field;
+
+DROP INDEX IF EXISTS idx_employee_employment_type;
+DROP INDEX IF EXISTS idx_employee_email;
+ALTER TABLE employee DROP COLUMN photo_key;
+ALTER TABLE employee DROP COLUMN work_location;
+ALTER TABLE employee DROP COLUMN employment_type;
+ALTER TABLE employee DROP COLUMN job_title;
+ALTER TABLE employee DROP COLUMN phone;
+ALTER TABLE employee DROP COLUMN email;
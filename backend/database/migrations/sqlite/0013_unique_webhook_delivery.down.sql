@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_event ON webhook_delivery(event_id);
+DROP INDEX IF EXISTS idx_webhook_delivery_event_webhook;
@@ -0,0 +1,3 @@
+-- Set when an event has failed outbox.max_attempts times; dead events are no
+-- longer relayed and no longer hold back later events with their key
+ALTER TABLE event_outbox ADD COLUMN failed_at TIMESTAMP NULL;
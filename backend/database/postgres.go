@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+func init() {
+	sql.Register(string(DialectPostgres), &postgresDriver{})
+}
+
+// postgresDriver wraps the pgx driver so that queries written with MySQL
+// style ? placeholders run unchanged on PostgreSQL
+type postgresDriver struct{}
+
+// Open opens a pgx connection that rewrites placeholders
+func (d *postgresDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := stdlib.GetDefaultDriver().Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn.(*stdlib.Conn)}, nil
+}
+
+// postgresConn rebinds placeholders before handing queries to pgx
+type postgresConn struct {
+	*stdlib.Conn
+}
+
+func (c *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c *postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.PrepareContext(ctx, rebind(query))
+}
+
+func (c *postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.ExecContext(ctx, rebind(query), args)
+}
+
+func (c *postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.QueryContext(ctx, rebind(query), args)
+}
+
+// rebind replaces ? placeholders with PostgreSQL's $1, $2, ... leaving
+// string literals, quoted identifiers and comments untouched
+func rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(query[i+1:], ch)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+1])
+			i += end
+		case ch == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// InsertReturningID runs an INSERT into a table with a generated id column
+// and returns the new id. PostgreSQL does not support LastInsertId, so the id
+// is read back with RETURNING there.
+func InsertReturningID(db *sql.DB, query string, args ...interface{}) (int64, error) {
+	return insertReturningID(db, db, query, args...)
+}
+
+// TxInsertReturningID is InsertReturningID within tx, a transaction on db
+func TxInsertReturningID(db *sql.DB, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	return insertReturningID(db, tx, query, args...)
+}
+
+// querier runs statements on a database or in a transaction
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func insertReturningID(db *sql.DB, q querier, query string, args ...interface{}) (int64, error) {
+	if _, ok := db.Driver().(*postgresDriver); ok {
+		var id int64
+		err := q.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
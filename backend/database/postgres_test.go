@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM employee WHERE id = ?", "SELECT * FROM employee WHERE id = $1"},
+		{
+			"UPDATE employee SET name = ?, address = ? WHERE id = ?",
+			"UPDATE employee SET name = $1, address = $2 WHERE id = $3",
+		},
+		{"SELECT '?' FROM t WHERE a = ?", "SELECT '?' FROM t WHERE a = $1"},
+		{`SELECT "col?" FROM t WHERE a = ?`, `SELECT "col?" FROM t WHERE a = $1`},
+		{"SELECT 'it''s ?' WHERE a = ?", "SELECT 'it''s ?' WHERE a = $1"},
+		{"-- why?\nSELECT ?", "-- why?\nSELECT $1"},
+		{"SELECT 'unterminated ?", "SELECT 'unterminated ?"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, rebind(tt.query), tt.query)
+	}
+}
@@ -0,0 +1,19 @@
+-- Sample departments and employees
+
+INSERT INTO departement (departement_name, max_clock_in_time, max_clock_out_time) VALUES
+('IT Department', '08:30:00', '17:30:00'),
+('HR Department', '08:00:00', '17:00:00'),
+('Finance Department', '08:15:00', '17:15:00'),
+('Marketing Department', '09:00:00', '18:00:00'),
+('Operations Department', '07:30:00', '16:30:00');
+
+INSERT INTO employee (employee_id, departement_id, name, address) VALUES
+('EMP001', 1, 'John Doe', '123 Main Street, City'),
+('EMP002', 1, 'Jane Smith', '456 Oak Avenue, Town'),
+('EMP003', 2, 'Bob Johnson', '789 Pine Road, Village'),
+('EMP004', 3, 'Alice Brown', '321 Elm Street, Borough'),
+('EMP005', 4, 'Charlie Wilson', '654 Maple Drive, District');
+
+-- Each employee has been in their department since before the sample attendance
+INSERT INTO department_assignment (employee_id, departement_id, effective_from)
+SELECT employee_id, departement_id, '2024-01-01' FROM employee;
@@ -1,8 +1,6 @@
 -- Sample Attendance Data for Dashboard
 -- This will populate the dashboard with some attendance records
 
-USE attendance_system;
-
 -- Insert sample attendance records for today
 INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at) VALUES
 ('EMP001', 'att_001_today', NOW() - INTERVAL 2 HOUR, NOW(), NOW()),
@@ -48,3 +46,12 @@ INSERT INTO attendance_history (employee_id, attendance_id, date_attendance, att
 ('EMP003', 'att_003_yesterday', DATE_SUB(NOW(), INTERVAL 1 DAY) + INTERVAL 17 HOUR, 2, 'Clock Out', NOW(), NOW()),
 ('EMP004', 'att_004_yesterday', DATE_SUB(NOW(), INTERVAL 1 DAY) + INTERVAL 17 HOUR, 2, 'Clock Out', NOW(), NOW()),
 ('EMP005', 'att_005_yesterday', DATE_SUB(NOW(), INTERVAL 1 DAY) + INTERVAL 17 HOUR, 2, 'Clock Out', NOW(), NOW());
+
+-- Record the schedule each sample punch was evaluated against
+UPDATE attendance_history ah
+JOIN employee e ON ah.employee_id = e.employee_id
+JOIN departement d ON e.departement_id = d.id
+SET ah.departement_id = d.id,
+    ah.max_clock_in_time = d.max_clock_in_time,
+    ah.max_clock_out_time = d.max_clock_out_time
+WHERE ah.departement_id IS NULL;
@@ -0,0 +1,98 @@
+package errcodes
+
+// Code is the stable, machine-readable kind of an API error. Clients should
+// branch on it rather than on HTTP statuses or messages, which may change.
+type Code string
+
+// General errors
+const (
+	InvalidRequest   Code = "INVALID_REQUEST"
+	ValidationFailed Code = "VALIDATION_FAILED"
+	RouteNotFound    Code = "ROUTE_NOT_FOUND"
+	Internal         Code = "INTERNAL_ERROR"
+)
+
+// Employee errors
+const (
+	EmployeeNotFound   Code = "EMPLOYEE_NOT_FOUND"
+	EmployeeIDExists   Code = "EMPLOYEE_ID_EXISTS"
+	EmployeeNotActive  Code = "EMPLOYEE_NOT_ACTIVE"
+	EmployeeHasReports Code = "EMPLOYEE_HAS_REPORTS"
+	ManagerNotFound    Code = "MANAGER_NOT_FOUND"
+	ManagerCycle       Code = "MANAGER_CYCLE"
+	OutsideTeam        Code = "OUTSIDE_TEAM"
+	PhotoNotFound      Code = "PHOTO_NOT_FOUND"
+)
+
+// Working-time contract errors
+const (
+	ContractNotFound Code = "CONTRACT_NOT_FOUND"
+)
+
+// Custom field errors
+const (
+	CustomFieldNotFound Code = "CUSTOM_FIELD_NOT_FOUND"
+	CustomFieldExists   Code = "CUSTOM_FIELD_EXISTS"
+)
+
+// Department errors
+const (
+	DepartmentNotFound     Code = "DEPARTMENT_NOT_FOUND"
+	DepartmentHasEmployees Code = "DEPARTMENT_HAS_EMPLOYEES"
+	// DepartmentHasHistory is permanent: assignments are never removed, so a
+	// department anyone has ever belonged to cannot be deleted
+	DepartmentHasHistory Code = "DEPARTMENT_HAS_HISTORY"
+)
+
+// Attendance errors
+const (
+	AlreadyClockedIn  Code = "ALREADY_CLOCKED_IN"
+	AlreadyClockedOut Code = "ALREADY_CLOCKED_OUT"
+	NotClockedIn      Code = "NOT_CLOCKED_IN"
+	InvalidFilters    Code = "INVALID_FILTERS"
+)
+
+// Import errors
+const (
+	ImportFileRequired Code = "IMPORT_FILE_REQUIRED"
+	ImportFileInvalid  Code = "IMPORT_FILE_INVALID"
+	ImportRowsInvalid  Code = "IMPORT_ROWS_INVALID"
+)
+
+// Export job errors
+const (
+	ExportJobNotFound     Code = "EXPORT_JOB_NOT_FOUND"
+	ExportJobNotCompleted Code = "EXPORT_JOB_NOT_COMPLETED"
+	ExportFileExpired     Code = "EXPORT_FILE_EXPIRED"
+	ExportQueueFull       Code = "EXPORT_QUEUE_FULL"
+)
+
+// Report schedule errors
+const (
+	ReportScheduleNotFound Code = "REPORT_SCHEDULE_NOT_FOUND"
+	InvalidCronExpression  Code = "INVALID_CRON_EXPRESSION"
+	ReportDeliveryFailed   Code = "REPORT_DELIVERY_FAILED"
+)
+
+// Webhook errors
+const (
+	WebhookNotFound         Code = "WEBHOOK_NOT_FOUND"
+	WebhookDeliveryNotFound Code = "WEBHOOK_DELIVERY_NOT_FOUND"
+)
+
+// All lists every code, for documentation. A test checks it against the
+// constants declared above.
+func All() []Code {
+	return []Code{
+		InvalidRequest, ValidationFailed, RouteNotFound, Internal,
+		EmployeeNotFound, EmployeeIDExists, EmployeeNotActive, EmployeeHasReports, ManagerNotFound, ManagerCycle,
+		OutsideTeam, PhotoNotFound, ContractNotFound,
+		CustomFieldNotFound, CustomFieldExists,
+		DepartmentNotFound, DepartmentHasEmployees, DepartmentHasHistory,
+		AlreadyClockedIn, AlreadyClockedOut, NotClockedIn, InvalidFilters,
+		ImportFileRequired, ImportFileInvalid, ImportRowsInvalid,
+		ExportJobNotFound, ExportJobNotCompleted, ExportFileExpired, ExportQueueFull,
+		ReportScheduleNotFound, InvalidCronExpression, ReportDeliveryFailed,
+		WebhookNotFound, WebhookDeliveryNotFound,
+	}
+}
@@ -0,0 +1,54 @@
+package errcodes
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// declaredCodes returns the value of every Code constant declared in
+// errcodes.go
+func declaredCodes(t *testing.T) []Code {
+	file, err := parser.ParseFile(token.NewFileSet(), "errcodes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []Code
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Code" {
+				continue
+			}
+			for _, expr := range value.Values {
+				code, err := strconv.Unquote(expr.(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				codes = append(codes, Code(code))
+			}
+		}
+	}
+	return codes
+}
+
+func TestAllListsEveryCode(t *testing.T) {
+	declared := declaredCodes(t)
+	assert.NotEmpty(t, declared)
+	assert.ElementsMatch(t, declared, All())
+
+	seen := make(map[Code]bool)
+	for _, code := range declared {
+		assert.False(t, seen[code], "%s is declared twice", code)
+		seen[code] = true
+	}
+}
@@ -2,11 +2,20 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/metrics"
 	"attendance-system/models"
 	"attendance-system/services"
 
@@ -14,100 +23,159 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// streamRetry is how long stream clients wait before reconnecting
+	streamRetry = 3 * time.Second
+	// streamReplayPage is how many history rows are read at a time when a
+	// stream client resumes
+	streamReplayPage = 500
+)
+
 // AttendanceHandler handles attendance-related HTTP requests
 type AttendanceHandler struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *services.Outbox
+	feed   *services.AttendanceFeed
+	// heartbeat is how often an idle stream sends a comment to keep
+	// proxies from closing it
+	heartbeat time.Duration
+	// exportDir receives CSV downloads until the export retention sweep
+	// removes them
+	exportDir string
 }
 
-// NewAttendanceHandler creates a new attendance handler
-func NewAttendanceHandler(db *sql.DB) *AttendanceHandler {
-	return &AttendanceHandler{db: db}
+// NewAttendanceHandler creates a new attendance handler. Clock-ins and
+// clock-outs are recorded in outbox and, once committed, published to feed
+// for stream clients; CSV downloads are written to exportDir.
+func NewAttendanceHandler(db *sql.DB, outbox *services.Outbox, feed *services.AttendanceFeed, exportDir string) *AttendanceHandler {
+	return &AttendanceHandler{db: db, outbox: outbox, feed: feed, heartbeat: 15 * time.Second, exportDir: exportDir}
 }
 
 // ClockIn handles employee clock in
 func (h *AttendanceHandler) ClockIn(c *gin.Context) {
 	var req models.ClockInRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// Check if employee exists
-	var employeeID string
-	var departmentID int
-	var maxClockInTime string
-	err := h.db.QueryRow(`
-		SELECT e.employee_id, e.departement_id, d.max_clock_in_time
+	var dept models.Department
+	employee, err := services.ScanEmployee(h.db.QueryRow(`
+		SELECT `+services.EmployeeColumns+`, d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time
 		FROM employee e
 		LEFT JOIN departement d ON e.departement_id = d.id
 		WHERE e.employee_id = ?
-	`, req.EmployeeID).Scan(&employeeID, &departmentID, &maxClockInTime)
+	`, req.EmployeeID), &dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
 		return
 	}
 
 	now := time.Now()
-	today := now.Format("2006-01-02")
+
+	// Only employees expected at work today may clock in
+	if reason := services.InactiveReason(employee, now); reason != "" {
+		respondError(c, http.StatusConflict, errcodes.EmployeeNotActive, "Cannot clock in: "+reason, nil)
+		return
+	}
+	dayStart, dayEnd := services.DayBounds(now)
 
 	// Check if already clocked in today
 	var existingAttendanceID string
 	err = h.db.QueryRow(`
 		SELECT attendance_id FROM attendance 
-		WHERE employee_id = ? AND DATE(clock_in) = ?
-	`, req.EmployeeID, today).Scan(&existingAttendanceID)
+		WHERE employee_id = ? AND clock_in >= ? AND clock_in < ?
+	`, req.EmployeeID, dayStart, dayEnd).Scan(&existingAttendanceID)
 
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Already clocked in today"})
+		respondError(c, http.StatusConflict, errcodes.AlreadyClockedIn, "Already clocked in today", nil)
 		return
 	}
 
 	// Generate attendance ID
 	attendanceID := uuid.New().String()
 
-	// Check if on time
-	isOnTime := true
-	if maxClockInTime != "" {
-		maxTime, err := time.Parse("15:04:05", maxClockInTime)
-		if err == nil {
-			currentTime := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
-			maxTimeToday := time.Date(now.Year(), now.Month(), now.Day(), maxTime.Hour(), maxTime.Minute(), maxTime.Second(), 0, now.Location())
-			isOnTime = currentTime.Before(maxTimeToday) || currentTime.Equal(maxTimeToday)
-		}
+	// Evaluate against the contract or schedule in force now; it is stored
+	// with the punch
+	contract, err := services.ContractOn(h.db, req.EmployeeID, now)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch working-time contract", err)
+		return
+	}
+	punctuality := services.EvaluatePunch(1, now, dept, contract)
+	isOnTime := punctuality.IsOnTime
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to clock in", err)
+		return
 	}
+	defer tx.Rollback()
 
 	// Insert attendance record
-	_, err = h.db.Exec(`
+	id, err := database.TxInsertReturningID(h.db, tx, `
 		INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?)
 	`, req.EmployeeID, attendanceID, now, now, now)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clock in"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to clock in", err)
 		return
 	}
 
 	// Insert attendance history
-	description := "Clock In"
-	if !isOnTime {
-		description = "Clock In (Late)"
-	}
-
-	_, err = h.db.Exec(`
-		INSERT INTO attendance_history (employee_id, attendance_id, date_attendance, attendance_type, description, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, req.EmployeeID, attendanceID, now, 1, description, now, now)
+	historyID, err := services.RecordPunch(h.db, tx, req.EmployeeID, attendanceID, 1, now, punctuality, now)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance history"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create attendance history", err)
 		return
 	}
 
+	event := models.AttendanceEvent{
+		HistoryID:      int(historyID),
+		AttendanceID:   attendanceID,
+		AttendanceType: 1,
+		EmployeeID:     req.EmployeeID,
+		EmployeeName:   employee.Name,
+		DepartementID:  dept.ID,
+		DepartmentName: dept.DepartementName,
+		Time:           now,
+		IsOnTime:       isOnTime,
+		LateMinutes:    punctuality.LateMinutes,
+		Description:    punctuality.Description,
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityAttendance,
+		EntityID:   attendanceID,
+		After: models.Attendance{
+			ID:           int(id),
+			EmployeeID:   req.EmployeeID,
+			AttendanceID: attendanceID,
+			ClockIn:      now,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		},
+	}
+	if !recordAudit(c, h.db, tx, "Failed to clock in", change) {
+		return
+	}
+	events := []models.Event{services.NewEvent(models.EventAttendanceClockIn, event)}
+	if !isOnTime {
+		events = append(events, services.NewEvent(models.EventAttendanceLate, event))
+	}
+	if !commitEvents(c, h.outbox, tx, "Failed to clock in", services.EmployeeEventKey(req.EmployeeID), events...) {
+		return
+	}
+
+	metrics.RecordClockIn(dept.DepartementName, isOnTime)
+	h.feed.Publish(events[0])
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Clock in successful",
 		"attendance_id": attendanceID,
@@ -119,51 +187,54 @@ func (h *AttendanceHandler) ClockIn(c *gin.Context) {
 // ClockOut handles employee clock out
 func (h *AttendanceHandler) ClockOut(c *gin.Context) {
 	var req models.ClockOutRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// Check if employee exists
-	var employeeID string
-	var departmentID int
-	var maxClockOutTime string
+	var employeeID, employeeName string
+	var dept models.Department
 	err := h.db.QueryRow(`
-		SELECT e.employee_id, e.departement_id, d.max_clock_out_time
+		SELECT e.employee_id, e.name, d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time
 		FROM employee e
 		LEFT JOIN departement d ON e.departement_id = d.id
 		WHERE e.employee_id = ?
-	`, req.EmployeeID).Scan(&employeeID, &departmentID, &maxClockOutTime)
+	`, req.EmployeeID).Scan(&employeeID, &employeeName, &dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
 		return
 	}
 
 	now := time.Now()
-	today := now.Format("2006-01-02")
+	dayStart, dayEnd := services.DayBounds(now)
 
 	// Check if already clocked in today and not clocked out
-	var attendanceID string
-	var clockInTime time.Time
+	var attendance models.Attendance
 	err = h.db.QueryRow(`
-		SELECT attendance_id, clock_in FROM attendance 
-		WHERE employee_id = ? AND DATE(clock_in) = ? AND clock_out IS NULL
-	`, req.EmployeeID, today).Scan(&attendanceID, &clockInTime)
+		SELECT id, employee_id, attendance_id, clock_in, created_at, updated_at FROM attendance 
+		WHERE employee_id = ? AND clock_in >= ? AND clock_in < ? AND clock_out IS NULL
+	`, req.EmployeeID, dayStart, dayEnd).Scan(
+		&attendance.ID, &attendance.EmployeeID, &attendance.AttendanceID, &attendance.ClockIn,
+		&attendance.CreatedAt, &attendance.UpdatedAt,
+	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "No active clock in found for today"})
+			respondError(c, http.StatusBadRequest, errcodes.NotClockedIn, "No active clock in found for today", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch attendance", err)
 		return
 	}
 
+	attendanceID := attendance.AttendanceID
+	clockInTime := attendance.ClockIn
+
 	// Check if already clocked out
 	var clockOutTime *time.Time
 	err = h.db.QueryRow(`
@@ -171,50 +242,85 @@ func (h *AttendanceHandler) ClockOut(c *gin.Context) {
 		WHERE attendance_id = ?
 	`, attendanceID).Scan(&clockOutTime)
 
-	if err == nil && clockOutTime != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Already clocked out today"})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch attendance", err)
+		return
+	}
+	if clockOutTime != nil {
+		respondError(c, http.StatusConflict, errcodes.AlreadyClockedOut, "Already clocked out today", nil)
 		return
 	}
 
-	// Check if on time for clock out
-	isOnTime := true
-	if maxClockOutTime != "" {
-		maxTime, err := time.Parse("15:04:05", maxClockOutTime)
-		if err == nil {
-			currentTime := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
-			maxTimeToday := time.Date(now.Year(), now.Month(), now.Day(), maxTime.Hour(), maxTime.Minute(), maxTime.Second(), 0, now.Location())
-			isOnTime = currentTime.After(maxTimeToday) || currentTime.Equal(maxTimeToday)
-		}
+	// Evaluate against the contract or schedule in force now; it is stored
+	// with the punch
+	contract, err := services.ContractOn(h.db, req.EmployeeID, now)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch working-time contract", err)
+		return
+	}
+	punctuality := services.EvaluatePunch(2, now, dept, contract)
+	isOnTime := punctuality.IsOnTime
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to clock out", err)
+		return
 	}
+	defer tx.Rollback()
 
 	// Update attendance record
-	_, err = h.db.Exec(`
+	_, err = tx.Exec(`
 		UPDATE attendance 
 		SET clock_out = ?, updated_at = ?
 		WHERE attendance_id = ?
 	`, now, now, attendanceID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clock out"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to clock out", err)
 		return
 	}
 
 	// Insert attendance history
-	description := "Clock Out"
-	if !isOnTime {
-		description = "Clock Out (Early)"
-	}
-
-	_, err = h.db.Exec(`
-		INSERT INTO attendance_history (employee_id, attendance_id, date_attendance, attendance_type, description, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, req.EmployeeID, attendanceID, now, 2, description, now, now)
+	historyID, err := services.RecordPunch(h.db, tx, req.EmployeeID, attendanceID, 2, now, punctuality, now)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance history"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create attendance history", err)
 		return
 	}
 
+	clockedOut := attendance
+	clockedOut.ClockOut = &now
+	clockedOut.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityAttendance,
+		EntityID:   attendanceID,
+		Before:     attendance,
+		After:      clockedOut,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to clock out", change) {
+		return
+	}
+	event := services.NewEvent(models.EventAttendanceClockOut, models.AttendanceEvent{
+		HistoryID:      int(historyID),
+		AttendanceID:   attendanceID,
+		AttendanceType: 2,
+		EmployeeID:     req.EmployeeID,
+		EmployeeName:   employeeName,
+		DepartementID:  dept.ID,
+		DepartmentName: dept.DepartementName,
+		Time:           now,
+		IsOnTime:       isOnTime,
+		EarlyMinutes:   punctuality.EarlyMinutes,
+		Description:    punctuality.Description,
+	})
+	if !commitEvents(c, h.outbox, tx, "Failed to clock out", services.EmployeeEventKey(req.EmployeeID), event) {
+		return
+	}
+
+	metrics.RecordClockOut(dept.DepartementName, isOnTime)
+	h.feed.Publish(event)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "Clock out successful",
 		"attendance_id":  attendanceID,
@@ -227,190 +333,66 @@ func (h *AttendanceHandler) ClockOut(c *gin.Context) {
 // GetAttendanceLogs retrieves attendance logs with filtering
 func (h *AttendanceHandler) GetAttendanceLogs(c *gin.Context) {
 	var filter models.AttendanceFilter
-	if err := c.ShouldBindQuery(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Build query with filters
-	query := `
-		SELECT 
-			ah.id,
-			ah.employee_id,
-			e.name as employee_name,
-			e.departement_id as department_id,
-			d.departement_name as department_name,
-			ah.attendance_id,
-			ah.date_attendance,
-			ah.attendance_type,
-			ah.description,
-			d.max_clock_in_time,
-			d.max_clock_out_time,
-			ah.created_at,
-			CASE 
-				WHEN ah.attendance_type = 1 THEN 
-					CASE 
-						WHEN TIME(ah.date_attendance) <= d.max_clock_in_time THEN 1
-						ELSE 0
-					END
-				WHEN ah.attendance_type = 2 THEN 
-					CASE 
-						WHEN TIME(ah.date_attendance) >= d.max_clock_out_time THEN 1
-						ELSE 0
-					END
-				ELSE 0
-			END as is_on_time
-		FROM attendance_history ah
-		LEFT JOIN employee e ON ah.employee_id = e.employee_id
-		LEFT JOIN departement d ON e.departement_id = d.id
-		WHERE 1=1
-	`
-
-	var args []interface{}
-
-	// Add date filter
-	if filter.Date != "" {
-		query += " AND DATE(ah.date_attendance) = ?"
-		args = append(args, filter.Date)
+	if !bindQuery(c, &filter) {
+		return
 	}
 
-	// Add department filter
-	if filter.DepartmentID > 0 {
-		query += " AND e.departement_id = ?"
-		args = append(args, filter.DepartmentID)
+	logs, ok := h.queryLogs(c, filter)
+	if !ok {
+		return
 	}
 
-	query += " ORDER BY ah.date_attendance DESC"
+	c.JSON(http.StatusOK, gin.H{
+		"attendance_logs": logs,
+		"count":           len(logs),
+		"filters":         filter,
+	})
+}
+
+// GetAbsences lists the weekdays in a date range on which employees expected
+// at work did not clock in
+func (h *AttendanceHandler) GetAbsences(c *gin.Context) {
+	var filter models.AbsenceFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+	if !scopeToTeam(c, h.db, &filter.ManagerID, "Failed to fetch absences") {
+		return
+	}
 
-	rows, err := h.db.Query(query, args...)
+	absences, err := services.QueryAbsences(h.db, models.ExportFilters{
+		StartDate:    filter.StartDate,
+		EndDate:      filter.EndDate,
+		DepartmentID: filter.DepartmentID,
+		ManagerID:    filter.ManagerID,
+		DirectOnly:   filter.DirectOnly,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance logs"})
-		return
-	}
-	defer rows.Close()
-
-	var logs []models.AttendanceLog
-	for rows.Next() {
-		var log models.AttendanceLog
-		var isOnTimeInt int
-		err := rows.Scan(
-			&log.ID,
-			&log.EmployeeID,
-			&log.EmployeeName,
-			&log.DepartmentID,
-			&log.DepartmentName,
-			&log.AttendanceID,
-			&log.DateAttendance,
-			&log.AttendanceType,
-			&log.Description,
-			&log.MaxClockInTime,
-			&log.MaxClockOutTime,
-			&log.CreatedAt,
-			&isOnTimeInt,
-		)
-		if err != nil {
-			continue
+		if errors.Is(err, services.ErrInvalidExportFilters) {
+			respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, err.Error(), nil)
+			return
 		}
-		log.IsOnTime = isOnTimeInt == 1
-		logs = append(logs, log)
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch absences", err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"attendance_logs": logs,
-		"count":           len(logs),
-		"filters":         filter,
+		"absences": absences,
+		"count":    len(absences),
+		"filters":  filter,
 	})
 }
 
 // ExportAttendanceLogsCSV exports attendance logs to CSV file
 func (h *AttendanceHandler) ExportAttendanceLogsCSV(c *gin.Context) {
 	var filter models.AttendanceFilter
-	if err := c.ShouldBindQuery(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Build query with filters (same as GetAttendanceLogs)
-	query := `
-		SELECT 
-			ah.id,
-			ah.employee_id,
-			e.name as employee_name,
-			e.departement_id as department_id,
-			d.departement_name as department_name,
-			ah.attendance_id,
-			ah.date_attendance,
-			ah.attendance_type,
-			ah.description,
-			d.max_clock_in_time,
-			d.max_clock_out_time,
-			ah.created_at,
-			CASE 
-				WHEN ah.attendance_type = 1 THEN 
-					CASE 
-						WHEN TIME(ah.date_attendance) <= d.max_clock_in_time THEN 1
-						ELSE 0
-					END
-				WHEN ah.attendance_type = 2 THEN 
-					CASE 
-						WHEN TIME(ah.date_attendance) >= d.max_clock_out_time THEN 1
-						ELSE 0
-					END
-				ELSE 0
-			END as is_on_time
-		FROM attendance_history ah
-		LEFT JOIN employee e ON ah.employee_id = e.employee_id
-		LEFT JOIN departement d ON e.departement_id = d.id
-		WHERE 1=1
-	`
-
-	var args []interface{}
-
-	// Add date filter
-	if filter.Date != "" {
-		query += " AND DATE(ah.date_attendance) = ?"
-		args = append(args, filter.Date)
-	}
-
-	// Add department filter
-	if filter.DepartmentID > 0 {
-		query += " AND e.departement_id = ?"
-		args = append(args, filter.DepartmentID)
+	if !bindQuery(c, &filter) {
+		return
 	}
 
-	query += " ORDER BY ah.date_attendance DESC"
-
-	rows, err := h.db.Query(query, args...)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance logs"})
-		return
-	}
-	defer rows.Close()
-
-	var logs []models.AttendanceLog
-	for rows.Next() {
-		var log models.AttendanceLog
-		var isOnTimeInt int
-		err := rows.Scan(
-			&log.ID,
-			&log.EmployeeID,
-			&log.EmployeeName,
-			&log.DepartmentID,
-			&log.DepartmentName,
-			&log.AttendanceID,
-			&log.DateAttendance,
-			&log.AttendanceType,
-			&log.Description,
-			&log.MaxClockInTime,
-			&log.MaxClockOutTime,
-			&log.CreatedAt,
-			&isOnTimeInt,
-		)
-		if err != nil {
-			continue
-		}
-		log.IsOnTime = isOnTimeInt == 1
-		logs = append(logs, log)
+	logs, ok := h.queryLogs(c, filter)
+	if !ok {
+		return
 	}
 
 	// Create CSV export service
@@ -418,17 +400,17 @@ func (h *AttendanceHandler) ExportAttendanceLogsCSV(c *gin.Context) {
 
 	// Generate filename
 	filename := csvService.GenerateFilename("attendance_logs")
-	filepath := filepath.Join("exports", filename)
+	filepath := filepath.Join(h.exportDir, filename)
 
 	// Create exports directory if it doesn't exist
-	if err := os.MkdirAll("exports", 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exports directory"})
+	if err := os.MkdirAll(h.exportDir, 0755); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create exports directory", err)
 		return
 	}
 
 	// Export to CSV
 	if err := csvService.ExportAttendanceLogs(logs, filepath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export CSV"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to export CSV", err)
 		return
 	}
 
@@ -444,3 +426,164 @@ func (h *AttendanceHandler) ExportAttendanceLogsCSV(c *gin.Context) {
 	// Send file
 	c.File(filepath)
 }
+
+// ImportPunches imports historical punch logs (employee code, timestamp,
+// direction) exported by legacy time clocks. The default dry-run mode reports
+// what would be imported; mode=commit writes the sessions.
+func (h *AttendanceHandler) ImportPunches(c *gin.Context) {
+	rows, mode, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewPunchImportService(h.db).Import(rows, mode, auditActor(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to import punches", err)
+		return
+	}
+
+	respondImport(c, report.Mode, report.ErrorRows, report, "Punches imported successfully")
+}
+
+// StreamAttendance streams clock-ins and clock-outs as Server-Sent Events,
+// optionally for one department. Event IDs are attendance history IDs, so a
+// client reconnecting with Last-Event-ID first receives what it missed.
+func (h *AttendanceHandler) StreamAttendance(c *gin.Context) {
+	var filter models.AttendanceStreamFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	// Browsers resend the last ID as a header; the query parameter is for
+	// clients that cannot set headers
+	lastID := filter.LastEventID
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil || id < 0 {
+			respondInvalidField(c, errcodes.InvalidRequest, "Last-Event-ID", "Last-Event-ID must be a non-negative integer")
+			return
+		}
+		lastID = id
+	}
+
+	// The team is fixed when the stream opens; reconnecting picks up changes
+	if !scopeToTeam(c, h.db, &filter.ManagerID, "Failed to open attendance stream") {
+		return
+	}
+	var team map[string]bool
+	if filter.ManagerID != "" {
+		members, err := services.TeamMembers(h.db, filter.ManagerID, filter.DirectOnly)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to open attendance stream", err)
+			return
+		}
+		team = make(map[string]bool, len(members))
+		for _, employeeID := range members {
+			team[employeeID] = true
+		}
+	}
+	inTeam := func(employeeID string) bool {
+		return team == nil || team[employeeID]
+	}
+
+	// Subscribe before replaying so nothing recorded in between is lost
+	sub := h.feed.Subscribe(filter.DepartmentID)
+	defer sub.Close()
+
+	// Streams outlive the server write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	c.Writer.Flush()
+
+	if lastID > 0 {
+		for {
+			logs, err := services.AttendanceLogsAfter(h.db, lastID, filter.DepartmentID, streamReplayPage)
+			if err != nil {
+				logging.FromContext(c).Error("failed to replay attendance history", "error", err)
+				return
+			}
+			for _, log := range logs {
+				if inTeam(log.EmployeeID) && !writeStreamEvent(c, services.AttendanceEventFromLog(log)) {
+					return
+				}
+				lastID = log.ID
+			}
+			if len(logs) < streamReplayPage {
+				break
+			}
+		}
+	}
+	replayedID := lastID
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				// Fell too far behind; the client reconnects and catches up
+				return
+			}
+			if event.HistoryID <= replayedID || !inTeam(event.EmployeeID) {
+				continue
+			}
+			if !writeStreamEvent(c, event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes event as a Server-Sent Event, returning false once
+// the client has gone
+func writeStreamEvent(c *gin.Context, event models.AttendanceEvent) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logging.FromContext(c).Error("failed to encode attendance event", "error", err)
+		return false
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n",
+		event.HistoryID, services.AttendanceEventType(event.AttendanceType), data)
+	if err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// queryLogs fetches the attendance logs matching filter, writing an error
+// response and returning false on failure
+func (h *AttendanceHandler) queryLogs(c *gin.Context, filter models.AttendanceFilter) ([]models.AttendanceLog, bool) {
+	if !scopeToTeam(c, h.db, &filter.ManagerID, "Failed to fetch attendance logs") {
+		return nil, false
+	}
+	logs, err := services.QueryAttendanceLogs(h.db, models.ExportFilters{
+		Date:         filter.Date,
+		DepartmentID: filter.DepartmentID,
+		ManagerID:    filter.ManagerID,
+		DirectOnly:   filter.DirectOnly,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExportFilters) {
+			respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, err.Error(), nil)
+			return nil, false
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch attendance logs", err)
+		return nil, false
+	}
+	return logs, true
+}
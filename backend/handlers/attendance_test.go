@@ -0,0 +1,557 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAttendanceRouter(db *sql.DB, outbox *services.Outbox) *gin.Engine {
+	r := setupTestRouter(db, outbox)
+
+	departmentHandler := NewDepartmentHandler(db, outbox, os.TempDir())
+	customFieldHandler := NewCustomFieldHandler(db)
+	contractHandler := NewContractHandler(db)
+	attendanceHandler := NewAttendanceHandler(db, outbox, services.NewAttendanceFeed(), os.TempDir())
+	attendanceHandler.heartbeat = 20 * time.Millisecond
+	exportHandler := NewExportHandler(db, services.NewExportJobService(db, os.TempDir(), 1, time.Hour))
+
+	api := r.Group("/api/v1")
+	{
+		departments := api.Group("/departments")
+		{
+			departments.POST("/", departmentHandler.CreateDepartment)
+			departments.GET("/:id", departmentHandler.GetDepartment)
+			departments.PUT("/:id", departmentHandler.UpdateDepartment)
+			departments.DELETE("/:id", departmentHandler.DeleteDepartment)
+		}
+
+		employees := api.Group("/employees")
+		{
+			employees.GET("/:id/contracts", contractHandler.GetContracts)
+			employees.POST("/:id/contracts", contractHandler.SetContract)
+			employees.DELETE("/:id/contracts/:contract_id", contractHandler.DeleteContract)
+			employees.GET("/:id/flex-time", contractHandler.GetFlexTime)
+		}
+
+		customFields := api.Group("/custom-fields")
+		{
+			customFields.POST("/", customFieldHandler.CreateCustomField)
+			customFields.GET("/", customFieldHandler.GetCustomFields)
+			customFields.PUT("/:id", customFieldHandler.UpdateCustomField)
+			customFields.DELETE("/:id", customFieldHandler.DeleteCustomField)
+		}
+
+		attendance := api.Group("/attendance")
+		{
+			attendance.POST("/clock-in", attendanceHandler.ClockIn)
+			attendance.PUT("/clock-out", attendanceHandler.ClockOut)
+			attendance.GET("/logs", attendanceHandler.GetAttendanceLogs)
+			attendance.GET("/stream", attendanceHandler.StreamAttendance)
+		}
+
+		api.POST("/exports", exportHandler.CreateExportJob)
+	}
+
+	return r
+}
+
+func doJSON(r *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAttendanceFlow(t *testing.T) {
+	db := openTestDB(t)
+	outbox, events := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT Department",
+		"max_clock_in_time":  "00:00:00",
+		"max_clock_out_time": "00:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		Department struct {
+			ID int `json:"id"`
+		} `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	departmentPath := fmt.Sprintf("/api/v1/departments/%d", created.Department.ID)
+	w = doJSON(r, "GET", departmentPath, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id":    "EMP001",
+		"departement_id": created.Department.ID,
+		"name":           "John Doe",
+		"address":        "123 Main Street",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var employee struct {
+		Employee struct {
+			ID int `json:"id"`
+		} `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &employee))
+
+	w = doJSON(r, "GET", fmt.Sprintf("/api/v1/employees/%d", employee.Employee.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"is_on_time":false`)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assertErrorCode(t, w, http.StatusConflict, errcodes.AlreadyClockedIn)
+
+	w = doJSON(r, "PUT", "/api/v1/attendance/clock-out", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "PUT", "/api/v1/attendance/clock-out", map[string]string{"employee_id": "EMP001"})
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.NotClockedIn)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "NOPE"})
+	assertErrorCode(t, w, http.StatusNotFound, errcodes.EmployeeNotFound)
+
+	w = doJSON(r, "DELETE", departmentPath, nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.DepartmentHasEmployees)
+
+	today := time.Now().Format("2006-01-02")
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+today, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var logs struct {
+		AttendanceLogs []struct {
+			AttendanceType int  `json:"attendance_type"`
+			IsOnTime       bool `json:"is_on_time"`
+		} `json:"attendance_logs"`
+		Count int `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	assert.Equal(t, 2, logs.Count)
+	for _, log := range logs.AttendanceLogs {
+		// A 00:00:00 limit makes every clock-in late and every clock-out on time
+		assert.Equal(t, log.AttendanceType == 2, log.IsOnTime)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+yesterday, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date=15-01-2024", nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.InvalidFilters)
+
+	// Only successful changes are recorded, late clock-ins twice, and
+	// nothing is sent before the relay runs
+	assert.Empty(t, events.types())
+	_, err := outbox.Relay(context.Background())
+	assert.NoError(t, err)
+
+	published := assert.Equal(t, []string{
+		models.EventDepartmentCreated,
+		models.EventEmployeeCreated,
+		models.EventAttendanceClockIn,
+		models.EventAttendanceLate,
+		models.EventAttendanceClockOut,
+	}, events.types())
+	if published {
+		var late models.AttendanceEvent
+		assert.NoError(t, json.Unmarshal(events.events[3].Data.(json.RawMessage), &late))
+		assert.Equal(t, "EMP001", late.EmployeeID)
+		assert.Equal(t, "IT Department", late.DepartmentName)
+		assert.False(t, late.IsOnTime)
+	}
+}
+
+func TestScheduleChangeKeepsHistory(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name": "Night Shift", "max_clock_in_time": "23:59:59", "max_clock_out_time": "23:59:59",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+	w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": department.Department.ID, "name": "John Doe",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/v1/departments/%d", department.Department.ID), map[string]string{
+		"departement_name": "Early Shift", "max_clock_in_time": "00:00:00", "max_clock_out_time": "00:00:00",
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The punch keeps the schedule it was evaluated against
+	var logs struct {
+		Logs []models.AttendanceLog `json:"attendance_logs"`
+	}
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+time.Now().Format("2006-01-02"), nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	if assert.Len(t, logs.Logs, 1) {
+		assert.True(t, logs.Logs[0].IsOnTime)
+		assert.Equal(t, "23:59:59", logs.Logs[0].MaxClockInTime)
+		assert.Equal(t, 0, logs.Logs[0].LateMinutes)
+		assert.Equal(t, "Clock In", logs.Logs[0].Description)
+		assert.Equal(t, "Early Shift", logs.Logs[0].DepartmentName)
+	}
+}
+
+// streamEvent is one event read from an attendance stream
+type streamEvent struct {
+	ID    string
+	Event string
+	Data  models.AttendanceEvent
+}
+
+// openStream connects to the attendance stream, returning once it is
+// subscribed
+func openStream(t *testing.T, url, lastEventID string) (*bufio.Reader, func()) {
+	t.Helper()
+	return openStreamAs(t, "", url, lastEventID)
+}
+
+// openStreamAs is openStream with actor sent as the X-Actor header
+func openStreamAs(t *testing.T, actor, url, lastEventID string) (*bufio.Reader, func()) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if actor != "" {
+		req.Header.Set(ActorHeader, actor)
+	}
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, _ := reader.ReadString('\n')
+	assert.Equal(t, "retry: 3000\n", line)
+	reader.ReadString('\n')
+	return reader, func() { resp.Body.Close() }
+}
+
+// readStreamEvent reads the next event, skipping comments such as heartbeats
+func readStreamEvent(t *testing.T, reader *bufio.Reader) streamEvent {
+	t.Helper()
+	var event streamEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.ID != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data))
+		}
+	}
+}
+
+func TestWorkingTimeContract(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	// The department schedule accepts any clock-in today
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT Department",
+		"max_clock_in_time":  "23:59:59",
+		"max_clock_out_time": "00:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": department.Department.ID, "name": "Part Timer",
+		"employment_type": "part_time", "hire_date": yesterday,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Employee models.Employee `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"is_on_time":true`)
+	w = doJSON(r, "PUT", "/api/v1/attendance/clock-out", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	allWeek := []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+	apiErr := assertErrorCode(t, doJSON(r, "POST", path+"/contracts", map[string]interface{}{
+		"effective_from": yesterday, "weekly_hours": 35, "working_days": allWeek,
+		"start_window_start": "10:00:00", "start_window_end": "08:00:00",
+	}), http.StatusBadRequest, errcodes.ValidationFailed)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "start_window_end", apiErr.Details[0].Field)
+	}
+	assertErrorCode(t, doJSON(r, "POST", path+"/contracts", map[string]interface{}{
+		"effective_from": yesterday, "weekly_hours": 35, "working_days": []string{"someday"},
+		"start_window_start": "00:00:00", "start_window_end": "00:00:01",
+	}), http.StatusBadRequest, errcodes.ValidationFailed)
+
+	// A backdated contract whose start window closed at midnight makes today's
+	// clock-in late
+	w = doJSON(r, "POST", path+"/contracts", map[string]interface{}{
+		"effective_from": yesterday, "weekly_hours": 35, "working_days": allWeek,
+		"start_window_start": "00:00:00", "start_window_end": "00:00:01",
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var set struct {
+		Contract  models.WorkingTimeContract   `json:"contract"`
+		Contracts []models.WorkingTimeContract `json:"contracts"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	assert.Len(t, set.Contracts, 1)
+	assert.Equal(t, allWeek, set.Contract.WorkingDays)
+
+	logs := func() []models.AttendanceLog {
+		w := doJSON(r, "GET", "/api/v1/attendance/logs?date="+today, nil)
+		var body struct {
+			Logs []models.AttendanceLog `json:"attendance_logs"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Logs
+	}
+	for _, log := range logs() {
+		if log.AttendanceType == 1 {
+			assert.False(t, log.IsOnTime)
+			assert.Equal(t, "00:00:01", log.MaxClockInTime)
+		} else {
+			assert.True(t, log.IsOnTime, "without core hours clocking out is never early")
+			assert.Equal(t, "", log.MaxClockOutTime)
+		}
+		if assert.NotNil(t, log.ContractID) {
+			assert.Equal(t, set.Contract.ID, *log.ContractID)
+		}
+	}
+
+	// 35 hours over seven days owe 300 minutes a day, none of which were worked
+	w = doJSON(r, "GET", path+"/flex-time?start_date="+yesterday+"&end_date="+today, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var flex struct {
+		FlexTime models.FlexTimeAccount `json:"flex_time"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &flex))
+	assert.Equal(t, 600, flex.FlexTime.TargetMinutes)
+	assert.Equal(t, -600, flex.FlexTime.BalanceMinutes)
+	assert.Len(t, flex.FlexTime.Days, 2)
+	assertErrorCode(t, doJSON(r, "GET", path+"/flex-time?start_date="+today+"&end_date="+yesterday, nil),
+		http.StatusBadRequest, errcodes.InvalidFilters)
+
+	// Removing the contract hands the punches back to the department
+	contractPath := fmt.Sprintf("%s/contracts/%d", path, set.Contract.ID)
+	w = doJSON(r, "DELETE", contractPath, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, log := range logs() {
+		assert.True(t, log.IsOnTime)
+		assert.Nil(t, log.ContractID)
+	}
+	assertErrorCode(t, doJSON(r, "DELETE", contractPath, nil), http.StatusNotFound, errcodes.ContractNotFound)
+	w = doJSON(r, "GET", path+"/contracts", nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+}
+
+func TestAttendanceStream(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	var departmentIDs []int
+	for i, name := range []string{"IT", "HR"} {
+		w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+			"departement_name":   name,
+			"max_clock_in_time":  "23:59:59",
+			"max_clock_out_time": "00:00:00",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct {
+			Department struct {
+				ID int `json:"id"`
+			} `json:"department"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		departmentIDs = append(departmentIDs, created.Department.ID)
+
+		w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+			"employee_id":    fmt.Sprintf("EMP00%d", i+1),
+			"departement_id": created.Department.ID,
+			"name":           name + " Employee",
+			"address":        "123 Main Street",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	// Recorded before anyone listens, so only seen on resume
+	w := doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	stream, closeStream := openStream(t, fmt.Sprintf("%s/api/v1/attendance/stream?department_id=%d", server.URL, departmentIDs[0]), "")
+
+	// Idle streams get heartbeats
+	line, _ := stream.ReadString('\n')
+	assert.Equal(t, ": heartbeat\n", line)
+	stream.ReadString('\n')
+
+	// Other departments are filtered out
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP002"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "PUT", "/api/v1/attendance/clock-out", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	event := readStreamEvent(t, stream)
+	closeStream()
+	assert.Equal(t, models.EventAttendanceClockOut, event.Event)
+	assert.Equal(t, "EMP001", event.Data.EmployeeID)
+	assert.Equal(t, "IT Employee", event.Data.EmployeeName)
+	assert.Equal(t, "IT", event.Data.DepartmentName)
+	assert.Equal(t, 2, event.Data.AttendanceType)
+	assert.True(t, event.Data.IsOnTime)
+	assert.Equal(t, fmt.Sprint(event.Data.HistoryID), event.ID)
+
+	// Resuming replays everything after the last ID seen, oldest first
+	stream, closeStream = openStream(t, server.URL+"/api/v1/attendance/stream", fmt.Sprint(event.Data.HistoryID-2))
+	defer closeStream()
+
+	first := readStreamEvent(t, stream)
+	assert.Equal(t, models.EventAttendanceClockIn, first.Event)
+	assert.Equal(t, "EMP002", first.Data.EmployeeID)
+	assert.Equal(t, "HR", first.Data.DepartmentName)
+	second := readStreamEvent(t, stream)
+	assert.Equal(t, event.ID, second.ID)
+	assert.Equal(t, event.Event, second.Event)
+
+	req, _ := http.NewRequest("GET", "/api/v1/attendance/stream", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.InvalidRequest)
+}
+
+func TestTeamScopeCoversExportsAndStream(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT",
+		"max_clock_in_time":  "23:59:59",
+		"max_clock_out_time": "00:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+
+	// M manages L, who manages E and F; X has no manager
+	for _, employee := range [][2]string{{"M", ""}, {"L", "M"}, {"E", "L"}, {"F", "L"}, {"X", ""}} {
+		body := map[string]interface{}{
+			"employee_id": employee[0], "departement_id": department.Department.ID, "name": "Employee " + employee[0],
+		}
+		if employee[1] != "" {
+			body["manager_id"] = employee[1]
+		}
+		w = doJSON(r, "POST", "/api/v1/employees/", body)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Exports are limited to the actor's team like the listings
+	createExport := func(actor string, filters map[string]interface{}) *httptest.ResponseRecorder {
+		return doJSONAs(r, actor, "POST", "/api/v1/exports", map[string]interface{}{"entity": "attendance", "filters": filters})
+	}
+	var created struct {
+		Job models.ExportJob `json:"job"`
+	}
+	w = createExport("L", nil)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "L", created.Job.Filters.ManagerID)
+	assertErrorCode(t, createExport("L", map[string]interface{}{"manager_id": "M"}), http.StatusForbidden, errcodes.OutsideTeam)
+	assertErrorCode(t, createExport("X", map[string]interface{}{"manager_id": "L"}), http.StatusForbidden, errcodes.OutsideTeam)
+	w = createExport("hr-admin", nil)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	created.Job = models.ExportJob{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Empty(t, created.Job.Filters.ManagerID)
+
+	// So is the stream, both the replay and live events
+	punch := func(method, path, employeeID string) {
+		w := doJSON(r, method, path, map[string]string{"employee_id": employeeID})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	punch("POST", "/api/v1/attendance/clock-in", "E")
+	punch("POST", "/api/v1/attendance/clock-in", "X")
+	punch("PUT", "/api/v1/attendance/clock-out", "E")
+
+	assertErrorCode(t, doJSONAs(r, "L", "GET", "/api/v1/attendance/stream?manager_id=M", nil),
+		http.StatusForbidden, errcodes.OutsideTeam)
+
+	stream, closeStream := openStreamAs(t, "L", server.URL+"/api/v1/attendance/stream", "1")
+	defer closeStream()
+	punch("PUT", "/api/v1/attendance/clock-out", "X")
+	punch("POST", "/api/v1/attendance/clock-in", "F")
+
+	replayed := readStreamEvent(t, stream)
+	assert.Equal(t, "E", replayed.Data.EmployeeID)
+	assert.Equal(t, models.EventAttendanceClockOut, replayed.Event)
+	live := readStreamEvent(t, stream)
+	assert.Equal(t, "F", live.Data.EmployeeID)
+	assert.Equal(t, models.EventAttendanceClockIn, live.Event)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ActorHeader names the user making a request, recorded in the audit log
+	ActorHeader = "X-Actor"
+	// anonymousActor is recorded for requests without an actor header
+	anonymousActor = "anonymous"
+	// auditDefaultLimit is the page size when a query sets no limit
+	auditDefaultLimit = 100
+)
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	db *sql.DB
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(db *sql.DB) *AuditHandler {
+	return &AuditHandler{db: db}
+}
+
+// GetAuditLog lists audit entries matching the query filters, newest first
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var filter models.AuditFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = auditDefaultLimit
+	}
+
+	entries, err := services.NewAuditLog(h.db).Query(filter)
+	if err != nil {
+		respondAuditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"filters": filter,
+	})
+}
+
+// ExportAuditLogCSV streams the audit entries matching the query filters as
+// CSV, hashes included so the export can be verified on its own
+func (h *AuditHandler) ExportAuditLogCSV(c *gin.Context) {
+	var filter models.AuditFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	if err := services.ValidateAuditFilter(filter); err != nil {
+		respondAuditError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=audit_log_"+time.Now().Format("20060102_150405")+".csv")
+	c.Header("Content-Type", "text/csv")
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(auditCSVHeader)
+	err := services.NewAuditLog(h.db).Each(filter, func(entry models.AuditEntry) error {
+		return w.Write([]string{
+			strconv.Itoa(entry.ID),
+			entry.OccurredAt.UTC().Format(time.RFC3339),
+			entry.Actor,
+			entry.Action,
+			entry.EntityType,
+			entry.EntityID,
+			string(entry.Before),
+			string(entry.After),
+			entry.IP,
+			entry.RequestID,
+			entry.PrevHash,
+			entry.Hash,
+		})
+	})
+	w.Flush()
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		// The status is already sent; a truncated file is all that can be done
+		logging.FromContext(c).Error("failed to export audit log", "error", err)
+	}
+}
+
+// VerifyAuditLog recomputes the hash chain and reports the first entry that
+// was altered, if any
+func (h *AuditHandler) VerifyAuditLog(c *gin.Context) {
+	result, err := services.NewAuditLog(h.db).Verify()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to verify audit log", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"verification": result})
+}
+
+var auditCSVHeader = []string{
+	"ID", "Occurred At", "Actor", "Action", "Entity Type", "Entity ID", "Before", "After",
+	"IP Address", "Request ID", "Previous Hash", "Hash",
+}
+
+// respondAuditError writes the response for a failed audit log query
+func respondAuditError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidAuditFilters) {
+		respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, err.Error(), nil)
+		return
+	}
+	respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch audit log", err)
+}
+
+// auditActor identifies who is making the request from the actor header,
+// the client address and the request ID
+func auditActor(c *gin.Context) models.AuditActor {
+	actor := c.GetHeader(ActorHeader)
+	if actor == "" {
+		actor = anonymousActor
+	}
+	return models.AuditActor{
+		Actor:     actor,
+		IP:        c.ClientIP(),
+		RequestID: logging.GetRequestID(c),
+	}
+}
+
+// recordAudit appends changes to the audit log in tx on behalf of the
+// request's actor. On failure it writes an error response with msg and
+// returns false; the caller's deferred rollback undoes the change.
+func recordAudit(c *gin.Context, db *sql.DB, tx *sql.Tx, msg string, changes ...models.AuditChange) bool {
+	auditLog := services.NewAuditLog(db)
+	actor := auditActor(c)
+	for _, change := range changes {
+		if err := auditLog.Append(tx, actor, change); err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+			return false
+		}
+	}
+	return true
+}
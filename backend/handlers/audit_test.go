@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// doJSONAs is doJSON on behalf of actor
+func doJSONAs(r *gin.Engine, actor, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(body)
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ActorHeader, actor)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuditLogRecordsChanges(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logging.RequestID())
+	departments := NewDepartmentHandler(db, outbox, os.TempDir())
+	r.POST("/api/v1/departments/", departments.CreateDepartment)
+	r.PUT("/api/v1/departments/:id", departments.UpdateDepartment)
+	audit := NewAuditHandler(db)
+	r.GET("/api/v1/audit", audit.GetAuditLog)
+	r.GET("/api/v1/audit/verify", audit.VerifyAuditLog)
+
+	w := doJSONAs(r, "alice", "POST", "/api/v1/departments/", map[string]string{
+		"departement_name": "IT", "max_clock_in_time": "09:00:00", "max_clock_out_time": "17:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := strconv.Itoa(created.Department.ID)
+
+	w = doJSONAs(r, "bob", "PUT", "/api/v1/departments/"+id, map[string]string{
+		"departement_name": "IT", "max_clock_in_time": "09:30:00", "max_clock_out_time": "17:00:00",
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/v1/audit?actor=bob&entity_type=department&entity_id="+id, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var listed struct {
+		Entries []models.AuditEntry `json:"entries"`
+		Count   int                 `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	if assert.Equal(t, 1, listed.Count) {
+		entry := listed.Entries[0]
+		assert.Equal(t, models.AuditActionUpdate, entry.Action)
+		assert.NotEmpty(t, entry.RequestID)
+		assert.NotEmpty(t, entry.IP)
+
+		var before, after models.Department
+		assert.NoError(t, json.Unmarshal(entry.Before, &before))
+		assert.NoError(t, json.Unmarshal(entry.After, &after))
+		assert.Equal(t, "09:00:00", before.MaxClockInTime)
+		assert.Equal(t, "09:30:00", after.MaxClockInTime)
+	}
+
+	w = doJSON(r, "GET", "/api/v1/audit?entity_type=department", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	if assert.Equal(t, 2, listed.Count) {
+		assert.Equal(t, models.AuditActionCreate, listed.Entries[1].Action, "newest first")
+		assert.Equal(t, "alice", listed.Entries[1].Actor)
+		assert.JSONEq(t, "null", string(listed.Entries[1].Before))
+	}
+
+	w = doJSON(r, "GET", "/api/v1/audit/verify", nil)
+	var verified struct {
+		Verification models.AuditVerification `json:"verification"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &verified))
+	assert.True(t, verified.Verification.Valid, verified.Verification.Reason)
+	assert.Equal(t, 2, verified.Verification.Entries)
+
+	w = doJSON(r, "GET", "/api/v1/audit?from=yesterday", nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.InvalidFilters)
+}
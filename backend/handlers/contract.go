@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContractHandler handles HTTP requests for employees' working-time contracts
+// and the flex-time accounts kept against them
+type ContractHandler struct {
+	db *sql.DB
+}
+
+// NewContractHandler creates a new working-time contract handler
+func NewContractHandler(db *sql.DB) *ContractHandler {
+	return &ContractHandler{db: db}
+}
+
+// GetContracts lists an employee's working-time contracts, oldest first
+func (h *ContractHandler) GetContracts(c *gin.Context) {
+	employee, ok := h.findEmployee(c)
+	if !ok {
+		return
+	}
+
+	contracts, err := services.WorkingTimeContracts(h.db, employee.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch working-time contracts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contracts": contracts,
+		"count":     len(contracts),
+	})
+}
+
+// SetContract puts an employee on a working-time contract from a given date.
+// Punches it covers are re-evaluated against it; a contract on a date that
+// already starts one replaces it.
+func (h *ContractHandler) SetContract(c *gin.Context) {
+	employee, ok := h.findEmployee(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetContractRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	contract := models.WorkingTimeContract{
+		EmployeeID:       employee.EmployeeID,
+		EffectiveFrom:    req.EffectiveFrom,
+		WeeklyHours:      req.WeeklyHours,
+		WorkingDays:      req.WorkingDays,
+		StartWindowStart: req.StartWindowStart,
+		StartWindowEnd:   req.StartWindowEnd,
+		CoreStart:        req.CoreStart,
+		CoreEnd:          req.CoreEnd,
+	}
+	if len(contract.WorkingDays) == 0 {
+		contract.WorkingDays = services.DefaultWorkingDays
+	}
+	if field, err := services.ValidateContract(contract); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return
+	}
+
+	existing, err := services.WorkingTimeContracts(h.db, employee.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch working-time contracts", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to set working-time contract", err)
+		return
+	}
+	defer tx.Rollback()
+
+	contracts, err := services.SetContract(h.db, tx, employee.EmployeeID, contract, time.Now())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to set working-time contract", err)
+		return
+	}
+
+	for _, set := range contracts {
+		if set.EffectiveFrom == req.EffectiveFrom {
+			contract = set
+		}
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityContract,
+		EntityID:   strconv.Itoa(contract.ID),
+		After:      contract,
+	}
+	for _, previous := range existing {
+		if previous.EffectiveFrom == req.EffectiveFrom {
+			change.Action = models.AuditActionUpdate
+			change.Before = previous
+		}
+	}
+	if !recordAudit(c, h.db, tx, "Failed to set working-time contract", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to set working-time contract", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Working-time contract set successfully",
+		"contract":  contract,
+		"contracts": contracts,
+	})
+}
+
+// DeleteContract removes one of an employee's working-time contracts. The
+// punches it covered are re-evaluated against the contract before it, or
+// their department's schedule when there is none.
+func (h *ContractHandler) DeleteContract(c *gin.Context) {
+	employee, ok := h.findEmployee(c)
+	if !ok {
+		return
+	}
+	contractID, err := strconv.Atoi(c.Param("contract_id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, errcodes.ContractNotFound, "Working-time contract not found", nil)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete working-time contract", err)
+		return
+	}
+	defer tx.Rollback()
+
+	deleted, contracts, err := services.DeleteContract(h.db, tx, employee.EmployeeID, contractID, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrContractNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ContractNotFound, "Working-time contract not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete working-time contract", err)
+		return
+	}
+
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityContract,
+		EntityID:   strconv.Itoa(deleted.ID),
+		Before:     deleted,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete working-time contract", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete working-time contract", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Working-time contract deleted successfully",
+		"contracts": contracts,
+	})
+}
+
+// GetFlexTime returns an employee's flex-time account over a date range: the
+// minutes worked against the minutes their contracts asked for
+func (h *ContractHandler) GetFlexTime(c *gin.Context) {
+	employee, ok := h.findEmployee(c)
+	if !ok {
+		return
+	}
+
+	var filter models.FlexTimeFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	account, err := services.FlexTime(h.db, employee, filter.StartDate, filter.EndDate, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExportFilters) {
+			respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, err.Error(), nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to compute flex time", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"flex_time": account})
+}
+
+// findEmployee loads the employee named by the id parameter, writing an
+// error response when it cannot
+func (h *ContractHandler) findEmployee(c *gin.Context) (models.Employee, bool) {
+	employee, err := services.ScanEmployee(h.db.QueryRow(`
+		SELECT `+services.EmployeeColumns+`
+		FROM employee e
+		WHERE e.id = ? AND e.deleted_at IS NULL
+	`, c.Param("id")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return employee, false
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return employee, false
+	}
+	return employee, true
+}
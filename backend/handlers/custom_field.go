@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomFieldHandler handles HTTP requests defining custom employee fields
+type CustomFieldHandler struct {
+	db *sql.DB
+}
+
+// NewCustomFieldHandler creates a new custom field handler
+func NewCustomFieldHandler(db *sql.DB) *CustomFieldHandler {
+	return &CustomFieldHandler{db: db}
+}
+
+// CreateCustomField defines a new custom employee field
+func (h *CustomFieldHandler) CreateCustomField(c *gin.Context) {
+	var req models.CreateCustomFieldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	field := models.CustomField{Name: req.Name, Label: req.Label, FieldType: req.FieldType, Options: req.Options}
+	if name, err := services.ValidateCustomField(field); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, name, name+" "+err.Error())
+		return
+	}
+	options, err := services.CustomFieldOptions(field)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create custom field", err)
+		return
+	}
+
+	var exists int
+	err = h.db.QueryRow("SELECT 1 FROM custom_field WHERE name = ?", req.Name).Scan(&exists)
+	if err == nil {
+		respondError(c, http.StatusConflict, errcodes.CustomFieldExists, "Custom field already exists", nil)
+		return
+	}
+	if err != sql.ErrNoRows {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch custom field", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create custom field", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	id, err := database.TxInsertReturningID(h.db, tx, `
+		INSERT INTO custom_field (name, label, field_type, options, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, field.Name, field.Label, field.FieldType, options, now, now)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create custom field", err)
+		return
+	}
+
+	field.ID = int(id)
+	field.CreatedAt = now
+	field.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityCustomField,
+		EntityID:   strconv.Itoa(field.ID),
+		After:      field,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to create custom field", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create custom field", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Custom field created successfully",
+		"custom_field": field,
+	})
+}
+
+// GetCustomFields lists the custom employee fields ordered by name
+func (h *CustomFieldHandler) GetCustomFields(c *gin.Context) {
+	fields, err := services.CustomFields(h.db)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch custom fields", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"custom_fields": fields,
+		"count":         len(fields),
+	})
+}
+
+// UpdateCustomField changes the label and, for select fields, the options of
+// a custom field. Options employees still have cannot be dropped.
+func (h *CustomFieldHandler) UpdateCustomField(c *gin.Context) {
+	before, ok := h.findCustomField(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateCustomFieldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	field := before
+	field.Label = req.Label
+	field.Options = req.Options
+	if name, err := services.ValidateCustomField(field); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, name, name+" "+err.Error())
+		return
+	}
+	options, err := services.CustomFieldOptions(field)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update custom field", err)
+		return
+	}
+
+	if field.FieldType == models.CustomFieldSelect {
+		inUse, err := services.CustomValuesInUse(h.db, field.ID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update custom field", err)
+			return
+		}
+		for _, value := range inUse {
+			if !slices.Contains(field.Options, value) {
+				respondInvalidField(c, errcodes.ValidationFailed, "options",
+					fmt.Sprintf("options must keep %q, which employees still have", value))
+				return
+			}
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update custom field", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec("UPDATE custom_field SET label = ?, options = ?, updated_at = ? WHERE id = ?",
+		field.Label, options, now, field.ID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update custom field", err)
+		return
+	}
+
+	field.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityCustomField,
+		EntityID:   strconv.Itoa(field.ID),
+		Before:     before,
+		After:      field,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to update custom field", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update custom field", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Custom field updated successfully",
+		"custom_field": field,
+	})
+}
+
+// DeleteCustomField deletes a custom field along with every employee's value
+// for it
+func (h *CustomFieldHandler) DeleteCustomField(c *gin.Context) {
+	field, ok := h.findCustomField(c)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete custom field", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Values are removed first for databases without foreign keys enabled
+	if _, err := tx.Exec("DELETE FROM employee_custom_value WHERE custom_field_id = ?", field.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete custom field", err)
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM custom_field WHERE id = ?", field.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete custom field", err)
+		return
+	}
+
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityCustomField,
+		EntityID:   strconv.Itoa(field.ID),
+		Before:     field,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete custom field", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete custom field", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
+
+// findCustomField loads the custom field named by the id parameter, writing
+// an error response when it cannot
+func (h *CustomFieldHandler) findCustomField(c *gin.Context) (models.CustomField, bool) {
+	field, err := services.ScanCustomField(h.db.QueryRow(
+		"SELECT "+services.CustomFieldColumns+" FROM custom_field WHERE id = ?", c.Param("id")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.CustomFieldNotFound, "Custom field not found", nil)
+			return field, false
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch custom field", err)
+		return field, false
+	}
+	return field, true
+}
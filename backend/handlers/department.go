@@ -5,7 +5,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/logging"
 	"attendance-system/models"
 	"attendance-system/services"
 
@@ -14,39 +18,63 @@ import (
 
 // DepartmentHandler handles department-related HTTP requests
 type DepartmentHandler struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *services.Outbox
+	// exportDir receives CSV downloads until the export retention sweep
+	// removes them
+	exportDir string
 }
 
-// NewDepartmentHandler creates a new department handler
-func NewDepartmentHandler(db *sql.DB) *DepartmentHandler {
-	return &DepartmentHandler{db: db}
+// NewDepartmentHandler creates a new department handler. Changes made
+// through the API are recorded as events in outbox; CSV downloads are written
+// to exportDir.
+func NewDepartmentHandler(db *sql.DB, outbox *services.Outbox, exportDir string) *DepartmentHandler {
+	return &DepartmentHandler{db: db, outbox: outbox, exportDir: exportDir}
 }
 
 // CreateDepartment creates a new department
 func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 	var req models.CreateDepartmentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	result, err := h.db.Exec(`
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create department", err)
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := database.TxInsertReturningID(h.db, tx, `
 		INSERT INTO departement (departement_name, max_clock_in_time, max_clock_out_time)
 		VALUES (?, ?, ?)
 	`, req.DepartementName, req.MaxClockInTime, req.MaxClockOutTime)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create department"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create department", err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
 	department := models.Department{
 		ID:              int(id),
 		DepartementName: req.DepartementName,
 		MaxClockInTime:  req.MaxClockInTime,
 		MaxClockOutTime: req.MaxClockOutTime,
 	}
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityDepartment,
+		EntityID:   strconv.Itoa(department.ID),
+		After:      department,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to create department", change) {
+		return
+	}
+	event := services.NewEvent(models.EventDepartmentCreated, department)
+	if !commitEvents(c, h.outbox, tx, "Failed to create department", services.DepartmentEventKey(department.ID), event) {
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    "Department created successfully",
@@ -64,7 +92,7 @@ func (h *DepartmentHandler) GetDepartments(c *gin.Context) {
 
 	rows, err := h.db.Query(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch departments"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch departments", err)
 		return
 	}
 	defer rows.Close()
@@ -79,6 +107,7 @@ func (h *DepartmentHandler) GetDepartments(c *gin.Context) {
 			&dept.MaxClockOutTime,
 		)
 		if err != nil {
+			logging.FromContext(c).Error("failed to read department row", "error", err)
 			continue
 		}
 		departments = append(departments, dept)
@@ -103,10 +132,10 @@ func (h *DepartmentHandler) GetDepartment(c *gin.Context) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+			respondError(c, http.StatusNotFound, errcodes.DepartmentNotFound, "Department not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch department"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
 		return
 	}
 
@@ -117,27 +146,62 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	id := c.Param("id")
 	var req models.UpdateDepartmentRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// Check if department exists
-	var exists int
-	err := h.db.QueryRow("SELECT 1 FROM departement WHERE id = ?", id).Scan(&exists)
+	var before models.Department
+	err := h.db.QueryRow(`
+		SELECT id, departement_name, max_clock_in_time, max_clock_out_time
+		FROM departement
+		WHERE id = ?
+	`, id).Scan(&before.ID, &before.DepartementName, &before.MaxClockInTime, &before.MaxClockOutTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.DepartmentNotFound, "Department not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update department", err)
 		return
 	}
+	defer tx.Rollback()
 
-	_, err = h.db.Exec(`
+	_, err = tx.Exec(`
 		UPDATE departement 
 		SET departement_name = ?, max_clock_in_time = ?, max_clock_out_time = ?
 		WHERE id = ?
 	`, req.DepartementName, req.MaxClockInTime, req.MaxClockOutTime, id)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update department"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update department", err)
+		return
+	}
+
+	department := models.Department{
+		ID:              before.ID,
+		DepartementName: req.DepartementName,
+		MaxClockInTime:  req.MaxClockInTime,
+		MaxClockOutTime: req.MaxClockOutTime,
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityDepartment,
+		EntityID:   strconv.Itoa(department.ID),
+		Before:     before,
+		After:      department,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to update department", change) {
+		return
+	}
+	event := services.NewEvent(models.EventDepartmentUpdated, department)
+	if !commitEvents(c, h.outbox, tx, "Failed to update department", services.DepartmentEventKey(department.ID), event) {
 		return
 	}
 
@@ -149,24 +213,69 @@ func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
 	id := c.Param("id")
 
 	// Check if department exists
-	var exists int
-	err := h.db.QueryRow("SELECT 1 FROM departement WHERE id = ?", id).Scan(&exists)
+	var dept models.Department
+	err := h.db.QueryRow(`
+		SELECT id, departement_name, max_clock_in_time, max_clock_out_time
+		FROM departement
+		WHERE id = ?
+	`, id).Scan(&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.DepartmentNotFound, "Department not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
 		return
 	}
 
 	// Check if department has employees
 	var employeeCount int
 	err = h.db.QueryRow("SELECT COUNT(*) FROM employee WHERE departement_id = ?", id).Scan(&employeeCount)
-	if err == nil && employeeCount > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete department with employees"})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
+		return
+	}
+	if employeeCount > 0 {
+		respondError(c, http.StatusBadRequest, errcodes.DepartmentHasEmployees, "Cannot delete department with employees", nil)
 		return
 	}
 
-	_, err = h.db.Exec("DELETE FROM departement WHERE id = ?", id)
+	// Past assignments keep the department's schedule in use for old punches.
+	// They are never removed, so this block is permanent.
+	var assignmentCount int
+	err = h.db.QueryRow("SELECT COUNT(*) FROM department_assignment WHERE departement_id = ?", id).Scan(&assignmentCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete department"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department assignments", err)
+		return
+	}
+	if assignmentCount > 0 {
+		respondError(c, http.StatusBadRequest, errcodes.DepartmentHasHistory, "Cannot delete department with past employees", nil)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete department", err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM departement WHERE id = ?", id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete department", err)
+		return
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityDepartment,
+		EntityID:   strconv.Itoa(dept.ID),
+		Before:     dept,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete department", change) {
+		return
+	}
+	event := services.NewEvent(models.EventDepartmentDeleted, dept)
+	if !commitEvents(c, h.outbox, tx, "Failed to delete department", services.DepartmentEventKey(dept.ID), event) {
 		return
 	}
 
@@ -183,7 +292,7 @@ func (h *DepartmentHandler) ExportDepartmentsCSV(c *gin.Context) {
 
 	rows, err := h.db.Query(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch departments"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch departments", err)
 		return
 	}
 	defer rows.Close()
@@ -198,6 +307,7 @@ func (h *DepartmentHandler) ExportDepartmentsCSV(c *gin.Context) {
 			&dept.MaxClockOutTime,
 		)
 		if err != nil {
+			logging.FromContext(c).Error("failed to read department row", "error", err)
 			continue
 		}
 		departments = append(departments, dept)
@@ -208,17 +318,17 @@ func (h *DepartmentHandler) ExportDepartmentsCSV(c *gin.Context) {
 
 	// Generate filename
 	filename := csvService.GenerateFilename("departments")
-	filepath := filepath.Join("exports", filename)
+	filepath := filepath.Join(h.exportDir, filename)
 
 	// Create exports directory if it doesn't exist
-	if err := os.MkdirAll("exports", 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exports directory"})
+	if err := os.MkdirAll(h.exportDir, 0755); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create exports directory", err)
 		return
 	}
 
 	// Export to CSV
 	if err := csvService.ExportDepartmentList(departments, filepath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export CSV"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to export CSV", err)
 		return
 	}
 
@@ -234,3 +344,22 @@ func (h *DepartmentHandler) ExportDepartmentsCSV(c *gin.Context) {
 	// Send file
 	c.File(filepath)
 }
+
+// ImportDepartments upserts departments from a CSV or XLSX file using the
+// column layout produced by ExportDepartmentsCSV. Departments are matched by
+// name. The default dry-run mode returns a diff preview; mode=commit applies
+// every change or none of them.
+func (h *DepartmentHandler) ImportDepartments(c *gin.Context) {
+	rows, mode, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewDepartmentImportService(h.db).Import(rows, mode, auditActor(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to import departments", err)
+		return
+	}
+
+	respondImport(c, report.Mode, report.ErrorRows, report, "Departments imported successfully")
+}
@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"bytes"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/logging"
 	"attendance-system/models"
 	"attendance-system/services"
 
@@ -15,19 +23,46 @@ import (
 
 // EmployeeHandler handles employee-related HTTP requests
 type EmployeeHandler struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *services.Outbox
+	blobs  services.BlobStore
+	// exportDir receives CSV downloads until the export retention sweep
+	// removes them
+	exportDir string
 }
 
-// NewEmployeeHandler creates a new employee handler
-func NewEmployeeHandler(db *sql.DB) *EmployeeHandler {
-	return &EmployeeHandler{db: db}
+// NewEmployeeHandler creates a new employee handler. Changes made through
+// the API are recorded as events in outbox; photos are kept in blobs and CSV
+// downloads written to exportDir.
+func NewEmployeeHandler(db *sql.DB, outbox *services.Outbox, blobs services.BlobStore, exportDir string) *EmployeeHandler {
+	return &EmployeeHandler{db: db, outbox: outbox, blobs: blobs, exportDir: exportDir}
 }
 
 // CreateEmployee creates a new employee
 func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	var req models.CreateEmployeeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.EmploymentStatus == "" {
+		req.EmploymentStatus = models.EmploymentActive
+	}
+	if req.EmploymentType == "" {
+		req.EmploymentType = models.EmploymentFullTime
+	}
+	if req.ManagerID != nil && *req.ManagerID == "" {
+		req.ManagerID = nil
+	}
+	if field, err := services.ValidateEmployment(req.EmploymentStatus, req.HireDate, req.TerminationDate); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return
+	}
+	if field, err := services.ValidateContact(req.Email, req.Phone); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return
+	}
+	customValues, ok := h.validateCustomValues(c, req.CustomFields)
+	if !ok {
 		return
 	}
 
@@ -35,7 +70,11 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	var exists int
 	err := h.db.QueryRow("SELECT 1 FROM employee WHERE employee_id = ?", req.EmployeeID).Scan(&exists)
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Employee ID already exists"})
+		respondError(c, http.StatusConflict, errcodes.EmployeeIDExists, "Employee ID already exists", nil)
+		return
+	}
+	if err != sql.ErrNoRows {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
 		return
 	}
 
@@ -43,30 +82,83 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	var deptExists int
 	err = h.db.QueryRow("SELECT 1 FROM departement WHERE id = ?", req.DepartementID).Scan(&deptExists)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Department not found"})
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusBadRequest, errcodes.DepartmentNotFound, "Department not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create employee", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if req.ManagerID != nil && !h.checkManager(c, tx, req.EmployeeID, *req.ManagerID, "Failed to create employee") {
 		return
 	}
 
 	now := time.Now()
-	result, err := h.db.Exec(`
-		INSERT INTO employee (employee_id, departement_id, name, address, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, req.EmployeeID, req.DepartementID, req.Name, req.Address, now, now)
+	id, err := database.TxInsertReturningID(h.db, tx, `
+		INSERT INTO employee (employee_id, departement_id, name, address, email, phone, job_title, employment_type,
+			work_location, employment_status, hire_date, termination_date, manager_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.EmployeeID, req.DepartementID, req.Name, req.Address, req.Email, req.Phone, req.JobTitle, req.EmploymentType,
+		req.WorkLocation, req.EmploymentStatus, req.HireDate, req.TerminationDate, req.ManagerID, now, now)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create employee", err)
+		return
+	}
+	if err := services.SaveCustomValues(tx, req.EmployeeID, customValues); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create employee", err)
+		return
+	}
+	customFields, err := services.EmployeeCustomFields(tx, req.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create employee", err)
+		return
+	}
+	_, err = services.AssignDepartment(h.db, tx, req.EmployeeID, req.DepartementID, services.FirstAssignmentDate(req.HireDate, now), now)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create employee", err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
 	employee := models.Employee{
-		ID:            int(id),
-		EmployeeID:    req.EmployeeID,
-		DepartementID: req.DepartementID,
-		Name:          req.Name,
-		Address:       req.Address,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		ID:               int(id),
+		EmployeeID:       req.EmployeeID,
+		DepartementID:    req.DepartementID,
+		Name:             req.Name,
+		Address:          req.Address,
+		Email:            req.Email,
+		Phone:            req.Phone,
+		JobTitle:         req.JobTitle,
+		EmploymentType:   req.EmploymentType,
+		WorkLocation:     req.WorkLocation,
+		CustomFields:     customFields,
+		EmploymentStatus: req.EmploymentStatus,
+		HireDate:         req.HireDate,
+		TerminationDate:  req.TerminationDate,
+		ManagerID:        req.ManagerID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityEmployee,
+		EntityID:   strconv.Itoa(employee.ID),
+		After:      employee,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to create employee", change) {
+		return
+	}
+	event := services.NewEvent(models.EventEmployeeCreated, employee)
+	if !commitEvents(c, h.outbox, tx, "Failed to create employee", services.EmployeeEventKey(employee.EmployeeID), event) {
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -75,39 +167,78 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	})
 }
 
-// GetEmployees retrieves all employees with optional department info
+// GetEmployees retrieves employees with their department info, optionally
+// searched and filtered by employment status, type, work location or
+// manager. Deleted employees are left out unless include_deleted is set.
 func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
+	var filter models.EmployeeFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+	if !scopeToTeam(c, h.db, &filter.ManagerID, "Failed to fetch employees") {
+		return
+	}
+
 	query := `
-		SELECT e.id, e.employee_id, e.departement_id, e.name, e.address, 
-		       e.created_at, e.updated_at,
-		       d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time
+		SELECT ` + services.EmployeeWithDepartmentColumns + `
 		FROM employee e
 		LEFT JOIN departement d ON e.departement_id = d.id
-		ORDER BY e.created_at DESC
+		WHERE 1=1
 	`
+	var args []interface{}
+	if !filter.IncludeDeleted {
+		query += " AND e.deleted_at IS NULL"
+	}
+	if filter.Status != "" {
+		query += " AND e.employment_status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.EmploymentType != "" {
+		query += " AND e.employment_type = ?"
+		args = append(args, filter.EmploymentType)
+	}
+	if filter.WorkLocation != "" {
+		query += " AND e.work_location = ?"
+		args = append(args, filter.WorkLocation)
+	}
+	if filter.Search != "" {
+		condition, searchArgs := services.EmployeeSearchCondition(filter.Search)
+		query += condition
+		args = append(args, searchArgs...)
+	}
+	if filter.ManagerID != "" {
+		condition, teamArgs, err := services.TeamCondition(h.db, "e.employee_id", filter.ManagerID, filter.DirectOnly)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
+			return
+		}
+		query += condition
+		args = append(args, teamArgs...)
+	}
+	query += " ORDER BY e.created_at DESC"
 
-	rows, err := h.db.Query(query)
+	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
 		return
 	}
 	defer rows.Close()
 
 	var employees []models.EmployeeWithDepartment
 	for rows.Next() {
-		var emp models.EmployeeWithDepartment
-		var dept models.Department
-		err := rows.Scan(
-			&emp.ID, &emp.EmployeeID, &emp.DepartementID, &emp.Name, &emp.Address,
-			&emp.CreatedAt, &emp.UpdatedAt,
-			&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime,
-		)
+		emp, err := services.ScanEmployeeWithDepartment(rows)
 		if err != nil {
+			logging.FromContext(c).Error("failed to read employee row", "error", err)
 			continue
 		}
-		emp.Department = dept
 		employees = append(employees, emp)
 	}
+	rows.Close()
+
+	if err := services.WithCustomFields(h.db, employees); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"employees": employees,
@@ -115,37 +246,34 @@ func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
 	})
 }
 
-// GetEmployee retrieves a single employee by ID
+// GetEmployee retrieves a single employee by ID. Deleted employees are
+// still found, with deleted_at set, so their history can be looked up.
 func (h *EmployeeHandler) GetEmployee(c *gin.Context) {
 	id := c.Param("id")
 
 	query := `
-		SELECT e.id, e.employee_id, e.departement_id, e.name, e.address, 
-		       e.created_at, e.updated_at,
-		       d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time
+		SELECT ` + services.EmployeeWithDepartmentColumns + `
 		FROM employee e
 		LEFT JOIN departement d ON e.departement_id = d.id
 		WHERE e.id = ?
 	`
 
-	var emp models.EmployeeWithDepartment
-	var dept models.Department
-	err := h.db.QueryRow(query, id).Scan(
-		&emp.ID, &emp.EmployeeID, &emp.DepartementID, &emp.Name, &emp.Address,
-		&emp.CreatedAt, &emp.UpdatedAt,
-		&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime,
-	)
+	emp, err := services.ScanEmployeeWithDepartment(h.db.QueryRow(query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+	emp.CustomFields, err = services.EmployeeCustomFields(h.db, emp.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
 		return
 	}
 
-	emp.Department = dept
 	c.JSON(http.StatusOK, gin.H{"employee": emp})
 }
 
@@ -154,16 +282,70 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	id := c.Param("id")
 	var req models.UpdateEmployeeRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// Check if employee exists
-	var exists int
-	err := h.db.QueryRow("SELECT 1 FROM employee WHERE id = ?", id).Scan(&exists)
+	before, err := h.findEmployee(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	employee := before
+	employee.DepartementID = req.DepartementID
+	employee.Name = req.Name
+	employee.Address = req.Address
+	if req.EmploymentStatus != "" {
+		employee.EmploymentStatus = req.EmploymentStatus
+		if req.EmploymentStatus != models.EmploymentTerminated {
+			employee.TerminationDate = nil
+		}
+	}
+	if req.HireDate != nil {
+		employee.HireDate = req.HireDate
+	}
+	if req.TerminationDate != nil {
+		employee.TerminationDate = req.TerminationDate
+	}
+	if req.ManagerID != nil {
+		employee.ManagerID = req.ManagerID
+		if *req.ManagerID == "" {
+			employee.ManagerID = nil
+		}
+	}
+	for _, field := range []struct {
+		value *string
+		dest  *string
+	}{
+		{req.Email, &employee.Email},
+		{req.Phone, &employee.Phone},
+		{req.JobTitle, &employee.JobTitle},
+		{req.EmploymentType, &employee.EmploymentType},
+		{req.WorkLocation, &employee.WorkLocation},
+	} {
+		if field.value != nil {
+			*field.dest = *field.value
+		}
+	}
+	if employee.EmploymentType == "" {
+		employee.EmploymentType = models.EmploymentFullTime
+	}
+	if field, err := services.ValidateEmployment(employee.EmploymentStatus, employee.HireDate, employee.TerminationDate); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return
+	}
+	if field, err := services.ValidateContact(employee.Email, employee.Phone); err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return
+	}
+	customValues, ok := h.validateCustomValues(c, req.CustomFields)
+	if !ok {
 		return
 	}
 
@@ -171,104 +353,502 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	var deptExists int
 	err = h.db.QueryRow("SELECT 1 FROM departement WHERE id = ?", req.DepartementID).Scan(&deptExists)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Department not found"})
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusBadRequest, errcodes.DepartmentNotFound, "Department not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update employee", err)
+		return
+	}
+	defer tx.Rollback()
+
+	managerChanged := employee.ManagerID != nil && (before.ManagerID == nil || *before.ManagerID != *employee.ManagerID)
+	if managerChanged && !h.checkManager(c, tx, employee.EmployeeID, *employee.ManagerID, "Failed to update employee") {
 		return
 	}
 
 	now := time.Now()
-	_, err = h.db.Exec(`
+	_, err = tx.Exec(`
 		UPDATE employee 
-		SET departement_id = ?, name = ?, address = ?, updated_at = ?
+		SET departement_id = ?, name = ?, address = ?, email = ?, phone = ?, job_title = ?, employment_type = ?,
+			work_location = ?, employment_status = ?, hire_date = ?, termination_date = ?, manager_id = ?, updated_at = ?
 		WHERE id = ?
-	`, req.DepartementID, req.Name, req.Address, now, id)
+	`, employee.DepartementID, employee.Name, employee.Address, employee.Email, employee.Phone, employee.JobTitle,
+		employee.EmploymentType, employee.WorkLocation, employee.EmploymentStatus, employee.HireDate,
+		employee.TerminationDate, employee.ManagerID, now, id)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update employee", err)
+		return
+	}
+	if len(customValues) > 0 {
+		if err := services.SaveCustomValues(tx, employee.EmployeeID, customValues); err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update employee", err)
+			return
+		}
+		employee.CustomFields, err = services.EmployeeCustomFields(tx, employee.EmployeeID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update employee", err)
+			return
+		}
+	}
+
+	// A new department applies from now; earlier punches keep the old one
+	if employee.DepartementID != before.DepartementID {
+		_, err = services.MoveDepartment(h.db, tx, employee.EmployeeID, employee.DepartementID, now)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update employee", err)
+			return
+		}
+	}
+
+	employee.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityEmployee,
+		EntityID:   strconv.Itoa(employee.ID),
+		Before:     before,
+		After:      employee,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to update employee", change) {
+		return
+	}
+	event := services.NewEvent(models.EventEmployeeUpdated, employee)
+	if !commitEvents(c, h.outbox, tx, "Failed to update employee", services.EmployeeEventKey(employee.EmployeeID), event) {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Employee updated successfully"})
 }
 
-// DeleteEmployee deletes an employee
+// DeleteEmployee soft-deletes an employee. The row stays, hidden from lists,
+// so their attendance history keeps its employee. Managers must have their
+// reports moved to someone else first.
 func (h *EmployeeHandler) DeleteEmployee(c *gin.Context) {
 	id := c.Param("id")
 
 	// Check if employee exists
-	var exists int
-	err := h.db.QueryRow("SELECT 1 FROM employee WHERE id = ?", id).Scan(&exists)
+	before, err := h.findEmployee(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
 		return
 	}
 
-	// Check if employee has attendance records
-	var attendanceCount int
-	err = h.db.QueryRow("SELECT COUNT(*) FROM attendance WHERE employee_id = (SELECT employee_id FROM employee WHERE id = ?)", id).Scan(&attendanceCount)
-	if err == nil && attendanceCount > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete employee with attendance records"})
+	var reports int
+	err = h.db.QueryRow("SELECT COUNT(*) FROM employee WHERE manager_id = ? AND deleted_at IS NULL", before.EmployeeID).
+		Scan(&reports)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch direct reports", err)
+		return
+	}
+	if reports > 0 {
+		respondError(c, http.StatusBadRequest, errcodes.EmployeeHasReports, "Cannot delete employee with direct reports", nil)
 		return
 	}
 
-	_, err = h.db.Exec("DELETE FROM employee WHERE id = ?", id)
+	tx, err := h.db.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete employee"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete employee", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec("UPDATE employee SET deleted_at = ?, updated_at = ? WHERE id = ?", now, now, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete employee", err)
+		return
+	}
+	employee := before
+	employee.DeletedAt = &now
+	employee.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityEmployee,
+		EntityID:   strconv.Itoa(employee.ID),
+		Before:     before,
+		After:      employee,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete employee", change) {
+		return
+	}
+	event := services.NewEvent(models.EventEmployeeDeleted, employee)
+	if !commitEvents(c, h.outbox, tx, "Failed to delete employee", services.EmployeeEventKey(employee.EmployeeID), event) {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Employee deleted successfully"})
 }
 
-// ExportEmployeesCSV exports employee list to CSV file
+// GetEmployeeAssignments lists the departments an employee has belonged to,
+// oldest first
+func (h *EmployeeHandler) GetEmployeeAssignments(c *gin.Context) {
+	var employeeID string
+	err := h.db.QueryRow("SELECT employee_id FROM employee WHERE id = ?", c.Param("id")).Scan(&employeeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	assignments, err := services.DepartmentAssignments(h.db, employeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department assignments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"count":       len(assignments),
+	})
+}
+
+// TransferEmployee moves an employee to another department from a given
+// date. Backdated transfers re-evaluate the punches they cover against the
+// new department's schedule; a transfer on a date that already starts an
+// assignment replaces it.
+func (h *EmployeeHandler) TransferEmployee(c *gin.Context) {
+	id := c.Param("id")
+	var req models.TransferEmployeeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	employee, err := h.findEmployee(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	var deptExists int
+	err = h.db.QueryRow("SELECT 1 FROM departement WHERE id = ?", req.DepartementID).Scan(&deptExists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusBadRequest, errcodes.DepartmentNotFound, "Department not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department", err)
+		return
+	}
+
+	existing, err := services.DepartmentAssignments(h.db, employee.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch department assignments", err)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to transfer employee", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	assignments, err := services.AssignDepartment(h.db, tx, employee.EmployeeID, req.DepartementID, req.EffectiveFrom, now)
+	if err != nil {
+		if errors.Is(err, services.ErrFutureTransfer) {
+			respondInvalidField(c, errcodes.ValidationFailed, "effective_from", err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to transfer employee", err)
+		return
+	}
+	current := assignments[len(assignments)-1].DepartementID
+	if current != employee.DepartementID {
+		_, err = tx.Exec("UPDATE employee SET departement_id = ?, updated_at = ? WHERE id = ?", current, now, employee.ID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to transfer employee", err)
+			return
+		}
+	}
+
+	var assignment models.DepartmentAssignment
+	for _, a := range assignments {
+		if a.EffectiveFrom == req.EffectiveFrom {
+			assignment = a
+		}
+	}
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityAssignment,
+		EntityID:   strconv.Itoa(assignment.ID),
+		After:      assignment,
+	}
+	for _, previous := range existing {
+		if previous.EffectiveFrom == req.EffectiveFrom {
+			change.Action = models.AuditActionUpdate
+			change.Before = previous
+		}
+	}
+	if !recordAudit(c, h.db, tx, "Failed to transfer employee", change) {
+		return
+	}
+	event := services.NewEvent(models.EventEmployeeTransferred, assignment)
+	if !commitEvents(c, h.outbox, tx, "Failed to transfer employee", services.EmployeeEventKey(employee.EmployeeID), event) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Employee transferred successfully",
+		"assignments": assignments,
+	})
+}
+
+// GetDirectReports lists the employees who report directly to an employee
+func (h *EmployeeHandler) GetDirectReports(c *gin.Context) {
+	manager, err := h.findEmployee(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT `+services.EmployeeWithDepartmentColumns+`
+		FROM employee e
+		LEFT JOIN departement d ON e.departement_id = d.id
+		WHERE e.manager_id = ? AND e.deleted_at IS NULL
+		ORDER BY e.name, e.employee_id
+	`, manager.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch direct reports", err)
+		return
+	}
+	defer rows.Close()
+
+	reports := []models.EmployeeWithDepartment{}
+	for rows.Next() {
+		emp, err := services.ScanEmployeeWithDepartment(rows)
+		if err != nil {
+			logging.FromContext(c).Error("failed to read employee row", "error", err)
+			continue
+		}
+		reports = append(reports, emp)
+	}
+	rows.Close()
+
+	if err := services.WithCustomFields(h.db, reports); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch direct reports", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+// GetOrgChart returns the reporting tree below an employee
+func (h *EmployeeHandler) GetOrgChart(c *gin.Context) {
+	manager, err := h.findEmployee(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	chart, size, err := services.OrgChart(h.db, manager.EmployeeID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch org chart", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"org_chart": chart,
+		"count":     size,
+	})
+}
+
+// UploadEmployeePhoto stores the image in the photo form field as an
+// employee's photo, replacing any previous one
+func (h *EmployeeHandler) UploadEmployeePhoto(c *gin.Context) {
+	before, err := h.findEmployee(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("photo")
+	if err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, "photo", "photo is required")
+		return
+	}
+	if fileHeader.Size > services.MaxPhotoSize {
+		respondInvalidField(c, errcodes.ValidationFailed, "photo",
+			fmt.Sprintf("photo must be at most %d MB", services.MaxPhotoSize>>20))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, "photo", "photo could not be read")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, "photo", "photo could not be read")
+		return
+	}
+
+	now := time.Now()
+	key, err := services.PhotoKey(before.ID, http.DetectContentType(data), now)
+	if err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, "photo", "photo "+err.Error())
+		return
+	}
+	if err := h.blobs.Put(key, bytes.NewReader(data)); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to store photo", err)
+		return
+	}
+	if !h.setPhoto(c, before, &key, now, "Failed to store photo") {
+		if err := h.blobs.Delete(key); err != nil {
+			logging.FromContext(c).Error("failed to delete unused photo", "key", key, "error", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Photo uploaded successfully",
+		"photo_url": services.PhotoURL(before.ID),
+	})
+}
+
+// GetEmployeePhoto serves an employee's photo
+func (h *EmployeeHandler) GetEmployeePhoto(c *gin.Context) {
+	employee, err := h.findEmployee(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+	if employee.PhotoKey == nil {
+		respondError(c, http.StatusNotFound, errcodes.PhotoNotFound, "Employee has no photo", nil)
+		return
+	}
+
+	photo, err := h.blobs.Open(*employee.PhotoKey)
+	if err != nil {
+		if errors.Is(err, services.ErrBlobNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.PhotoNotFound, "Employee has no photo", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to read photo", err)
+		return
+	}
+	defer photo.Close()
+
+	c.DataFromReader(http.StatusOK, -1, services.PhotoContentType(*employee.PhotoKey), photo, nil)
+}
+
+// DeleteEmployeePhoto removes an employee's photo
+func (h *EmployeeHandler) DeleteEmployeePhoto(c *gin.Context) {
+	before, err := h.findEmployee(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, errcodes.EmployeeNotFound, "Employee not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employee", err)
+		return
+	}
+	if before.PhotoKey == nil {
+		respondError(c, http.StatusNotFound, errcodes.PhotoNotFound, "Employee has no photo", nil)
+		return
+	}
+
+	if !h.setPhoto(c, before, nil, time.Now(), "Failed to delete photo") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted successfully"})
+}
+
+// ExportEmployeesCSV exports the list of employees who have not been deleted
+// to a CSV file
 func (h *EmployeeHandler) ExportEmployeesCSV(c *gin.Context) {
 	query := `
-		SELECT e.id, e.employee_id, e.departement_id, e.name, e.address, 
-		       e.created_at, e.updated_at,
-		       d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time
+		SELECT ` + services.EmployeeWithDepartmentColumns + `
 		FROM employee e
 		LEFT JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NULL
 		ORDER BY e.created_at DESC
 	`
 
 	rows, err := h.db.Query(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
 		return
 	}
 	defer rows.Close()
 
 	var employees []models.EmployeeWithDepartment
 	for rows.Next() {
-		var emp models.EmployeeWithDepartment
-		var dept models.Department
-		err := rows.Scan(
-			&emp.ID, &emp.EmployeeID, &emp.DepartementID, &emp.Name, &emp.Address,
-			&emp.CreatedAt, &emp.UpdatedAt,
-			&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime,
-		)
+		emp, err := services.ScanEmployeeWithDepartment(rows)
 		if err != nil {
+			logging.FromContext(c).Error("failed to read employee row", "error", err)
 			continue
 		}
-		emp.Department = dept
 		employees = append(employees, emp)
 	}
+	rows.Close()
+
+	if err := services.WithCustomFields(h.db, employees); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch employees", err)
+		return
+	}
+	fields, err := services.CustomFields(h.db)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch custom fields", err)
+		return
+	}
 
 	// Create CSV export service
 	csvService := services.NewCSVExportService()
 
 	// Generate filename
 	filename := csvService.GenerateFilename("employees")
-	filepath := filepath.Join("exports", filename)
+	filepath := filepath.Join(h.exportDir, filename)
 
 	// Create exports directory if it doesn't exist
-	if err := os.MkdirAll("exports", 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exports directory"})
+	if err := os.MkdirAll(h.exportDir, 0755); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create exports directory", err)
 		return
 	}
 
 	// Export to CSV
-	if err := csvService.ExportEmployeeList(employees, filepath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export CSV"})
+	if err := csvService.ExportEmployeeList(employees, fields, filepath); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to export CSV", err)
 		return
 	}
 
@@ -284,3 +864,136 @@ func (h *EmployeeHandler) ExportEmployeesCSV(c *gin.Context) {
 	// Send file
 	c.File(filepath)
 }
+
+// ImportEmployees bulk imports employees from a CSV or XLSX file using the
+// column layout produced by ExportEmployeesCSV. The default dry-run mode only
+// validates; mode=commit inserts every row or none of them.
+func (h *EmployeeHandler) ImportEmployees(c *gin.Context) {
+	rows, mode, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewEmployeeImportService(h.db).Import(rows, mode, auditActor(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to import employees", err)
+		return
+	}
+
+	respondImport(c, report.Mode, report.ErrorRows, report, "Employees imported successfully")
+}
+
+// checkManager verifies in tx that managerID may manage employeeID,
+// responding with an error and returning false otherwise
+func (h *EmployeeHandler) checkManager(c *gin.Context, tx *sql.Tx, employeeID, managerID, msg string) bool {
+	err := services.CheckManager(h.db, tx, employeeID, managerID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrManagerNotFound):
+		respondInvalidField(c, errcodes.ManagerNotFound, "manager_id", "Manager not found")
+	case errors.Is(err, services.ErrManagerCycle):
+		respondInvalidField(c, errcodes.ManagerCycle, "manager_id", "Manager cannot report to this employee")
+	default:
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+	}
+	return false
+}
+
+// scopeToTeam limits *managerID to the team of the request's actor as
+// decided by services.ScopeToTeam, responding with an error and returning
+// false when the actor asked for someone else's team
+func scopeToTeam(c *gin.Context, db *sql.DB, managerID *string, msg string) bool {
+	scoped, err := services.ScopeToTeam(db, c.GetHeader(ActorHeader), *managerID)
+	switch {
+	case err == nil:
+		*managerID = scoped
+		return true
+	case errors.Is(err, services.ErrOutsideTeam):
+		respondError(c, http.StatusForbidden, errcodes.OutsideTeam, "Manager is outside your team", nil)
+	default:
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+	}
+	return false
+}
+
+// setPhoto records key, or nil, as the photo of before and removes the
+// previous photo from the blob store once that is committed. On failure it
+// writes an error response with msg and returns false.
+func (h *EmployeeHandler) setPhoto(c *gin.Context, before models.Employee, key *string, now time.Time, msg string) bool {
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+		return false
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE employee SET photo_key = ?, updated_at = ? WHERE id = ?", key, now, before.ID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+		return false
+	}
+
+	employee := before
+	employee.PhotoKey = key
+	employee.PhotoURL = nil
+	if key != nil {
+		employee.PhotoURL = services.PhotoURL(employee.ID)
+	}
+	employee.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityEmployee,
+		EntityID:   strconv.Itoa(employee.ID),
+		Before:     before,
+		After:      employee,
+	}
+	if !recordAudit(c, h.db, tx, msg, change) {
+		return false
+	}
+	event := services.NewEvent(models.EventEmployeeUpdated, employee)
+	if !commitEvents(c, h.outbox, tx, msg, services.EmployeeEventKey(employee.EmployeeID), event) {
+		return false
+	}
+
+	if before.PhotoKey != nil {
+		if err := h.blobs.Delete(*before.PhotoKey); err != nil {
+			logging.FromContext(c).Error("failed to delete previous photo", "key", *before.PhotoKey, "error", err)
+		}
+	}
+	return true
+}
+
+// validateCustomValues checks the custom field values of a request, writing
+// an error response and returning false when they are invalid
+func (h *EmployeeHandler) validateCustomValues(c *gin.Context, values map[string]interface{}) (map[int]*string, bool) {
+	if len(values) == 0 {
+		return nil, true
+	}
+	fields, err := services.CustomFields(h.db)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch custom fields", err)
+		return nil, false
+	}
+	stored, field, err := services.ValidateCustomValues(fields, values)
+	if err != nil {
+		respondInvalidField(c, errcodes.ValidationFailed, field, field+" "+err.Error())
+		return nil, false
+	}
+	return stored, true
+}
+
+// findEmployee loads an employee that has not been deleted, with their
+// custom fields, returning sql.ErrNoRows otherwise
+func (h *EmployeeHandler) findEmployee(id string) (models.Employee, error) {
+	emp, err := services.ScanEmployee(h.db.QueryRow(`
+		SELECT `+services.EmployeeColumns+`
+		FROM employee e
+		WHERE e.id = ? AND e.deleted_at IS NULL
+	`, id))
+	if err != nil {
+		return emp, err
+	}
+	emp.CustomFields, err = services.EmployeeCustomFields(h.db, emp.EmployeeID)
+	return emp, err
+}
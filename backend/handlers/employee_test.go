@@ -2,23 +2,30 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"attendance-system/errcodes"
 	"attendance-system/models"
+	"attendance-system/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestRouter(db *sql.DB) *gin.Engine {
+func setupTestRouter(db *sql.DB, outbox *services.Outbox) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	
-	employeeHandler := NewEmployeeHandler(db)
+	employeeHandler := NewEmployeeHandler(db, outbox, newMemoryBlobStore(), os.TempDir())
 	
 	api := r.Group("/api/v1")
 	{
@@ -29,6 +36,13 @@ func setupTestRouter(db *sql.DB) *gin.Engine {
 			employees.GET("/:id", employeeHandler.GetEmployee)
 			employees.PUT("/:id", employeeHandler.UpdateEmployee)
 			employees.DELETE("/:id", employeeHandler.DeleteEmployee)
+			employees.GET("/:id/assignments", employeeHandler.GetEmployeeAssignments)
+			employees.GET("/:id/reports", employeeHandler.GetDirectReports)
+			employees.GET("/:id/org-chart", employeeHandler.GetOrgChart)
+			employees.POST("/:id/transfers", employeeHandler.TransferEmployee)
+			employees.PUT("/:id/photo", employeeHandler.UploadEmployeePhoto)
+			employees.GET("/:id/photo", employeeHandler.GetEmployeePhoto)
+			employees.DELETE("/:id/photo", employeeHandler.DeleteEmployeePhoto)
 		}
 	}
 	
@@ -73,3 +87,557 @@ func TestGetEmployees(t *testing.T) {
 		assert.NotNil(t, w)
 	})
 }
+
+func TestEmploymentLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT Department",
+		"max_clock_in_time":  "09:00:00",
+		"max_clock_out_time": "17:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": department.Department.ID, "name": "John Doe",
+		"employment_status": "terminated",
+	})
+	apiErr := assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "termination_date", apiErr.Details[0].Field)
+	}
+
+	w = doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": department.Department.ID, "name": "John Doe",
+		"hire_date": "2020-01-31",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Employee models.Employee `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.EmploymentActive, created.Employee.EmploymentStatus)
+	employeePath := fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Terminated yesterday: clock-ins are refused, the record is kept
+	w = doJSON(r, "PUT", employeePath, map[string]interface{}{
+		"departement_id": department.Department.ID, "name": "John Doe",
+		"employment_status": "terminated", "termination_date": yesterday,
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	apiErr = assertErrorCode(t, w, http.StatusConflict, errcodes.EmployeeNotActive)
+	assert.Contains(t, apiErr.Message, yesterday)
+
+	w = doJSON(r, "GET", employeePath, nil)
+	var fetched struct {
+		Employee models.EmployeeWithDepartment `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, models.EmploymentTerminated, fetched.Employee.EmploymentStatus)
+	if assert.NotNil(t, fetched.Employee.HireDate) && assert.NotNil(t, fetched.Employee.TerminationDate) {
+		assert.Equal(t, "2020-01-31", *fetched.Employee.HireDate, "omitted fields are kept")
+		assert.Equal(t, yesterday, *fetched.Employee.TerminationDate)
+	}
+
+	// Deleting keeps the row and its history but hides it from lists
+	w = doJSON(r, "DELETE", employeePath, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "DELETE", employeePath, nil)
+	assertErrorCode(t, w, http.StatusNotFound, errcodes.EmployeeNotFound)
+
+	w = doJSON(r, "GET", "/api/v1/employees/", nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+	w = doJSON(r, "GET", "/api/v1/employees/?include_deleted=true&status=terminated", nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	w = doJSON(r, "GET", "/api/v1/employees/?status=retired", nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+
+	w = doJSON(r, "GET", employeePath, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.NotNil(t, fetched.Employee.DeletedAt)
+
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+time.Now().Format("2006-01-02"), nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/v1/departments/%d", department.Department.ID), nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.DepartmentHasEmployees)
+}
+
+func TestTransferEmployee(t *testing.T) {
+	db := openTestDB(t)
+	outbox, recorder := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	var departmentIDs []int
+	for _, dept := range []map[string]string{
+		{"departement_name": "Night Shift", "max_clock_in_time": "23:59:59", "max_clock_out_time": "23:59:59"},
+		{"departement_name": "Early Shift", "max_clock_in_time": "00:00:00", "max_clock_out_time": "00:00:00"},
+	} {
+		w := doJSON(r, "POST", "/api/v1/departments/", dept)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct {
+			Department models.Department `json:"department"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		departmentIDs = append(departmentIDs, created.Department.ID)
+	}
+	night, early := departmentIDs[0], departmentIDs[1]
+
+	w := doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": night, "name": "John Doe", "hire_date": "2020-01-31",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Employee models.Employee `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	employeePath := fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	today := time.Now().Format("2006-01-02")
+	var logs struct {
+		Logs []models.AttendanceLog `json:"attendance_logs"`
+	}
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+today, nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	if assert.Len(t, logs.Logs, 1) {
+		assert.Equal(t, night, logs.Logs[0].DepartmentID)
+		assert.True(t, logs.Logs[0].IsOnTime)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	w = doJSON(r, "POST", employeePath+"/transfers", map[string]interface{}{"departement_id": early, "effective_from": tomorrow})
+	apiErr := assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "effective_from", apiErr.Details[0].Field)
+	}
+	w = doJSON(r, "POST", employeePath+"/transfers", map[string]interface{}{"departement_id": early, "effective_from": "31/01/2020"})
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+
+	// Transferring from today re-evaluates today's punch against the new schedule
+	w = doJSON(r, "POST", employeePath+"/transfers", map[string]interface{}{"departement_id": early, "effective_from": today})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?date="+today, nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	if assert.Len(t, logs.Logs, 1) {
+		assert.Equal(t, early, logs.Logs[0].DepartmentID)
+		assert.Equal(t, "Early Shift", logs.Logs[0].DepartmentName)
+		assert.False(t, logs.Logs[0].IsOnTime)
+	}
+	w = doJSON(r, "GET", fmt.Sprintf("/api/v1/attendance/logs?date=%s&department_id=%d", today, night), nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+
+	var history struct {
+		Assignments []models.DepartmentAssignment `json:"assignments"`
+	}
+	w = doJSON(r, "GET", employeePath+"/assignments", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	if assert.Len(t, history.Assignments, 2) {
+		assert.Equal(t, "2020-01-31", history.Assignments[0].EffectiveFrom)
+		assert.Equal(t, night, history.Assignments[0].DepartementID)
+		assert.Equal(t, today, history.Assignments[1].EffectiveFrom)
+		assert.Nil(t, history.Assignments[1].EffectiveUntil)
+	}
+
+	w = doJSON(r, "GET", employeePath, nil)
+	assert.Contains(t, w.Body.String(), `"departement_name":"Early Shift"`)
+
+	// The night shift is no longer current but still holds John's history
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/v1/departments/%d", night), nil)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.DepartmentHasHistory)
+
+	_, err := outbox.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, recorder.types(), models.EventEmployeeTransferred)
+}
+
+func TestDepartmentChangeKeepsEarlierPunches(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	var departmentIDs []int
+	for _, dept := range []map[string]string{
+		{"departement_name": "Night Shift", "max_clock_in_time": "23:59:59", "max_clock_out_time": "23:59:59"},
+		{"departement_name": "Early Shift", "max_clock_in_time": "00:00:00", "max_clock_out_time": "00:00:00"},
+	} {
+		w := doJSON(r, "POST", "/api/v1/departments/", dept)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct {
+			Department models.Department `json:"department"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		departmentIDs = append(departmentIDs, created.Department.ID)
+	}
+	night, early := departmentIDs[0], departmentIDs[1]
+
+	w := doJSON(r, "POST", "/api/v1/employees/", map[string]interface{}{
+		"employee_id": "EMP001", "departement_id": night, "name": "John Doe", "hire_date": "2020-01-31",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Employee models.Employee `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	employeePath := fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+
+	w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Moving John on his record later the same day leaves the clock-in alone
+	w = doJSON(r, "PUT", employeePath, map[string]interface{}{"departement_id": early, "name": "John Doe"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "PUT", "/api/v1/attendance/clock-out", map[string]string{"employee_id": "EMP001"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assertLogs := func() {
+		t.Helper()
+		w := doJSON(r, "GET", "/api/v1/attendance/logs?date="+time.Now().Format("2006-01-02"), nil)
+		var logs struct {
+			Logs []models.AttendanceLog `json:"attendance_logs"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+		if !assert.Len(t, logs.Logs, 2) {
+			return
+		}
+		clockOut, clockIn := logs.Logs[0], logs.Logs[1]
+		assert.Equal(t, 1, clockIn.AttendanceType)
+		assert.Equal(t, night, clockIn.DepartmentID)
+		assert.Equal(t, "23:59:59", clockIn.MaxClockInTime)
+		assert.True(t, clockIn.IsOnTime)
+		assert.Equal(t, 2, clockOut.AttendanceType)
+		assert.Equal(t, early, clockOut.DepartmentID)
+		assert.Equal(t, "00:00:00", clockOut.MaxClockOutTime)
+	}
+	assertLogs()
+
+	// As does re-evaluating all stored attendance
+	tx, err := db.Begin()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = services.RecomputeAttendance(tx, "", time.Time{}, time.Time{}, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assertLogs()
+
+	var history struct {
+		Assignments []models.DepartmentAssignment `json:"assignments"`
+	}
+	w = doJSON(r, "GET", employeePath+"/assignments", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	if assert.Len(t, history.Assignments, 2) {
+		assert.Equal(t, time.Now().Format("2006-01-02"), history.Assignments[1].EffectiveFrom)
+		assert.Equal(t, early, history.Assignments[1].DepartementID)
+	}
+}
+
+func TestManagerHierarchy(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT Department",
+		"max_clock_in_time":  "09:00:00",
+		"max_clock_out_time": "17:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+
+	paths := make(map[string]string)
+	create := func(employeeID, managerID string) *httptest.ResponseRecorder {
+		body := map[string]interface{}{
+			"employee_id": employeeID, "departement_id": department.Department.ID, "name": "Employee " + employeeID,
+		}
+		if managerID != "" {
+			body["manager_id"] = managerID
+		}
+		w := doJSON(r, "POST", "/api/v1/employees/", body)
+		var created struct {
+			Employee models.Employee `json:"employee"`
+		}
+		if w.Code == http.StatusCreated && assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created)) {
+			paths[employeeID] = fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+		}
+		return w
+	}
+	setManager := func(employeeID, managerID string) *httptest.ResponseRecorder {
+		return doJSON(r, "PUT", paths[employeeID], map[string]interface{}{
+			"departement_id": department.Department.ID, "name": "Employee " + employeeID, "manager_id": managerID,
+		})
+	}
+
+	// M manages L, who manages E; X has no manager
+	for _, employee := range [][2]string{{"M", ""}, {"L", "M"}, {"E", "L"}, {"X", ""}} {
+		w = create(employee[0], employee[1])
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	apiErr := assertErrorCode(t, create("N", "NOPE"), http.StatusBadRequest, errcodes.ManagerNotFound)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "manager_id", apiErr.Details[0].Field)
+	}
+	assertErrorCode(t, setManager("M", "E"), http.StatusBadRequest, errcodes.ManagerCycle)
+	assertErrorCode(t, setManager("M", "M"), http.StatusBadRequest, errcodes.ManagerCycle)
+
+	w = doJSON(r, "GET", paths["M"]+"/reports", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reports struct {
+		Reports []models.EmployeeWithDepartment `json:"reports"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reports))
+	if assert.Len(t, reports.Reports, 1) {
+		assert.Equal(t, "L", reports.Reports[0].EmployeeID)
+	}
+
+	w = doJSON(r, "GET", paths["M"]+"/org-chart", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var chart struct {
+		OrgChart models.OrgChartNode `json:"org_chart"`
+		Count    int                 `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &chart))
+	assert.Equal(t, 2, chart.Count)
+	if assert.Len(t, chart.OrgChart.Reports, 1) && assert.Len(t, chart.OrgChart.Reports[0].Reports, 1) {
+		assert.Equal(t, "E", chart.OrgChart.Reports[0].Reports[0].Employee.EmployeeID)
+	}
+
+	w = doJSON(r, "GET", "/api/v1/employees/?manager_id=M", nil)
+	assert.Contains(t, w.Body.String(), `"count":2`)
+	w = doJSON(r, "GET", "/api/v1/employees/?manager_id=M&direct_only=true", nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	w = doJSON(r, "GET", "/api/v1/employees/?manager_id=X", nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+
+	// Managers only see their people's attendance
+	for _, employeeID := range []string{"E", "X"} {
+		w = doJSON(r, "POST", "/api/v1/attendance/clock-in", map[string]string{"employee_id": employeeID})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?manager_id=M", nil)
+	var logs struct {
+		Logs []models.AttendanceLog `json:"attendance_logs"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	if assert.Len(t, logs.Logs, 1) {
+		assert.Equal(t, "E", logs.Logs[0].EmployeeID)
+	}
+	w = doJSON(r, "GET", "/api/v1/attendance/logs?manager_id=M&direct_only=true", nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+
+	// Employees acting for themselves are held to their own team
+	w = doJSONAs(r, "L", "GET", "/api/v1/attendance/logs", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &logs))
+	if assert.Len(t, logs.Logs, 1) {
+		assert.Equal(t, "E", logs.Logs[0].EmployeeID)
+	}
+	w = doJSONAs(r, "M", "GET", "/api/v1/attendance/logs?manager_id=L", nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	assertErrorCode(t, doJSONAs(r, "L", "GET", "/api/v1/attendance/logs?manager_id=M", nil),
+		http.StatusForbidden, errcodes.OutsideTeam)
+	assertErrorCode(t, doJSONAs(r, "X", "GET", "/api/v1/attendance/logs?manager_id=L", nil),
+		http.StatusForbidden, errcodes.OutsideTeam)
+	w = doJSONAs(r, "X", "GET", "/api/v1/attendance/logs", nil)
+	assert.Contains(t, w.Body.String(), `"count":0`)
+	w = doJSONAs(r, "L", "GET", "/api/v1/employees/", nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	w = doJSONAs(r, "hr-admin", "GET", "/api/v1/attendance/logs", nil)
+	assert.Contains(t, w.Body.String(), `"count":2`)
+
+	// L keeps their reports until E is moved
+	assertErrorCode(t, doJSON(r, "DELETE", paths["L"], nil), http.StatusBadRequest, errcodes.EmployeeHasReports)
+	w = setManager("E", "M")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "DELETE", paths["L"], nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "GET", "/api/v1/employees/?manager_id=M&direct_only=true", nil)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+
+	w = setManager("E", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "GET", paths["E"], nil)
+	var fetched struct {
+		Employee models.EmployeeWithDepartment `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Nil(t, fetched.Employee.ManagerID)
+}
+
+// uploadPhoto sends data as the photo of the employee at path
+func uploadPhoto(r *gin.Engine, path string, data []byte) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, _ := form.CreateFormFile("photo", "photo")
+	part.Write(data)
+	form.Close()
+	req, _ := http.NewRequest("PUT", path+"/photo", &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestEmployeeProfile(t *testing.T) {
+	db := openTestDB(t)
+	outbox, _ := newTestOutbox(db)
+	r := setupAttendanceRouter(db, outbox)
+
+	w := doJSON(r, "POST", "/api/v1/departments/", map[string]string{
+		"departement_name":   "IT Department",
+		"max_clock_in_time":  "09:00:00",
+		"max_clock_out_time": "17:00:00",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var department struct {
+		Department models.Department `json:"department"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &department))
+
+	w = doJSON(r, "POST", "/api/v1/custom-fields/", map[string]interface{}{
+		"name": "shirt_size", "label": "Shirt size", "field_type": "select", "options": []string{"S", "M", "L"},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var shirtSize struct {
+		CustomField models.CustomField `json:"custom_field"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &shirtSize))
+	w = doJSON(r, "POST", "/api/v1/custom-fields/", map[string]interface{}{
+		"name": "badge_number", "label": "Badge number", "field_type": "number",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doJSON(r, "POST", "/api/v1/custom-fields/", map[string]interface{}{
+		"name": "shirt_size", "label": "Shirt size", "field_type": "text",
+	})
+	assertErrorCode(t, w, http.StatusConflict, errcodes.CustomFieldExists)
+	w = doJSON(r, "POST", "/api/v1/custom-fields/", map[string]interface{}{
+		"name": "Parking Spot", "label": "Parking spot", "field_type": "text",
+	})
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+	w = doJSON(r, "POST", "/api/v1/custom-fields/", map[string]interface{}{
+		"name": "team", "label": "Team", "field_type": "select",
+	})
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+
+	create := func(employeeID string, profile map[string]interface{}) *httptest.ResponseRecorder {
+		body := map[string]interface{}{
+			"employee_id": employeeID, "departement_id": department.Department.ID, "name": "Employee " + employeeID,
+		}
+		for key, value := range profile {
+			body[key] = value
+		}
+		return doJSON(r, "POST", "/api/v1/employees/", body)
+	}
+
+	w = create("EMP001", map[string]interface{}{
+		"email": "ada@example.com", "phone": "+44 20 7946 0000", "job_title": "Engineer",
+		"employment_type": "contractor", "work_location": "London",
+		"custom_fields": map[string]interface{}{"shirt_size": "M", "badge_number": 42},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Employee models.Employee `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "contractor", created.Employee.EmploymentType)
+	assert.Equal(t, map[string]interface{}{"shirt_size": "M", "badge_number": float64(42)}, created.Employee.CustomFields)
+	assert.Nil(t, created.Employee.PhotoURL)
+	path := fmt.Sprintf("/api/v1/employees/%d", created.Employee.ID)
+
+	w = create("EMP002", nil)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"employment_type":"full_time"`)
+
+	apiErr := assertErrorCode(t, create("EMP003", map[string]interface{}{"email": "not-an-email"}),
+		http.StatusBadRequest, errcodes.ValidationFailed)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "email", apiErr.Details[0].Field)
+	}
+	apiErr = assertErrorCode(t, create("EMP003", map[string]interface{}{
+		"custom_fields": map[string]interface{}{"shirt_size": "XXL"},
+	}), http.StatusBadRequest, errcodes.ValidationFailed)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "custom_fields.shirt_size", apiErr.Details[0].Field)
+	}
+	assertErrorCode(t, create("EMP003", map[string]interface{}{
+		"custom_fields": map[string]interface{}{"unknown": "x"},
+	}), http.StatusBadRequest, errcodes.ValidationFailed)
+
+	// Search covers profile fields and custom values
+	for query, count := range map[string]int{
+		"q=engineer": 1, "q=ADA%40": 1, "q=42": 1, "q=employee": 2, "employment_type=contractor": 1,
+		"employment_type=full_time": 1, "work_location=London": 1, "work_location=Lon": 0,
+	} {
+		w = doJSON(r, "GET", "/api/v1/employees/?"+query, nil)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"count":%d`, count), query)
+	}
+
+	// Options still in use cannot be dropped
+	fieldPath := fmt.Sprintf("/api/v1/custom-fields/%d", shirtSize.CustomField.ID)
+	w = doJSON(r, "PUT", fieldPath, map[string]interface{}{"label": "Shirt size", "options": []string{"S", "L"}})
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+
+	// Updating leaves fields that are not sent alone and null removes a custom value
+	w = doJSON(r, "PUT", path, map[string]interface{}{
+		"departement_id": department.Department.ID, "name": "Employee EMP001", "job_title": "Lead Engineer",
+		"custom_fields": map[string]interface{}{"shirt_size": nil},
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "GET", path, nil)
+	var fetched struct {
+		Employee models.EmployeeWithDepartment `json:"employee"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, "Lead Engineer", fetched.Employee.JobTitle)
+	assert.Equal(t, "ada@example.com", fetched.Employee.Email)
+	assert.Equal(t, map[string]interface{}{"badge_number": float64(42)}, fetched.Employee.CustomFields)
+	w = doJSON(r, "PUT", fieldPath, map[string]interface{}{"label": "Shirt size", "options": []string{"S", "L"}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Photos
+	assertErrorCode(t, doJSON(r, "GET", path+"/photo", nil), http.StatusNotFound, errcodes.PhotoNotFound)
+	assertErrorCode(t, uploadPhoto(r, path, []byte("just some text")), http.StatusBadRequest, errcodes.ValidationFailed)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	w = uploadPhoto(r, path, png)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "GET", path+"/photo", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, png, w.Body.Bytes())
+	w = doJSON(r, "GET", path, nil)
+	assert.Contains(t, w.Body.String(), `"photo_url":"`+path+`/photo"`)
+	w = doJSON(r, "DELETE", path+"/photo", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assertErrorCode(t, doJSON(r, "GET", path+"/photo", nil), http.StatusNotFound, errcodes.PhotoNotFound)
+
+	// Deleting a field removes it from every employee
+	w = doJSON(r, "GET", "/api/v1/custom-fields/", nil)
+	var fields struct {
+		CustomFields []models.CustomField `json:"custom_fields"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fields))
+	for _, field := range fields.CustomFields {
+		w = doJSON(r, "DELETE", fmt.Sprintf("/api/v1/custom-fields/%d", field.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = doJSON(r, "GET", path, nil)
+	assert.Contains(t, w.Body.String(), `"custom_fields":{}`)
+	assertErrorCode(t, doJSON(r, "DELETE", fieldPath, nil), http.StatusNotFound, errcodes.CustomFieldNotFound)
+}
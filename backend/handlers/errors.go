@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report invalid fields by the names clients send rather than Go names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// respondError writes the error envelope with code and the request ID. When
+// err is set it is logged with the request context, since the response only
+// holds a generic message.
+func respondError(c *gin.Context, status int, code errcodes.Code, message string, err error) {
+	if err != nil {
+		logger := logging.FromContext(c)
+		if status >= http.StatusInternalServerError {
+			logger.Error(message, "status", status, "code", code, "error", err)
+		} else {
+			logger.Warn(message, "status", status, "code", code, "error", err)
+		}
+	}
+
+	c.JSON(status, models.ErrorResponse{Error: newAPIError(c, code, message, nil)})
+}
+
+// respondInvalidField writes a 400 naming the single field that was rejected
+func respondInvalidField(c *gin.Context, code errcodes.Code, field, message string) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: newAPIError(c, code, message, []models.ErrorDetail{
+		{Field: field, Message: message},
+	})})
+}
+
+// newAPIError builds the error envelope for the current request
+func newAPIError(c *gin.Context, code errcodes.Code, message string, details []models.ErrorDetail) models.APIError {
+	return models.APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: logging.GetRequestID(c),
+	}
+}
+
+// bindJSON binds the request body into obj. On failure it writes a 400
+// listing every invalid field and returns false.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		respondBindError(c, err, "Request body is not valid JSON")
+		return false
+	}
+	return true
+}
+
+// bindQuery binds the query parameters into obj. On failure it writes a 400
+// listing every invalid parameter and returns false.
+func bindQuery(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		respondBindError(c, err, "Invalid query parameters")
+		return false
+	}
+	return true
+}
+
+// respondBindError describes a binding failure, with a detail per field when
+// the failure can be traced to fields
+func respondBindError(c *gin.Context, err error, message string) {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		details := make([]models.ErrorDetail, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			details = append(details, validationDetail(fieldErr))
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: newAPIError(c, errcodes.ValidationFailed, "Request validation failed", details),
+		})
+	case errors.As(err, &typeErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: newAPIError(c, errcodes.InvalidRequest, "Request body has a field of the wrong type", []models.ErrorDetail{{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+			}}),
+		})
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: newAPIError(c, errcodes.InvalidRequest, message+": "+err.Error(), nil),
+		})
+	}
+}
+
+// validationDetail turns a failed binding rule into a detail such as
+// "recipients[0] must be a valid email address"
+func validationDetail(fieldErr validator.FieldError) models.ErrorDetail {
+	field := fieldErr.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	var rule string
+	switch fieldErr.Tag() {
+	case "required":
+		rule = "is required"
+	case "oneof":
+		rule = "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "email":
+		rule = "must be a valid email address"
+	case "url":
+		rule = "must be a valid URL"
+	case "datetime":
+		if fieldErr.Param() == "2006-01-02" {
+			rule = "must be a date formatted as YYYY-MM-DD"
+		} else {
+			rule = "must be formatted as " + fieldErr.Param()
+		}
+	case "min":
+		switch fieldErr.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			rule = "must have at least " + fieldErr.Param() + " items"
+		case reflect.String:
+			rule = "must be at least " + fieldErr.Param() + " characters"
+		default:
+			rule = "must be at least " + fieldErr.Param()
+		}
+	default:
+		rule = "failed the " + fieldErr.Tag() + " rule"
+	}
+
+	return models.ErrorDetail{
+		Field:   field,
+		Rule:    fieldErr.Tag(),
+		Message: field + " " + rule,
+	}
+}
+
+// jsonTypeName names the JSON type expected for a Go type
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// requestFieldName returns the JSON or query parameter name of a field
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// RouteNotFound answers requests that match no route
+func RouteNotFound(c *gin.Context) {
+	respondError(c, http.StatusNotFound, errcodes.RouteNotFound, "No route matches "+c.Request.Method+" "+c.Request.URL.Path, nil)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertErrorCode checks that w is an error envelope with status and code
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, status int, code errcodes.Code) models.APIError {
+	t.Helper()
+	assert.Equal(t, status, w.Code, w.Body.String())
+
+	var body models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, code, body.Error.Code, w.Body.String())
+	return body.Error
+}
+
+func TestErrorResponseIncludesRequestID(t *testing.T) {
+	db := openTestDB(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logging.RequestID())
+	r.GET("/api/v1/departments/:id", NewDepartmentHandler(db, nil, os.TempDir()).GetDepartment)
+
+	req := httptest.NewRequest("GET", "/api/v1/departments/999", nil)
+	req.Header.Set(logging.RequestIDHeader, "support-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	apiErr := assertErrorCode(t, w, http.StatusNotFound, errcodes.DepartmentNotFound)
+	assert.Equal(t, "Department not found", apiErr.Message)
+	assert.Equal(t, "support-42", apiErr.RequestID)
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	db := openTestDB(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/report-schedules/", NewReportScheduleHandler(db, nil).CreateReportSchedule)
+
+	w := doJSON(r, "POST", "/api/v1/report-schedules/", map[string]interface{}{
+		"cron_expr":   "0 8 * * *",
+		"report_type": "weekly",
+		"recipients":  []string{"hr@example.com", "not-an-email"},
+	})
+
+	apiErr := assertErrorCode(t, w, http.StatusBadRequest, errcodes.ValidationFailed)
+	assert.ElementsMatch(t, []models.ErrorDetail{
+		{Field: "name", Rule: "required", Message: "name is required"},
+		{Field: "report_type", Rule: "oneof", Message: "report_type must be one of late_arrivals, attendance_summary, absences"},
+		{Field: "recipients[1]", Rule: "email", Message: "recipients[1] must be a valid email address"},
+	}, apiErr.Details)
+
+	w = doJSON(r, "POST", "/api/v1/report-schedules/", map[string]interface{}{"name": 5})
+	apiErr = assertErrorCode(t, w, http.StatusBadRequest, errcodes.InvalidRequest)
+	if assert.Len(t, apiErr.Details, 1) {
+		assert.Equal(t, "name", apiErr.Details[0].Field)
+		assert.Equal(t, "name must be a string", apiErr.Details[0].Message)
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/report-schedules/", strings.NewReader("{"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertErrorCode(t, w, http.StatusBadRequest, errcodes.InvalidRequest)
+}
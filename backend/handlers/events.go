@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commitEvents records events under the ordering key in tx and commits it,
+// so the events exist exactly when the change does. On failure it writes an
+// error response with msg and returns false; the caller's deferred rollback
+// undoes the change.
+func commitEvents(c *gin.Context, outbox *services.Outbox, tx *sql.Tx, msg, key string, events ...models.Event) bool {
+	for _, event := range events {
+		if err := outbox.Add(tx, key, event); err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+			return false
+		}
+	}
+	if err := outbox.Commit(tx); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, msg, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles export job HTTP requests
+type ExportHandler struct {
+	db            *sql.DB
+	exportService *services.ExportJobService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(db *sql.DB, exportService *services.ExportJobService) *ExportHandler {
+	return &ExportHandler{db: db, exportService: exportService}
+}
+
+// CreateExportJob queues a new export job
+func (h *ExportHandler) CreateExportJob(c *gin.Context) {
+	var req models.CreateExportJobRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !scopeToTeam(c, h.db, &req.Filters.ManagerID, "Failed to create export job") {
+		return
+	}
+
+	job, err := h.exportService.Create(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidExportFilters):
+			respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, err.Error(), nil)
+		case errors.Is(err, services.ErrExportQueueFull):
+			respondError(c, http.StatusServiceUnavailable, errcodes.ExportQueueFull, "Export queue is full, try again later", nil)
+		default:
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create export job", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Export job created successfully",
+		"job":     job,
+	})
+}
+
+// GetExportJob reports the status and progress of an export job
+func (h *ExportHandler) GetExportJob(c *gin.Context) {
+	job, err := h.exportService.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrExportJobNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ExportJobNotFound, "Export job not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch export job", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// DownloadExportJob sends the file produced by a completed export job
+func (h *ExportHandler) DownloadExportJob(c *gin.Context) {
+	job, err := h.exportService.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrExportJobNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ExportJobNotFound, "Export job not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch export job", err)
+		return
+	}
+
+	switch job.Status {
+	case models.ExportJobCompleted:
+	case models.ExportJobExpired:
+		respondError(c, http.StatusGone, errcodes.ExportFileExpired, "Export file has expired", nil)
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  newAPIError(c, errcodes.ExportJobNotCompleted, "Export job is not completed", nil),
+			"status": job.Status,
+		})
+		return
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", "attachment; filename="+job.FileName)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Expires", "0")
+	c.Header("Cache-Control", "must-revalidate")
+	c.Header("Pragma", "public")
+
+	c.File(h.exportService.FilePath(job))
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checker *services.HealthChecker
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(checker *services.HealthChecker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Livez reports that the process is up. It does not check dependencies, so
+// an outage elsewhere does not get the instance restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusOK})
+}
+
+// Readyz reports whether the instance can serve traffic, responding with 503
+// and the failing checks when it cannot
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != models.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
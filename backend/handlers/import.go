@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readImportFile reads the mode query parameter and the uploaded "file" form
+// field. It writes an error response and returns ok=false on bad input.
+func readImportFile(c *gin.Context) (rows [][]string, mode string, ok bool) {
+	mode = c.DefaultQuery("mode", models.ImportModeDryRun)
+	if mode != models.ImportModeDryRun && mode != models.ImportModeCommit {
+		respondInvalidField(c, errcodes.InvalidRequest, "mode", "mode must be dry-run or commit")
+		return nil, "", false
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errcodes.ImportFileRequired, "File is required", nil)
+		return nil, "", false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errcodes.ImportFileInvalid, "Failed to read file", nil)
+		return nil, "", false
+	}
+	defer file.Close()
+
+	rows, err = services.ReadSpreadsheet(fileHeader.Filename, file)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errcodes.ImportFileInvalid, err.Error(), nil)
+		return nil, "", false
+	}
+
+	return rows, mode, true
+}
+
+// respondImport writes an import report, run in mode with errorRows invalid
+// rows, with a status matching its outcome
+func respondImport(c *gin.Context, mode string, errorRows int, report interface{}, successMessage string) {
+	if errorRows > 0 {
+		status := http.StatusOK
+		if mode == models.ImportModeCommit {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"error":  newAPIError(c, errcodes.ImportRowsInvalid, "Import file has validation errors", nil),
+			"report": report,
+		})
+		return
+	}
+
+	if mode == models.ImportModeCommit {
+		c.JSON(http.StatusOK, gin.H{
+			"message": successMessage,
+			"report":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import file is valid",
+		"report":  report,
+	})
+}
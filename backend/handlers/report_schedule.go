@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportScheduleHandler handles report schedule HTTP requests
+type ReportScheduleHandler struct {
+	db        *sql.DB
+	scheduler *services.ReportScheduler
+}
+
+// NewReportScheduleHandler creates a new report schedule handler
+func NewReportScheduleHandler(db *sql.DB, scheduler *services.ReportScheduler) *ReportScheduleHandler {
+	return &ReportScheduleHandler{db: db, scheduler: scheduler}
+}
+
+// CreateReportSchedule creates a new report schedule
+func (h *ReportScheduleHandler) CreateReportSchedule(c *gin.Context) {
+	var req models.CreateReportScheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := services.ValidateCronExpr(req.CronExpr); err != nil {
+		respondInvalidField(c, errcodes.InvalidCronExpression, "cron_expr", err.Error())
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = models.ReportFormatCSV
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, "Invalid filters", nil)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create report schedule", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	id, err := database.TxInsertReturningID(h.db, tx, `
+		INSERT INTO report_schedule (name, cron_expr, report_type, filters, recipients, format, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, req.CronExpr, req.ReportType, string(filters), services.JoinRecipients(req.Recipients),
+		format, enabled, now, now)
+
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create report schedule", err)
+		return
+	}
+
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityReportSchedule,
+		EntityID:   strconv.Itoa(int(id)),
+		After: models.ReportSchedule{
+			ID:         int(id),
+			Name:       req.Name,
+			CronExpr:   req.CronExpr,
+			ReportType: req.ReportType,
+			Filters:    req.Filters,
+			Recipients: req.Recipients,
+			Format:     format,
+			Enabled:    enabled,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+	if !recordAudit(c, h.db, tx, "Failed to create report schedule", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create report schedule", err)
+		return
+	}
+
+	if err := h.scheduler.Reload(int(id)); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to schedule report", err)
+		return
+	}
+
+	schedule, err := h.scheduler.Get(int(id))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch report schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":         "Report schedule created successfully",
+		"report_schedule": schedule,
+	})
+}
+
+// GetReportSchedules retrieves all report schedules
+func (h *ReportScheduleHandler) GetReportSchedules(c *gin.Context) {
+	rows, err := h.db.Query("SELECT id FROM report_schedule ORDER BY name")
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch report schedules", err)
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			logging.FromContext(c).Error("failed to read report schedule row", "error", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var schedules []models.ReportSchedule
+	for _, id := range ids {
+		schedule, err := h.scheduler.Get(id)
+		if err != nil {
+			logging.FromContext(c).Error("failed to load report schedule", "schedule_id", id, "error", err)
+			continue
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report_schedules": schedules,
+		"count":            len(schedules),
+	})
+}
+
+// GetReportSchedule retrieves a single report schedule by ID
+func (h *ReportScheduleHandler) GetReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "id", "Invalid report schedule ID")
+		return
+	}
+
+	schedule, err := h.scheduler.Get(id)
+	if err != nil {
+		if errors.Is(err, services.ErrReportScheduleNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ReportScheduleNotFound, "Report schedule not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch report schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report_schedule": schedule})
+}
+
+// UpdateReportSchedule updates an existing report schedule
+func (h *ReportScheduleHandler) UpdateReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "id", "Invalid report schedule ID")
+		return
+	}
+
+	var req models.UpdateReportScheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := services.ValidateCronExpr(req.CronExpr); err != nil {
+		respondInvalidField(c, errcodes.InvalidCronExpression, "cron_expr", err.Error())
+		return
+	}
+
+	before, ok := h.findReportSchedule(c, id)
+	if !ok {
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = models.ReportFormatCSV
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errcodes.InvalidFilters, "Invalid filters", nil)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update report schedule", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		UPDATE report_schedule
+		SET name = ?, cron_expr = ?, report_type = ?, filters = ?, recipients = ?, format = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, req.Name, req.CronExpr, req.ReportType, string(filters), services.JoinRecipients(req.Recipients),
+		format, enabled, now, id)
+
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update report schedule", err)
+		return
+	}
+
+	after := *before
+	after.Name = req.Name
+	after.CronExpr = req.CronExpr
+	after.ReportType = req.ReportType
+	after.Filters = req.Filters
+	after.Recipients = req.Recipients
+	after.Format = format
+	after.Enabled = enabled
+	after.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityReportSchedule,
+		EntityID:   strconv.Itoa(id),
+		Before:     before,
+		After:      after,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to update report schedule", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update report schedule", err)
+		return
+	}
+
+	if err := h.scheduler.Reload(id); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to schedule report", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report schedule updated successfully"})
+}
+
+// DeleteReportSchedule deletes a report schedule
+func (h *ReportScheduleHandler) DeleteReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "id", "Invalid report schedule ID")
+		return
+	}
+
+	before, ok := h.findReportSchedule(c, id)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete report schedule", err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM report_schedule WHERE id = ?", id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete report schedule", err)
+		return
+	}
+
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityReportSchedule,
+		EntityID:   strconv.Itoa(id),
+		Before:     before,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete report schedule", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete report schedule", err)
+		return
+	}
+
+	h.scheduler.Remove(id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report schedule deleted successfully"})
+}
+
+// RunReportSchedule generates and sends a scheduled report immediately
+func (h *ReportScheduleHandler) RunReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "id", "Invalid report schedule ID")
+		return
+	}
+
+	if err := h.scheduler.Run(id); err != nil {
+		if errors.Is(err, services.ErrReportScheduleNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ReportScheduleNotFound, "Report schedule not found", nil)
+			return
+		}
+		respondError(c, http.StatusBadGateway, errcodes.ReportDeliveryFailed, "Failed to send report: "+err.Error(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report sent successfully"})
+}
+
+// findReportSchedule loads the report schedule with id, writing an error
+// response when it cannot
+func (h *ReportScheduleHandler) findReportSchedule(c *gin.Context, id int) (*models.ReportSchedule, bool) {
+	schedule, err := h.scheduler.Get(id)
+	if err != nil {
+		if errors.Is(err, services.ErrReportScheduleNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.ReportScheduleNotFound, "Report schedule not found", nil)
+			return nil, false
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch report schedule", err)
+		return nil, false
+	}
+	return schedule, true
+}
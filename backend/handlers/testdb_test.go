@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// testTables lists the tables cleaned between tests, children first
+var testTables = []string{
+	"attendance_history", "attendance", "working_time_contract", "department_assignment", "employee_custom_value",
+	"custom_field", "employee", "departement", "export_job", "report_schedule", "webhook_delivery", "webhook",
+	"event_outbox", "audit_log",
+}
+
+// eventRecorder is an outbox sink collecting relayed events in order
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func (r *eventRecorder) Name() string { return "recorder" }
+
+func (r *eventRecorder) Send(ctx context.Context, event models.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *eventRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// memoryBlobStore is a blob store keeping blobs in memory
+type memoryBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memoryBlobStore) Put(key string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = b
+	return nil
+}
+
+func (s *memoryBlobStore) Open(key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, services.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memoryBlobStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+// newTestOutbox returns an outbox relaying to a recorder. The relay is not
+// started; tests call Relay to send what has been recorded.
+func newTestOutbox(db *sql.DB) (*services.Outbox, *eventRecorder) {
+	recorder := &eventRecorder{}
+	return services.NewOutbox(db, time.Second, time.Second, 10, recorder), recorder
+}
+
+// openTestDB returns a migrated, empty database for handler tests. SQLite in
+// memory is used by default; set TEST_DB_DRIVER to mysql or postgres and
+// TEST_DB_DSN to run the same tests against MySQL or PostgreSQL. CI runs them
+// against both with make test-postgres and make test-mysql.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		driver = string(database.DialectSQLite)
+	}
+	dialect, err := database.ParseDialect(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dialect == database.DialectSQLite && dsn == "" {
+		dsn = "file::memory:?_pragma=foreign_keys(1)"
+	}
+	if dsn == "" {
+		t.Skipf("TEST_DB_DSN is not set for %s", dialect)
+	}
+
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dialect == database.DialectSQLite {
+		// Every connection to :memory: is a separate database
+		db.SetMaxOpenConns(1)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range testTables {
+		if _, err := db.Exec("DELETE FROM " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The chain head row is seeded by its migration and only reset
+	if _, err := db.Exec("UPDATE audit_chain SET entries = 0, last_hash = ''"); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
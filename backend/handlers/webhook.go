@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/errcodes"
+	"attendance-system/logging"
+	"attendance-system/models"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook subscription HTTP requests
+type WebhookHandler struct {
+	db         *sql.DB
+	dispatcher *services.WebhookDispatcher
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(db *sql.DB, dispatcher *services.WebhookDispatcher) *WebhookHandler {
+	return &WebhookHandler{db: db, dispatcher: dispatcher}
+}
+
+// CreateWebhook subscribes a URL to events. The response is the only place
+// the signing secret is returned.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !validWebhookURL(c, req.URL) {
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = services.GenerateWebhookSecret(); err != nil {
+			respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create webhook", err)
+			return
+		}
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create webhook", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	id, err := database.TxInsertReturningID(h.db, tx, `
+		INSERT INTO webhook (url, secret, event_types, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, req.URL, secret, services.JoinEventTypes(req.EventTypes), enabled, now, now)
+
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create webhook", err)
+		return
+	}
+
+	// The secret is left out of the audit log
+	change := models.AuditChange{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityWebhook,
+		EntityID:   strconv.Itoa(int(id)),
+		After: models.Webhook{
+			ID:         int(id),
+			URL:        req.URL,
+			EventTypes: req.EventTypes,
+			Enabled:    enabled,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+	if !recordAudit(c, h.db, tx, "Failed to create webhook", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to create webhook", err)
+		return
+	}
+
+	webhook, err := h.dispatcher.Get(int(id))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch webhook", err)
+		return
+	}
+	webhook.Secret = secret
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+	})
+}
+
+// GetWebhooks retrieves all webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	rows, err := h.db.Query("SELECT id FROM webhook ORDER BY id")
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch webhooks", err)
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			logging.FromContext(c).Error("failed to read webhook row", "error", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var webhooks []models.Webhook
+	for _, id := range ids {
+		webhook, err := h.dispatcher.Get(id)
+		if err != nil {
+			logging.FromContext(c).Error("failed to load webhook", "webhook_id", id, "error", err)
+			continue
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+// GetWebhook retrieves a single webhook by ID
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// UpdateWebhook updates an existing webhook
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !validWebhookURL(c, req.URL) {
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+	now := time.Now()
+	query := "UPDATE webhook SET url = ?, event_types = ?, enabled = ?, updated_at = ?"
+	args := []interface{}{req.URL, services.JoinEventTypes(req.EventTypes), enabled, now}
+	if req.Secret != "" {
+		query += ", secret = ?"
+		args = append(args, req.Secret)
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update webhook", err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query+" WHERE id = ?", append(args, webhook.ID)...)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update webhook", err)
+		return
+	}
+
+	after := *webhook
+	after.URL = req.URL
+	after.EventTypes = req.EventTypes
+	after.Enabled = enabled
+	after.UpdatedAt = now
+	change := models.AuditChange{
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityWebhook,
+		EntityID:   strconv.Itoa(webhook.ID),
+		Before:     webhook,
+		After:      after,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to update webhook", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to update webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook updated successfully"})
+}
+
+// DeleteWebhook deletes a webhook and its delivery history
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete webhook", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Deliveries are removed first for databases without foreign keys enabled
+	if _, err := tx.Exec("DELETE FROM webhook_delivery WHERE webhook_id = ?", webhook.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete webhook", err)
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM webhook WHERE id = ?", webhook.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete webhook", err)
+		return
+	}
+
+	change := models.AuditChange{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityWebhook,
+		EntityID:   strconv.Itoa(webhook.ID),
+		Before:     webhook,
+	}
+	if !recordAudit(c, h.db, tx, "Failed to delete webhook", change) {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to delete webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook, newest first.
+// Filter on status=dead to see deliveries that ran out of retries.
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var filter models.WebhookDeliveryFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	deliveries, err := h.dispatcher.ListDeliveries(webhook.ID, filter.Status)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch webhook deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// RedeliverWebhookDelivery sends a delivery again, typically a dead one
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "delivery_id", "Invalid webhook delivery ID")
+		return
+	}
+
+	delivery, err := h.dispatcher.Redeliver(webhook.ID, deliveryID)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookDeliveryNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.WebhookDeliveryNotFound, "Webhook delivery not found", nil)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to redeliver webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Webhook delivery scheduled",
+		"delivery": delivery,
+	})
+}
+
+// findWebhook loads the webhook named by the id parameter, writing an error
+// response when it cannot
+func (h *WebhookHandler) findWebhook(c *gin.Context) (*models.Webhook, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondInvalidField(c, errcodes.InvalidRequest, "id", "Invalid webhook ID")
+		return nil, false
+	}
+
+	webhook, err := h.dispatcher.Get(id)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookNotFound) {
+			respondError(c, http.StatusNotFound, errcodes.WebhookNotFound, "Webhook not found", nil)
+			return nil, false
+		}
+		respondError(c, http.StatusInternalServerError, errcodes.Internal, "Failed to fetch webhook", err)
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// validWebhookURL rejects URLs that are not http or https
+func validWebhookURL(c *gin.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondInvalidField(c, errcodes.ValidationFailed, "url", "url must be an http or https URL")
+		return false
+	}
+	return true
+}
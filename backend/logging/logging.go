@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "request_id"
+
+// validRequestID limits incoming request IDs to a safe, loggable form
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ParseLevel converts a level name (debug, info, warn, error) to a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q: expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// Setup installs a JSON logger writing to w as the default slog logger. The
+// standard library log package is routed through it as well.
+func Setup(w io.Writer, level slog.Level) *slog.Logger {
+	logger := slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger
+}
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID
+// sent by the client or a proxy, and echoes it in the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by RequestID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// FromContext returns the default logger annotated with the request's ID,
+// method and route
+func FromContext(c *gin.Context) *slog.Logger {
+	return slog.Default().With(
+		"request_id", GetRequestID(c),
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+	)
+}
+
+// AccessLog logs one JSON line per request
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("request_id", GetRequestID(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypeAny); len(errs) > 0 {
+			attrs = append(attrs, slog.String("errors", strings.Join(errs.Errors(), "; ")))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery logs panics with the request ID and responds with a 500 that
+// carries the same ID
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		FromContext(c).Error("panic recovered", "panic", fmt.Sprint(recovered))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: models.APIError{
+			Code:      errcodes.Internal,
+			Message:   "Internal server error",
+			RequestID: GetRequestID(c),
+		}})
+	})
+}
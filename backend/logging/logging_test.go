@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), AccessLog(), Recovery())
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, GetRequestID(c)) })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	return r
+}
+
+func TestRequestID(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", w.Body.String())
+
+	req = httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	id := w.Header().Get(RequestIDHeader)
+	assert.NotEqual(t, "bad id\nwith newline", id)
+	assert.Len(t, id, 36)
+	assert.Equal(t, id, w.Body.String())
+}
+
+func TestAccessLogAndRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	Setup(&buf, slog.LevelInfo)
+	defer slog.SetDefault(previous)
+
+	r := setupRouter()
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var body models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errcodes.Internal, body.Error.Code)
+	assert.Equal(t, "req-1", body.Error.RequestID)
+
+	var lines []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line map[string]interface{}
+		assert.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "panic recovered", lines[0]["msg"])
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+		assert.Equal(t, "request", lines[1]["msg"])
+		assert.Equal(t, "ERROR", lines[1]["level"])
+		assert.Equal(t, "req-1", lines[1]["request_id"])
+		assert.Equal(t, "/panic", lines[1]["route"])
+		assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"attendance-system/config"
+	"attendance-system/database"
+	"attendance-system/logging"
+	"attendance-system/metrics"
 	"attendance-system/routes"
+	"attendance-system/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -15,37 +24,201 @@ import (
 var startTime = time.Now()
 
 func main() {
+	// Log JSON from the start; the configured level is applied once loaded
+	logging.Setup(os.Stdout, slog.LevelInfo)
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+		slog.Info("no .env file found, using system environment variables")
 	}
 
+	// Load and validate configuration
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logging.Setup(os.Stdout, level)
+
 	// Initialize database
-	db, err := config.InitDB()
+	db, err := config.InitDB(cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to connect to database", err)
 	}
 	defer db.Close()
 
+	dialect := cfg.Database.Dialect()
+
+	// Expose connection pool statistics
+	dbName := cfg.Database.Name
+	if dialect == database.DialectSQLite {
+		dbName = cfg.Database.Path
+	}
+	metrics.RegisterDBStats(db, dbName)
+
+	// Run database migrations from the command line instead of the server
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrateCommand(db, dialect, args[1:]); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+
+	// Re-evaluate stored attendance against the current schedules
+	if len(args) > 0 && args[0] == "recompute" {
+		if err := runRecomputeCommand(db, args[1:]); err != nil {
+			fatal("recompute failed", err)
+		}
+		return
+	}
+
+	// Apply pending migrations
+	if cfg.AutoMigrate {
+		if err := runMigrateCommand(db, dialect, []string{"up"}); err != nil {
+			fatal("migration failed", err)
+		}
+	}
+
 	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
+	gin.SetMode(cfg.Server.GinMode)
+
+	// Start export job workers
+	exportService := services.NewExportJobService(db, cfg.Export.Dir, cfg.Export.Workers, cfg.Export.Retention)
+	if err := exportService.Start(); err != nil {
+		fatal("failed to start export workers", err)
+	}
+
+	// Start scheduled report delivery
+	mailer := services.NewSMTPMailer(
+		cfg.SMTP.Host,
+		cfg.SMTP.Port,
+		cfg.SMTP.Username,
+		cfg.SMTP.Password,
+		cfg.SMTP.From,
+	)
+	reportScheduler := services.NewReportScheduler(db, mailer)
+	if err := reportScheduler.Start(); err != nil {
+		fatal("failed to start report scheduler", err)
+	}
+
+	// Start webhook delivery
+	webhooks := services.NewWebhookDispatcher(
+		db,
+		&http.Client{Timeout: cfg.Webhook.Timeout},
+		cfg.Webhook.Workers,
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.RetryBackoff,
+	)
+	webhooks.Start()
+
+	// Start relaying recorded events, including any a previous process
+	// committed but did not get to send
+	outbox := services.NewOutbox(db, cfg.Outbox.PollInterval, cfg.Outbox.RetryBackoff, cfg.Outbox.MaxAttempts, outboxSinks(cfg.Outbox, webhooks)...)
+	outbox.Start()
+
+	migrator, err := database.NewMigrator(db, dialect)
+	if err != nil {
+		fatal("failed to load migrations", err)
 	}
+	healthChecker := services.NewHealthChecker(db, migrator, exportService, reportScheduler, webhooks, outbox, cfg.Server.HealthCheckTimeout)
+
+	// Clock-ins and clock-outs are streamed live to clients of this process
+	feed := services.NewAttendanceFeed()
 
-	// Initialize router
-	r := gin.Default()
+	// Initialize router; logging and recovery middleware are added by SetupRoutes
+	r := gin.New()
 
 	// Setup routes
-	routes.SetupRoutes(r, db)
+	routes.SetupRoutes(r, db, cfg.CORS, exportService, reportScheduler, webhooks, outbox, feed, healthChecker, blobStore(cfg.Storage))
+
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	// Attendance streams stay open until the client leaves; end them so
+	// Shutdown does not wait for every dashboard to disconnect
+	srv.RegisterOnShutdown(feed.Close)
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Stop on SIGINT/SIGTERM so deploys drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down server")
+	case err := <-serverErr:
+		slog.Error("server failed", "error", err)
 	}
+	stop()
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	shutdown(srv, exportService, reportScheduler, webhooks, outbox, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown stops accepting requests and drains in-flight ones, then stops the
+// background workers. Each step gets its own timeout, so a slow one does not
+// leave the next without time to drain. The database is closed by main
+// afterwards so nothing still running loses its connection.
+func shutdown(srv *http.Server, exportService *services.ExportJobService, reportScheduler *services.ReportScheduler, webhooks *services.WebhookDispatcher, outbox *services.Outbox, timeout time.Duration) {
+	steps := []struct {
+		name string
+		stop func(ctx context.Context) error
+	}{
+		{"HTTP server", srv.Shutdown},
+		{"report scheduler", reportScheduler.Stop},
+		{"export workers", exportService.Stop},
+		// The relay stops before the webhook workers it queues deliveries for
+		{"outbox relay", outbox.Stop},
+		{"webhook workers", webhooks.Stop},
+	}
+	for _, step := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if err := step.stop(ctx); err != nil {
+			slog.Error(step.name+" did not stop cleanly", "error", err)
+		}
+		cancel()
 	}
+
+	slog.Info("server stopped")
+}
+
+// outboxSinks returns the configured sinks for recorded events
+func outboxSinks(cfg config.OutboxConfig, webhooks *services.WebhookDispatcher) []services.EventSink {
+	var sinks []services.EventSink
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, webhooks)
+		case "log":
+			sinks = append(sinks, services.LogSink{})
+		case "file":
+			sinks = append(sinks, services.NewFileSink(cfg.File))
+		}
+	}
+	return sinks
+}
+
+// blobStore returns the blob store selected by cfg
+func blobStore(cfg config.StorageConfig) services.BlobStore {
+	switch cfg.Driver {
+	case "local":
+		return services.NewLocalBlobStore(cfg.Dir)
+	}
+	return nil
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
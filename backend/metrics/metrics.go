@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "attendance"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	clockIns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clock_ins_total",
+		Help:      "Clock-ins recorded, by department.",
+	}, []string{"department"})
+
+	clockOuts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clock_outs_total",
+		Help:      "Clock-outs recorded, by department.",
+	}, []string{"department"})
+
+	lateArrivals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "late_arrivals_total",
+		Help:      "Clock-ins after the department's max clock-in time, by department.",
+	}, []string{"department"})
+
+	earlyLeaves = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "early_leaves_total",
+		Help:      "Clock-outs before the department's max clock-out time, by department.",
+	}, []string{"department"})
+
+	exportJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "export_job_duration_seconds",
+		Help:      "Time taken to run export jobs, by entity and final status.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"entity", "status"})
+
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts, by event type and resulting delivery status.",
+	}, []string{"event_type", "status"})
+
+	outboxRelays = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_relays_total",
+		Help:      "Outbox events handed to a sink, by sink and result.",
+	}, []string{"sink", "result"})
+)
+
+// RegisterDBStats exposes the database/sql connection pool statistics
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware records the duration of every request. Requests are labelled
+// with the route pattern (e.g. /api/v1/employees/:id) rather than the raw
+// path so that IDs do not create a series each.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// RecordClockIn counts a clock-in and, when late, a late arrival
+func RecordClockIn(department string, isOnTime bool) {
+	clockIns.WithLabelValues(department).Inc()
+	if !isOnTime {
+		lateArrivals.WithLabelValues(department).Inc()
+	}
+}
+
+// RecordClockOut counts a clock-out and, when early, an early leave
+func RecordClockOut(department string, isOnTime bool) {
+	clockOuts.WithLabelValues(department).Inc()
+	if !isOnTime {
+		earlyLeaves.WithLabelValues(department).Inc()
+	}
+}
+
+// ObserveExportJob records how long an export job ran
+func ObserveExportJob(entity, status string, duration time.Duration) {
+	exportJobDuration.WithLabelValues(entity, status).Observe(duration.Seconds())
+}
+
+// RecordWebhookDelivery counts a webhook delivery attempt. status is the
+// delivery's status afterwards: succeeded, pending (to be retried) or dead.
+func RecordWebhookDelivery(eventType, status string) {
+	webhookDeliveries.WithLabelValues(eventType, status).Inc()
+}
+
+// RecordOutboxRelay counts an outbox event handed to a sink. result is sent
+// or failed; failed events are retried.
+func RecordOutboxRelay(sink, result string) {
+	outboxRelays.WithLabelValues(sink, result).Inc()
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordClockInAndOut(t *testing.T) {
+	RecordClockIn("Test Department", true)
+	RecordClockIn("Test Department", false)
+	RecordClockOut("Test Department", false)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(clockIns.WithLabelValues("Test Department")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(lateArrivals.WithLabelValues("Test Department")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(clockOuts.WithLabelValues("Test Department")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(earlyLeaves.WithLabelValues("Test Department")))
+}
+
+func TestMiddlewareAndHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/employees/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	r.GET("/metrics", Handler())
+
+	ObserveExportJob("attendance", "completed", 2*time.Second)
+
+	for _, path := range []string{"/employees/1", "/employees/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body,
+		`attendance_http_request_duration_seconds_count{method="GET",route="/employees/:id",status="404"} 2`)
+	assert.Contains(t, body, `attendance_export_job_duration_seconds_count{entity="attendance",status="completed"} 1`)
+	assert.False(t, strings.Contains(body, `route="/employees/1"`))
+}
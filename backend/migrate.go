@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"attendance-system/database"
+)
+
+// runMigrateCommand handles "attendance-system migrate <up|down [n]|status|resolve <version>|seed>"
+func runMigrateCommand(db *sql.DB, dialect database.Dialect, args []string) error {
+	ctx := context.Background()
+
+	migrator, err := database.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			slog.Info("database schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			slog.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Error != "" {
+				applied = "failed: " + status.Error
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	case "resolve":
+		if len(args) < 2 {
+			return fmt.Errorf("migrate resolve needs the version of the failed migration")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		if err := migrator.Resolve(ctx, version); err != nil {
+			return err
+		}
+		slog.Info("cleared migration failure", "version", version)
+		return nil
+	case "seed":
+		if err := database.Seed(ctx, db, dialect); err != nil {
+			return err
+		}
+		slog.Info("sample data loaded")
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q: expected up, down, status, resolve or seed", command)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+// Absence is a working day on which an employee expected at work did not
+// clock in
+type Absence struct {
+	Date           string `json:"date"`
+	EmployeeID     string `json:"employee_id"`
+	EmployeeName   string `json:"employee_name"`
+	DepartmentID   int    `json:"department_id"`
+	DepartmentName string `json:"department_name"`
+}
+
+// AbsenceFilter represents the query parameters of the absence report.
+// Both dates are inclusive YYYY-MM-DD. ManagerID limits the report to the
+// manager's team, as in EmployeeFilter.
+type AbsenceFilter struct {
+	StartDate    string `form:"start_date" json:"start_date" binding:"required"`
+	EndDate      string `form:"end_date" json:"end_date" binding:"required"`
+	DepartmentID int    `form:"department_id" json:"department_id,omitempty"`
+	ManagerID    string `form:"manager_id" json:"manager_id,omitempty"`
+	DirectOnly   bool   `form:"direct_only" json:"direct_only,omitempty"`
+}
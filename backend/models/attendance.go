@@ -23,6 +23,13 @@ type AttendanceHistory struct {
 	DateAttendance  time.Time `json:"date_attendance" db:"date_attendance"`
 	AttendanceType  int       `json:"attendance_type" db:"attendance_type"` // 1 = In, 2 = Out
 	Description     string    `json:"description" db:"description"`
+	DepartementID   int       `json:"departement_id" db:"departement_id"`
+	MaxClockInTime  string    `json:"max_clock_in_time" db:"max_clock_in_time"`
+	MaxClockOutTime string    `json:"max_clock_out_time" db:"max_clock_out_time"`
+	ContractID      *int      `json:"contract_id" db:"contract_id"`
+	IsOnTime        bool      `json:"is_on_time" db:"is_on_time"`
+	LateMinutes     int       `json:"late_minutes" db:"late_minutes"`
+	EarlyMinutes    int       `json:"early_minutes" db:"early_minutes"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -50,12 +57,29 @@ type AttendanceLog struct {
 	Description     string    `json:"description" db:"description"`
 	MaxClockInTime  string    `json:"max_clock_in_time" db:"max_clock_in_time"`
 	MaxClockOutTime string    `json:"max_clock_out_time" db:"max_clock_out_time"`
+	ContractID      *int      `json:"contract_id" db:"contract_id"`
 	IsOnTime        bool      `json:"is_on_time" db:"is_on_time"`
+	LateMinutes     int       `json:"late_minutes" db:"late_minutes"`
+	EarlyMinutes    int       `json:"early_minutes" db:"early_minutes"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
 
-// AttendanceFilter represents filter parameters for attendance logs
+// AttendanceFilter represents filter parameters for attendance logs.
+// ManagerID limits the logs to the manager's team, as in EmployeeFilter.
 type AttendanceFilter struct {
 	Date         string `form:"date"`
 	DepartmentID int    `form:"department_id"`
+	ManagerID    string `form:"manager_id"`
+	DirectOnly   bool   `form:"direct_only"`
+}
+
+// AttendanceStreamFilter represents the query parameters of the attendance
+// stream. LastEventID resumes after a history ID, for clients that cannot
+// send the Last-Event-ID header. ManagerID limits the stream to the
+// manager's team, as in AttendanceFilter.
+type AttendanceStreamFilter struct {
+	DepartmentID int    `form:"department_id"`
+	LastEventID  int    `form:"last_event_id" binding:"omitempty,min=0"`
+	ManagerID    string `form:"manager_id"`
+	DirectOnly   bool   `form:"direct_only"`
 }
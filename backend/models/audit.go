@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Audited entity types
+const (
+	AuditEntityEmployee       = "employee"
+	AuditEntityDepartment     = "department"
+	AuditEntityAttendance     = "attendance"
+	AuditEntityWebhook        = "webhook"
+	AuditEntityReportSchedule = "report_schedule"
+	AuditEntityAssignment     = "department_assignment"
+	AuditEntityCustomField    = "custom_field"
+	AuditEntityContract       = "working_time_contract"
+)
+
+// AuditActor identifies who made a change and where the request came from
+type AuditActor struct {
+	Actor     string
+	IP        string
+	RequestID string
+}
+
+// AuditChange describes a change to one entity. Before is nil for creations
+// and After is nil for deletions.
+type AuditChange struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditEntry represents the audit_log table. Each entry's hash covers its
+// content and the previous entry's hash, so editing or removing an entry
+// breaks the chain from that point on.
+type AuditEntry struct {
+	ID         int             `json:"id" db:"id"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+	Actor      string          `json:"actor" db:"actor"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityID   string          `json:"entity_id" db:"entity_id"`
+	Before     json.RawMessage `json:"before" db:"before_data"`
+	After      json.RawMessage `json:"after" db:"after_data"`
+	IP         string          `json:"ip" db:"ip_address"`
+	RequestID  string          `json:"request_id" db:"request_id"`
+	PrevHash   string          `json:"prev_hash" db:"prev_hash"`
+	Hash       string          `json:"hash" db:"hash"`
+}
+
+// AuditFilter represents the query parameters for searching the audit log.
+// From and To are inclusive YYYY-MM-DD dates.
+type AuditFilter struct {
+	Actor      string `form:"actor" json:"actor,omitempty"`
+	Action     string `form:"action" json:"action,omitempty" binding:"omitempty,oneof=create update delete"`
+	EntityType string `form:"entity_type" json:"entity_type,omitempty" binding:"omitempty,oneof=employee department attendance webhook report_schedule department_assignment custom_field working_time_contract"`
+	EntityID   string `form:"entity_id" json:"entity_id,omitempty"`
+	From       string `form:"from" json:"from,omitempty"`
+	To         string `form:"to" json:"to,omitempty"`
+	Limit      int    `form:"limit" json:"limit,omitempty" binding:"omitempty,min=1,max=1000"`
+	Offset     int    `form:"offset" json:"offset,omitempty" binding:"omitempty,min=0"`
+}
+
+// AuditVerification reports whether the audit log hash chain is intact.
+// BrokenAt is the ID of the first entry that does not match.
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Entries  int    `json:"entries"`
+	LastHash string `json:"last_hash"`
+	BrokenAt int    `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
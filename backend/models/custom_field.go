@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Custom field types
+const (
+	CustomFieldText    = "text"
+	CustomFieldNumber  = "number"
+	CustomFieldDate    = "date"
+	CustomFieldBoolean = "boolean"
+	CustomFieldSelect  = "select"
+)
+
+// CustomField represents the custom_field table: an extra employee field
+// defined for this deployment. Name is its key in an employee's
+// custom_fields; Options lists the values a select field accepts.
+type CustomField struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Label     string    `json:"label" db:"label"`
+	FieldType string    `json:"field_type" db:"field_type"`
+	Options   []string  `json:"options" db:"options"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateCustomFieldRequest represents the request body for defining a custom
+// field. Name is lowercase letters, digits and underscores, starting with a
+// letter; Options is required for select fields and not allowed otherwise.
+type CreateCustomFieldRequest struct {
+	Name      string   `json:"name" binding:"required,max=50"`
+	Label     string   `json:"label" binding:"required,max=100"`
+	FieldType string   `json:"field_type" binding:"required,oneof=text number date boolean select"`
+	Options   []string `json:"options"`
+}
+
+// UpdateCustomFieldRequest represents the request body for updating a custom
+// field. The name and type cannot change; a select field cannot drop an
+// option that employees still have.
+type UpdateCustomFieldRequest struct {
+	Label   string   `json:"label" binding:"required,max=100"`
+	Options []string `json:"options"`
+}
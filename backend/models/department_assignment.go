@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// DepartmentAssignment represents the department_assignment table: the
+// department an employee belongs to from EffectiveFrom, a YYYY-MM-DD date,
+// until the day before their next assignment. EffectiveUntil is that last
+// day, nil for the current assignment.
+type DepartmentAssignment struct {
+	ID             int       `json:"id" db:"id"`
+	EmployeeID     string    `json:"employee_id" db:"employee_id"`
+	DepartementID  int       `json:"departement_id" db:"departement_id"`
+	DepartmentName string    `json:"departement_name" db:"departement_name"`
+	EffectiveFrom  string    `json:"effective_from" db:"effective_from"`
+	EffectiveUntil *string   `json:"effective_until" db:"-"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// TransferEmployeeRequest represents the request body for moving an employee
+// to another department from a given date, which may be in the past
+type TransferEmployeeRequest struct {
+	DepartementID int    `json:"departement_id" binding:"required"`
+	EffectiveFrom string `json:"effective_from" binding:"required,datetime=2006-01-02"`
+}
@@ -4,15 +4,45 @@ import (
 	"time"
 )
 
-// Employee represents the employee table
+// Employment statuses
+const (
+	EmploymentActive     = "active"
+	EmploymentSuspended  = "suspended"
+	EmploymentTerminated = "terminated"
+)
+
+// Employment types
+const (
+	EmploymentFullTime   = "full_time"
+	EmploymentPartTime   = "part_time"
+	EmploymentContractor = "contractor"
+)
+
+// Employee represents the employee table. HireDate and TerminationDate are
+// YYYY-MM-DD; DeletedAt is set once the employee is deleted, which keeps the
+// row for their attendance history. PhotoURL is set when they have a photo,
+// and CustomFields holds the values of the custom fields by name.
 type Employee struct {
-	ID            int       `json:"id" db:"id"`
-	EmployeeID    string    `json:"employee_id" db:"employee_id" binding:"required"`
-	DepartementID int       `json:"departement_id" db:"departement_id" binding:"required"`
-	Name          string    `json:"name" db:"name" binding:"required"`
-	Address       string    `json:"address" db:"address"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID               int        `json:"id" db:"id"`
+	EmployeeID       string     `json:"employee_id" db:"employee_id" binding:"required"`
+	DepartementID    int        `json:"departement_id" db:"departement_id" binding:"required"`
+	Name             string     `json:"name" db:"name" binding:"required"`
+	Address          string     `json:"address" db:"address"`
+	Email            string     `json:"email" db:"email"`
+	Phone            string     `json:"phone" db:"phone"`
+	JobTitle         string     `json:"job_title" db:"job_title"`
+	EmploymentType   string     `json:"employment_type" db:"employment_type"`
+	WorkLocation     string     `json:"work_location" db:"work_location"`
+	PhotoKey         *string    `json:"-" db:"photo_key"`
+	PhotoURL         *string    `json:"photo_url"`
+	CustomFields     map[string]interface{} `json:"custom_fields"`
+	EmploymentStatus string     `json:"employment_status" db:"employment_status"`
+	HireDate         *string    `json:"hire_date" db:"hire_date"`
+	TerminationDate  *string    `json:"termination_date" db:"termination_date"`
+	ManagerID        *string    `json:"manager_id" db:"manager_id"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // EmployeeWithDepartment represents employee with department information
@@ -22,22 +52,76 @@ type EmployeeWithDepartment struct {
 	DepartementID int       `json:"departement_id" db:"departement_id"`
 	Name          string    `json:"name" db:"name"`
 	Address       string    `json:"address" db:"address"`
+	Email         string    `json:"email" db:"email"`
+	Phone         string    `json:"phone" db:"phone"`
+	JobTitle      string    `json:"job_title" db:"job_title"`
+	EmploymentType string   `json:"employment_type" db:"employment_type"`
+	WorkLocation  string    `json:"work_location" db:"work_location"`
+	PhotoKey      *string   `json:"-" db:"photo_key"`
+	PhotoURL      *string   `json:"photo_url"`
+	CustomFields  map[string]interface{} `json:"custom_fields"`
+	EmploymentStatus string  `json:"employment_status" db:"employment_status"`
+	HireDate        *string  `json:"hire_date" db:"hire_date"`
+	TerminationDate *string  `json:"termination_date" db:"termination_date"`
+	ManagerID       *string  `json:"manager_id" db:"manager_id"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 	Department    Department `json:"department"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
-// CreateEmployeeRequest represents the request body for creating an employee
+// CreateEmployeeRequest represents the request body for creating an employee.
+// The status defaults to active and the employment type to full_time.
+// ManagerID is the employee_id of their manager.
 type CreateEmployeeRequest struct {
-	EmployeeID    string `json:"employee_id" binding:"required"`
-	DepartementID int    `json:"departement_id" binding:"required"`
-	Name          string `json:"name" binding:"required"`
-	Address       string `json:"address"`
+	EmployeeID       string  `json:"employee_id" binding:"required"`
+	DepartementID    int     `json:"departement_id" binding:"required"`
+	Name             string  `json:"name" binding:"required"`
+	Address          string  `json:"address"`
+	Email            string  `json:"email" binding:"omitempty,max=255"`
+	Phone            string  `json:"phone" binding:"omitempty,max=50"`
+	JobTitle         string  `json:"job_title" binding:"omitempty,max=100"`
+	EmploymentType   string  `json:"employment_type" binding:"omitempty,oneof=full_time part_time contractor"`
+	WorkLocation     string  `json:"work_location" binding:"omitempty,max=100"`
+	CustomFields     map[string]interface{} `json:"custom_fields"`
+	EmploymentStatus string  `json:"employment_status" binding:"omitempty,oneof=active suspended terminated"`
+	HireDate         *string `json:"hire_date" binding:"omitempty,datetime=2006-01-02"`
+	TerminationDate  *string `json:"termination_date" binding:"omitempty,datetime=2006-01-02"`
+	ManagerID        *string `json:"manager_id"`
 }
 
-// UpdateEmployeeRequest represents the request body for updating an employee
+// UpdateEmployeeRequest represents the request body for updating an employee.
+// Omitted employment, profile and manager fields keep their current values; a
+// status other than terminated clears the termination date and an empty
+// manager_id removes the manager. CustomFields only changes the fields it
+// names, removing those set to null.
 type UpdateEmployeeRequest struct {
-	DepartementID int    `json:"departement_id" binding:"required"`
-	Name          string `json:"name" binding:"required"`
-	Address       string `json:"address"`
+	DepartementID    int     `json:"departement_id" binding:"required"`
+	Name             string  `json:"name" binding:"required"`
+	Email            *string `json:"email" binding:"omitempty,max=255"`
+	Phone            *string `json:"phone" binding:"omitempty,max=50"`
+	JobTitle         *string `json:"job_title" binding:"omitempty,max=100"`
+	EmploymentType   *string `json:"employment_type" binding:"omitempty,oneof=full_time part_time contractor"`
+	WorkLocation     *string `json:"work_location" binding:"omitempty,max=100"`
+	CustomFields     map[string]interface{} `json:"custom_fields"`
+	Address          string  `json:"address"`
+	EmploymentStatus string  `json:"employment_status" binding:"omitempty,oneof=active suspended terminated"`
+	HireDate         *string `json:"hire_date" binding:"omitempty,datetime=2006-01-02"`
+	TerminationDate  *string `json:"termination_date" binding:"omitempty,datetime=2006-01-02"`
+	ManagerID        *string `json:"manager_id"`
+}
+
+// EmployeeFilter represents the query parameters for listing employees.
+// Deleted employees are left out unless IncludeDeleted is set. ManagerID
+// limits the list to the manager's team: everyone reporting to them directly
+// or indirectly, or only directly with DirectOnly. Search matches part of the
+// ID, name, contact and job details or a custom field value, ignoring case.
+type EmployeeFilter struct {
+	Search         string `form:"q"`
+	Status         string `form:"status" binding:"omitempty,oneof=active suspended terminated"`
+	EmploymentType string `form:"employment_type" binding:"omitempty,oneof=full_time part_time contractor"`
+	WorkLocation   string `form:"work_location"`
+	IncludeDeleted bool   `form:"include_deleted"`
+	ManagerID      string `form:"manager_id"`
+	DirectOnly     bool   `form:"direct_only"`
 }
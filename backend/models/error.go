@@ -0,0 +1,26 @@
+package models
+
+import (
+	"attendance-system/errcodes"
+)
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes a failed request. Code is stable and meant for
+// programs; Message is meant for people and may change.
+type APIError struct {
+	Code      errcodes.Code `json:"code"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id"`
+}
+
+// ErrorDetail describes a problem with a single request field
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// Event types
+const (
+	EventAttendanceClockIn   = "attendance.clock_in"
+	EventAttendanceClockOut  = "attendance.clock_out"
+	EventAttendanceLate      = "attendance.late"
+	EventEmployeeCreated     = "employee.created"
+	EventEmployeeUpdated     = "employee.updated"
+	EventEmployeeDeleted     = "employee.deleted"
+	EventEmployeeTransferred = "employee.transferred"
+	EventDepartmentCreated   = "department.created"
+	EventDepartmentUpdated   = "department.updated"
+	EventDepartmentDeleted   = "department.deleted"
+)
+
+// EventTypes lists every event type that can be published
+var EventTypes = []string{
+	EventAttendanceClockIn,
+	EventAttendanceClockOut,
+	EventAttendanceLate,
+	EventEmployeeCreated,
+	EventEmployeeUpdated,
+	EventEmployeeDeleted,
+	EventEmployeeTransferred,
+	EventDepartmentCreated,
+	EventDepartmentUpdated,
+	EventDepartmentDeleted,
+}
+
+// Event is a change to attendance or HR data delivered to subscribers
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// AttendanceEvent is the data of attendance.* events. HistoryID is the
+// attendance_history row the event recorded.
+type AttendanceEvent struct {
+	HistoryID      int       `json:"history_id"`
+	AttendanceID   string    `json:"attendance_id"`
+	AttendanceType int       `json:"attendance_type"`
+	EmployeeID     string    `json:"employee_id"`
+	EmployeeName   string    `json:"employee_name"`
+	DepartementID  int       `json:"departement_id"`
+	DepartmentName string    `json:"departement_name"`
+	Time           time.Time `json:"time"`
+	IsOnTime       bool      `json:"is_on_time"`
+	LateMinutes    int       `json:"late_minutes"`
+	EarlyMinutes   int       `json:"early_minutes"`
+	Description    string    `json:"description"`
+}
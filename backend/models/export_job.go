@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Export job statuses
+const (
+	ExportJobPending   = "pending"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+	ExportJobExpired   = "expired"
+)
+
+// ExportJob represents the export_job table
+type ExportJob struct {
+	ID         string        `json:"id" db:"id"`
+	Entity     string        `json:"entity" db:"entity"`
+	Format     string        `json:"format" db:"format"`
+	Filters    ExportFilters `json:"filters" db:"filters"`
+	Status     string        `json:"status" db:"status"`
+	Progress   int           `json:"progress" db:"progress"`
+	RowCount   int           `json:"row_count" db:"row_count"`
+	FileName   string        `json:"file_name,omitempty" db:"file_name"`
+	Error      string        `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	StartedAt  *time.Time    `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at" db:"finished_at"`
+	ExpiresAt  *time.Time    `json:"expires_at" db:"expires_at"`
+}
+
+// ExportFilters represents the filters applied to an export job. Search
+// applies to employee exports, as in EmployeeFilter.
+type ExportFilters struct {
+	Date         string `json:"date,omitempty"`
+	StartDate    string `json:"start_date,omitempty"`
+	EndDate      string `json:"end_date,omitempty"`
+	DepartmentID int    `json:"department_id,omitempty"`
+	ManagerID    string `json:"manager_id,omitempty"`
+	DirectOnly   bool   `json:"direct_only,omitempty"`
+	Search       string `json:"search,omitempty"`
+}
+
+// CreateExportJobRequest represents the request body for creating an export job
+type CreateExportJobRequest struct {
+	Entity  string        `json:"entity" binding:"required,oneof=attendance employees departments"`
+	Format  string        `json:"format" binding:"omitempty,oneof=csv"`
+	Filters ExportFilters `json:"filters"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Health check statuses
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck is the result of checking a single dependency
+type HealthCheck struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// ReadinessReport is the readiness status of the service with a breakdown
+// per dependency. Status is fail when any check failed.
+type ReadinessReport struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]HealthCheck `json:"checks"`
+	Timestamp time.Time              `json:"timestamp"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// Import modes
+const (
+	ImportModeDryRun = "dry-run"
+	ImportModeCommit = "commit"
+)
+
+// ImportRowError describes a validation problem on a single import row
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// Import change actions
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+)
+
+// ImportChange previews what an import will do to a single record
+type ImportChange struct {
+	Row    int         `json:"row"`
+	Action string      `json:"action"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after"`
+}
+
+// ImportReport summarises the result of validating or applying an import file
+type ImportReport struct {
+	Mode      string           `json:"mode"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	ErrorRows int              `json:"error_rows"`
+	Imported  int              `json:"imported"`
+	Errors    []ImportRowError `json:"errors"`
+	Changes   []ImportChange   `json:"changes,omitempty"`
+}
+
+// Punch import issue kinds
+const (
+	PunchIssueUnpairedIn  = "unpaired_in"
+	PunchIssueUnpairedOut = "unpaired_out"
+	PunchIssueDuplicate   = "duplicate"
+)
+
+// PunchIssue describes a punch that could not be paired into a clean session
+type PunchIssue struct {
+	Row        int       `json:"row"`
+	EmployeeID string    `json:"employee_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Direction  string    `json:"direction"`
+	Issue      string    `json:"issue"`
+}
+
+// PunchImportReport summarises the result of importing legacy punch logs
+type PunchImportReport struct {
+	Mode            string           `json:"mode"`
+	TotalRows       int              `json:"total_rows"`
+	ErrorRows       int              `json:"error_rows"`
+	Sessions        int              `json:"sessions"`
+	Imported        int              `json:"imported"`
+	Updated         int              `json:"updated"`
+	AlreadyImported int              `json:"already_imported"`
+	Errors          []ImportRowError `json:"errors"`
+	Issues          []PunchIssue     `json:"issues"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// OrgChartNode is an employee with the people reporting to them, each with
+// their own reports in turn
+type OrgChartNode struct {
+	Employee EmployeeWithDepartment `json:"employee"`
+	Reports  []OrgChartNode         `json:"reports"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// Report types
+const (
+	ReportLateArrivals      = "late_arrivals"
+	ReportAttendanceSummary = "attendance_summary"
+	ReportAbsences          = "absences"
+)
+
+// ReportFormatCSV is the only report format; deliveries attach a CSV file
+const ReportFormatCSV = "csv"
+
+// ReportSchedule represents the report_schedule table
+type ReportSchedule struct {
+	ID           int           `json:"id" db:"id"`
+	Name         string        `json:"name" db:"name"`
+	CronExpr     string        `json:"cron_expr" db:"cron_expr"`
+	ReportType   string        `json:"report_type" db:"report_type"`
+	Filters      ReportFilters `json:"filters" db:"filters"`
+	Recipients   []string      `json:"recipients" db:"recipients"`
+	Format       string        `json:"format" db:"format"`
+	Enabled      bool          `json:"enabled" db:"enabled"`
+	LastRunAt    *time.Time    `json:"last_run_at" db:"last_run_at"`
+	LastRunError string        `json:"last_run_error,omitempty" db:"last_run_error"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// ReportFilters represents the filters applied when a report runs
+type ReportFilters struct {
+	DepartmentID int `json:"department_id,omitempty"`
+	// ManagerID limits the report to the manager's team, everyone below them
+	// or only their direct reports with DirectOnly
+	ManagerID  string `json:"manager_id,omitempty"`
+	DirectOnly bool   `json:"direct_only,omitempty"`
+	// LookbackDays is how many days up to and including the run date the
+	// report covers. Defaults to 1 for late arrivals and 7 for summaries.
+	LookbackDays int `json:"lookback_days,omitempty"`
+}
+
+// CreateReportScheduleRequest represents the request body for creating a report schedule
+type CreateReportScheduleRequest struct {
+	Name       string        `json:"name" binding:"required"`
+	CronExpr   string        `json:"cron_expr" binding:"required"`
+	ReportType string        `json:"report_type" binding:"required,oneof=late_arrivals attendance_summary absences"`
+	Filters    ReportFilters `json:"filters"`
+	Recipients []string      `json:"recipients" binding:"required,min=1,dive,email"`
+	Format     string        `json:"format" binding:"omitempty,oneof=csv"`
+	Enabled    *bool         `json:"enabled"`
+}
+
+// UpdateReportScheduleRequest represents the request body for updating a report schedule
+type UpdateReportScheduleRequest struct {
+	Name       string        `json:"name" binding:"required"`
+	CronExpr   string        `json:"cron_expr" binding:"required"`
+	ReportType string        `json:"report_type" binding:"required,oneof=late_arrivals attendance_summary absences"`
+	Filters    ReportFilters `json:"filters"`
+	Recipients []string      `json:"recipients" binding:"required,min=1,dive,email"`
+	Format     string        `json:"format" binding:"omitempty,oneof=csv"`
+	Enabled    *bool         `json:"enabled"`
+}
+
+// AttendanceSummary is one employee's attendance over the period of an
+// attendance summary report
+type AttendanceSummary struct {
+	EmployeeID     string `json:"employee_id"`
+	EmployeeName   string `json:"employee_name"`
+	DepartmentName string `json:"department_name"`
+	DaysPresent    int    `json:"days_present"`
+	LateArrivals   int    `json:"late_arrivals"`
+	EarlyLeaves    int    `json:"early_leaves"`
+	LateMinutes    int    `json:"late_minutes"`
+	EarlyMinutes   int    `json:"early_minutes"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// Webhook delivery statuses. Deliveries that exhaust their retries are dead
+// and stay in the table until redelivered.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Webhook represents the webhook table. The secret is only returned when the
+// webhook is created.
+type Webhook struct {
+	ID         int       `json:"id" db:"id"`
+	URL        string    `json:"url" db:"url"`
+	Secret     string    `json:"secret,omitempty" db:"secret"`
+	EventTypes []string  `json:"event_types" db:"event_types"`
+	Enabled    bool      `json:"enabled" db:"enabled"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery represents the webhook_delivery table
+type WebhookDelivery struct {
+	ID             int        `json:"id" db:"id"`
+	WebhookID      int        `json:"webhook_id" db:"webhook_id"`
+	EventID        string     `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Payload        Event      `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at" db:"delivered_at"`
+}
+
+// CreateWebhookRequest represents the request body for creating a webhook.
+// A secret is generated when none is given. Event types may end in ".*" to
+// match a whole group, or be "*" to match everything.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=* attendance.* employee.* department.* attendance.clock_in attendance.clock_out attendance.late employee.created employee.updated employee.deleted employee.transferred department.created department.updated department.deleted"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook.
+// The secret is kept when omitted.
+type UpdateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=* attendance.* employee.* department.* attendance.clock_in attendance.clock_out attendance.late employee.created employee.updated employee.deleted employee.transferred department.created department.updated department.deleted"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// WebhookDeliveryFilter represents the query parameters for listing deliveries
+type WebhookDeliveryFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// WorkingTimeContract represents the working_time_contract table: the hours
+// an employee owes from EffectiveFrom, a YYYY-MM-DD date, until the day
+// before their next contract. While it applies they are evaluated against it
+// instead of their department's schedule. WeeklyHours are spread evenly over
+// WorkingDays (mon to sun). Clocking in after StartWindowEnd is late;
+// clocking out before CoreEnd, when core hours are set, is early.
+// EffectiveUntil is the last day, nil for the current contract.
+type WorkingTimeContract struct {
+	ID               int       `json:"id" db:"id"`
+	EmployeeID       string    `json:"employee_id" db:"employee_id"`
+	EffectiveFrom    string    `json:"effective_from" db:"effective_from"`
+	EffectiveUntil   *string   `json:"effective_until" db:"-"`
+	WeeklyHours      float64   `json:"weekly_hours" db:"weekly_hours"`
+	WorkingDays      []string  `json:"working_days" db:"working_days"`
+	StartWindowStart string    `json:"start_window_start" db:"start_window_start"`
+	StartWindowEnd   string    `json:"start_window_end" db:"start_window_end"`
+	CoreStart        *string   `json:"core_start" db:"core_start"`
+	CoreEnd          *string   `json:"core_end" db:"core_end"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SetContractRequest represents the request body for giving an employee a
+// working-time contract from a date, replacing any contract starting that
+// same day. WorkingDays defaults to mon to fri; core hours are optional but
+// must be given together.
+type SetContractRequest struct {
+	EffectiveFrom    string   `json:"effective_from" binding:"required,datetime=2006-01-02"`
+	WeeklyHours      float64  `json:"weekly_hours" binding:"required,gt=0,lte=100"`
+	WorkingDays      []string `json:"working_days" binding:"omitempty,unique,dive,oneof=mon tue wed thu fri sat sun"`
+	StartWindowStart string   `json:"start_window_start" binding:"required,datetime=15:04:05"`
+	StartWindowEnd   string   `json:"start_window_end" binding:"required,datetime=15:04:05"`
+	CoreStart        *string  `json:"core_start" binding:"omitempty,datetime=15:04:05"`
+	CoreEnd          *string  `json:"core_end" binding:"omitempty,datetime=15:04:05"`
+}
+
+// FlexTimeDay is one day of an employee's flex-time account: the minutes
+// their contract asked for and the minutes they worked
+type FlexTimeDay struct {
+	Date           string `json:"date"`
+	ContractID     int    `json:"contract_id"`
+	TargetMinutes  int    `json:"target_minutes"`
+	WorkedMinutes  int    `json:"worked_minutes"`
+	BalanceMinutes int    `json:"balance_minutes"`
+}
+
+// FlexTimeAccount is the balance of worked against contracted minutes over
+// the days of a date range covered by a contract. A positive balance is time
+// worked beyond the contract.
+type FlexTimeAccount struct {
+	EmployeeID     string        `json:"employee_id"`
+	StartDate      string        `json:"start_date"`
+	EndDate        string        `json:"end_date"`
+	TargetMinutes  int           `json:"target_minutes"`
+	WorkedMinutes  int           `json:"worked_minutes"`
+	BalanceMinutes int           `json:"balance_minutes"`
+	Days           []FlexTimeDay `json:"days"`
+}
+
+// FlexTimeFilter represents the query parameters of the flex-time account.
+// Both dates are inclusive YYYY-MM-DD.
+type FlexTimeFilter struct {
+	StartDate string `form:"start_date" json:"start_date" binding:"required"`
+	EndDate   string `form:"end_date" json:"end_date" binding:"required"`
+}
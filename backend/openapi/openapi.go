@@ -0,0 +1,446 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path keyed by lower case HTTP method
+type PathItem map[string]*OperationObject
+
+// Components holds the schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// OperationObject is a documented operation in the generated document
+type OperationObject struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []Parameter                `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body accepted by an operation
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// ResponseObject describes a single response of an operation
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a request or response body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Operation documents a route. Request and response bodies are given as
+// values whose types are reflected into schemas, so the document follows
+// the models package as it changes.
+type Operation struct {
+	Summary     string
+	Description string
+	Tag         string
+	// Query is a struct whose form tags are the query parameters
+	Query interface{}
+	// Params lists query parameters not described by Query
+	Params []Parameter
+	// Body is the JSON request body
+	Body interface{}
+	// Upload names the multipart form field carrying an uploaded file
+	Upload    string
+	Responses map[int]Response
+	// Errors lists the error statuses, each answered with the error body
+	// passed to Build
+	Errors []int
+	// Hidden routes are served but left out of the document
+	Hidden bool
+}
+
+// Response documents a response body. ContentType defaults to JSON.
+type Response struct {
+	Description string
+	Body        interface{}
+	ContentType string
+}
+
+// Object describes a JSON object by example: each value's type becomes the
+// schema of the property with the same key
+type Object map[string]interface{}
+
+// Key returns the key of a route in the operations passed to Build
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Build generates the document for routes. errorBody is the body of the
+// responses listed in Operation.Errors. Routes with no operation are left
+// out and returned as undocumented.
+func Build(info Info, routes gin.RoutesInfo, operations map[string]Operation, errorBody interface{}) (*Document, []string) {
+	g := &generator{schemas: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	var undocumented []string
+	for _, route := range routes {
+		op, ok := operations[Key(route.Method, route.Path)]
+		if !ok {
+			undocumented = append(undocumented, Key(route.Method, route.Path))
+			continue
+		}
+		if op.Hidden {
+			continue
+		}
+
+		path, params := convertPath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = g.operation(op, operationID(route.Handler), params, errorBody)
+	}
+
+	sort.Strings(undocumented)
+	doc.Components.Schemas = g.schemas
+	return doc, undocumented
+}
+
+// convertPath turns gin's :name and *name segments into OpenAPI {name}
+// templates and returns the matching path parameters
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives an ID from a handler method name such as
+// attendance-system/handlers.(*EmployeeHandler).GetEmployees-fm
+func operationID(handler string) string {
+	name := strings.TrimSuffix(handler, "-fm")
+	if !strings.Contains(name, ").") {
+		return ""
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func (g *generator) operation(op Operation, id string, params []Parameter, errorBody interface{}) *OperationObject {
+	result := &OperationObject{
+		OperationID: id,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Parameters:  params,
+		Responses:   make(map[string]*ResponseObject),
+	}
+	if op.Tag != "" {
+		result.Tags = []string{op.Tag}
+	}
+
+	if op.Query != nil {
+		result.Parameters = append(result.Parameters, g.queryParams(reflect.TypeOf(op.Query))...)
+	}
+	result.Parameters = append(result.Parameters, op.Params...)
+
+	switch {
+	case op.Body != nil:
+		result.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.valueSchema(op.Body)}},
+		}
+	case op.Upload != "":
+		result.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{op.Upload: {Type: "string", Format: "binary"}},
+				Required:   []string{op.Upload},
+			}}},
+		}
+	}
+
+	for status, response := range op.Responses {
+		description := response.Description
+		if description == "" {
+			description = http.StatusText(status)
+		}
+		object := &ResponseObject{Description: description}
+		if response.Body != nil {
+			contentType := response.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			object.Content = map[string]MediaType{contentType: {Schema: g.valueSchema(response.Body)}}
+		}
+		result.Responses[strconv.Itoa(status)] = object
+	}
+	for _, status := range op.Errors {
+		result.Responses[strconv.Itoa(status)] = &ResponseObject{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{"application/json": {Schema: g.valueSchema(errorBody)}},
+		}
+	}
+
+	return result
+}
+
+// queryParams describes the fields of a struct bound with ShouldBindQuery
+func (g *generator) queryParams(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := g.schema(field.Type)
+		rules := parseBinding(field.Tag.Get("binding"))
+		rules.apply(schema)
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: rules.required,
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+// valueSchema describes v, expanding Object values property by property
+func (g *generator) valueSchema(v interface{}) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return &Schema{}
+	case Object:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(v))}
+		for name, value := range v {
+			schema.Properties[name] = g.valueSchema(value)
+		}
+		return schema
+	default:
+		return g.schema(reflect.TypeOf(v))
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema describes a Go type. Named structs are added to the components and
+// referenced.
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		schema := g.schema(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// Embedded JSON of any shape
+		return &Schema{}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Register before describing the fields so recursive types terminate
+			g.schemas[t.Name()] = &Schema{}
+			*g.schemas[t.Name()] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema describes the JSON encoding of a struct
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schema(field.Type)
+		rules := parseBinding(field.Tag.Get("binding"))
+		if property.Ref == "" {
+			rules.apply(property)
+		}
+		if rules.required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// bindingRules holds the validator rules from a binding tag that the schema
+// can express. Rules after "dive" apply to the items of a slice.
+type bindingRules struct {
+	required bool
+	enum     []string
+	format   string
+	items    *bindingRules
+}
+
+func parseBinding(tag string) bindingRules {
+	var rules bindingRules
+	current := &rules
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			current.items = &bindingRules{}
+			current = current.items
+		case "required":
+			current.required = true
+		case "oneof":
+			current.enum = strings.Fields(value)
+		case "email":
+			current.format = "email"
+		}
+	}
+	return rules
+}
+
+func (r bindingRules) apply(schema *Schema) {
+	if len(r.enum) > 0 {
+		schema.Enum = r.enum
+	}
+	if r.format != "" {
+		schema.Format = r.format
+	}
+	if r.items != nil && schema.Items != nil && schema.Items.Ref == "" {
+		r.items.apply(schema.Items)
+	}
+}
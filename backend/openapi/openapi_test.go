@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBase struct {
+	ID int `json:"id"`
+}
+
+type testItem struct {
+	testBase
+	Name     string     `json:"name" binding:"required"`
+	Kind     string     `json:"kind,omitempty" binding:"omitempty,oneof=a b"`
+	Tags     []string   `json:"tags" binding:"dive,email"`
+	Deleted  *time.Time `json:"deleted_at"`
+	Parent   *testItem  `json:"parent"`
+	Internal string     `json:"-"`
+	secret   string
+}
+
+func TestSchema(t *testing.T) {
+	g := &generator{schemas: make(map[string]*Schema)}
+
+	ref := g.schema(reflect.TypeOf(testItem{}))
+	assert.Equal(t, "#/components/schemas/testItem", ref.Ref)
+
+	schema := g.schemas["testItem"]
+	if !assert.NotNil(t, schema) {
+		return
+	}
+	assert.ElementsMatch(t, []string{"id", "name", "kind", "tags", "deleted_at", "parent"}, keys(schema.Properties))
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, []string{"a", "b"}, schema.Properties["kind"].Enum)
+	assert.Equal(t, "email", schema.Properties["tags"].Items.Format)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time", Nullable: true}, schema.Properties["deleted_at"])
+	assert.Equal(t, "#/components/schemas/testItem", schema.Properties["parent"].Ref)
+}
+
+func TestConvertPath(t *testing.T) {
+	path, params := convertPath("/api/v1/exports/:id/download")
+	assert.Equal(t, "/api/v1/exports/{id}/download", path)
+	if assert.Len(t, params, 1) {
+		assert.Equal(t, "id", params[0].Name)
+		assert.True(t, params[0].Required)
+	}
+}
+
+func keys(m map[string]*Schema) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
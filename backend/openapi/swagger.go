@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// swaggerInitializer replaces the initializer bundled with Swagger UI, which
+// points at the petstore example
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: %q,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// SwaggerUI serves the embedded Swagger UI showing the document at specURL.
+// It must be registered on a route ending in /*filepath.
+func SwaggerUI(specURL string) gin.HandlerFunc {
+	initializer := fmt.Sprintf(swaggerInitializer, specURL)
+	files := http.FileServer(http.FS(swaggerFiles.FS))
+
+	return func(c *gin.Context) {
+		name := c.Param("filepath")
+		if name == "/swagger-initializer.js" {
+			c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(initializer))
+			return
+		}
+
+		// Serve the files relative to the route rather than the full URL path
+		req := c.Request.Clone(c.Request.Context())
+		req.URL.Path = "/" + strings.TrimPrefix(name, "/")
+		files.ServeHTTP(c.Writer, req)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"attendance-system/services"
+)
+
+// runRecomputeCommand handles
+// "attendance-system recompute [-employee ID] [-from YYYY-MM-DD] [-to YYYY-MM-DD]",
+// re-evaluating stored attendance against the current schedules. Both dates
+// are inclusive.
+func runRecomputeCommand(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("recompute", flag.ContinueOnError)
+	employeeID := fs.String("employee", "", "only recompute this employee's attendance")
+	fromDate := fs.String("from", "", "first day to recompute (YYYY-MM-DD)")
+	toDate := fs.String("to", "", "last day to recompute (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var from, to time.Time
+	if *fromDate != "" {
+		day, err := time.ParseInLocation("2006-01-02", *fromDate, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -from date %q", *fromDate)
+		}
+		from = day
+	}
+	if *toDate != "" {
+		day, err := time.ParseInLocation("2006-01-02", *toDate, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -to date %q", *toDate)
+		}
+		_, to = services.DayBounds(day)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changed, err := services.RecomputeAttendance(tx, *employeeID, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.Info("recomputed attendance", "changed", changed)
+	return nil
+}
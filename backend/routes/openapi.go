@@ -0,0 +1,582 @@
+package routes
+
+import (
+	"net/http"
+
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/openapi"
+)
+
+// apiInfo describes the API in the OpenAPI document
+var apiInfo = openapi.Info{
+	Title:       "Attendance System API",
+	Version:     "1.0.0",
+	Description: "Employee, department and attendance management with exports, scheduled reports and webhooks.",
+}
+
+// Shared response bodies
+var (
+	messageBody = openapi.Object{"message": ""}
+	healthBody  = openapi.Object{"status": "", "message": "", "uptime": "", "database": "", "timestamp": ""}
+	csvFile     = openapi.Response{Description: "CSV file download", Body: "", ContentType: "text/csv"}
+	imageFile   = openapi.Response{Description: "Image file", Body: "", ContentType: "image/*"}
+	importMode  = openapi.Parameter{
+		Name:        "mode",
+		In:          "query",
+		Description: "dry-run reports what would change; commit applies it",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{models.ImportModeDryRun, models.ImportModeCommit}},
+	}
+)
+
+// documentErrorCodes lists every error code in the schema of error bodies
+func documentErrorCodes(doc *openapi.Document) {
+	schema, ok := doc.Components.Schemas["APIError"]
+	if !ok {
+		return
+	}
+	for _, code := range errcodes.All() {
+		schema.Properties["code"].Enum = append(schema.Properties["code"].Enum, string(code))
+	}
+}
+
+// importResponses documents an import endpoint returning report. Files with
+// invalid rows are reported with an error; in commit mode the status is 422.
+func importResponses(report interface{}) map[int]openapi.Response {
+	return map[int]openapi.Response{
+		http.StatusOK: {
+			Description: "Import report; error is set when rows failed validation",
+			Body:        openapi.Object{"message": "", "error": models.APIError{}, "report": report},
+		},
+		http.StatusUnprocessableEntity: {
+			Description: "Rows failed validation and nothing was imported",
+			Body:        openapi.Object{"error": models.APIError{}, "report": report},
+		},
+	}
+}
+
+// apiOperations documents every route registered by SetupRoutes, keyed by
+// method and path. routes_test fails when a route is missing here.
+var apiOperations = map[string]openapi.Operation{
+	// Employees
+	openapi.Key("POST", "/api/v1/employees/"): {
+		Summary: "Create an employee", Tag: "Employees",
+		Body: models.CreateEmployeeRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {Body: openapi.Object{"message": "", "employee": models.Employee{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/"): {
+		Summary: "List employees with their department", Tag: "Employees",
+		Description: "Deleted employees are left out unless include_deleted is true. " +
+			"q matches part of the ID, name, email, phone, job title, work location or a custom field value. " +
+			"manager_id limits the list to that manager's team, or their direct reports with direct_only. " +
+			"An X-Actor naming an employee limits the list to their team.",
+		Query: models.EmployeeFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"employees": []models.EmployeeWithDepartment{}, "count": 0}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id"): {
+		Summary: "Get an employee", Tag: "Employees",
+		Description: "Deleted employees are still returned, with deleted_at set.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"employee": models.EmployeeWithDepartment{}}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/employees/:id"): {
+		Summary: "Update an employee", Tag: "Employees",
+		Description: "Omitted employment fields and manager_id keep their current values; an empty manager_id removes the manager. " +
+			"Deleted employees are not found.",
+		Body:      models.UpdateEmployeeRequest{},
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/employees/:id"): {
+		Summary: "Delete an employee", Tag: "Employees",
+		Description: "Soft-deletes the employee: their attendance history is kept and they no longer appear in lists. " +
+			"Employees with direct reports cannot be deleted.",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/reports"): {
+		Summary: "List an employee's direct reports", Tag: "Employees",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"reports": []models.EmployeeWithDepartment{}, "count": 0}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/org-chart"): {
+		Summary: "Get the reporting tree below an employee", Tag: "Employees",
+		Description: "Each level is sorted by name; count is the number of people below the employee.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"org_chart": models.OrgChartNode{}, "count": 0}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/assignments"): {
+		Summary: "List an employee's department history", Tag: "Employees",
+		Description: "Assignments are oldest first; the last one is the current department.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"assignments": []models.DepartmentAssignment{}, "count": 0}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/employees/:id/transfers"): {
+		Summary: "Transfer an employee to a department", Tag: "Employees",
+		Description: "Records the department from effective_from, which may be in the past but not the future. " +
+			"Attendance from that date on is evaluated against the new department's schedule; " +
+			"a transfer on the same date as an existing one replaces it.",
+		Body: models.TransferEmployeeRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"message": "", "assignments": []models.DepartmentAssignment{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/employees/:id/photo"): {
+		Summary: "Upload an employee's photo", Tag: "Employees",
+		Description: "Replaces any previous photo. The photo must be a JPEG, PNG, GIF or WebP image of at most 5 MB.",
+		Upload:      "photo",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"message": "", "photo_url": ""}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/photo"): {
+		Summary: "Get an employee's photo", Tag: "Employees",
+		Responses: map[int]openapi.Response{http.StatusOK: imageFile},
+		Errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/employees/:id/photo"): {
+		Summary: "Delete an employee's photo", Tag: "Employees",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/contracts"): {
+		Summary: "List an employee's working-time contracts", Tag: "Employees",
+		Description: "Contracts are oldest first; the last one is in force from its effective_from on.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"contracts": []models.WorkingTimeContract{}, "count": 0}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/employees/:id/contracts"): {
+		Summary: "Set an employee's working-time contract", Tag: "Employees",
+		Description: "Puts the employee on the contract from effective_from, which may be in the past or the future. " +
+			"Punches it covers are evaluated against its start window and core hours instead of the department's " +
+			"schedule; a contract on the same date as an existing one replaces it.",
+		Body: models.SetContractRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{
+				"message":   "",
+				"contract":  models.WorkingTimeContract{},
+				"contracts": []models.WorkingTimeContract{},
+			}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/employees/:id/contracts/:contract_id"): {
+		Summary: "Delete an employee's working-time contract", Tag: "Employees",
+		Description: "Punches the contract covered are evaluated against the contract before it, " +
+			"or the department's schedule when there is none.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"message": "", "contracts": []models.WorkingTimeContract{}}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/:id/flex-time"): {
+		Summary: "Get an employee's flex-time account", Tag: "Employees",
+		Description: "Balances the minutes worked against the contracted minutes on each day between start_date and " +
+			"end_date (YYYY-MM-DD, at most 366 days apart, up to today) covered by a working-time contract.",
+		Query: models.FlexTimeFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"flex_time": models.FlexTimeAccount{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/employees/export/csv"): {
+		Summary: "Download employees as CSV", Tag: "Employees",
+		Description: "Deleted employees are left out. Custom fields follow the standard columns, headed by their labels.",
+		Responses:   map[int]openapi.Response{http.StatusOK: csvFile},
+		Errors:      []int{http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/employees/import"): {
+		Summary: "Import employees from CSV or XLSX", Tag: "Employees",
+		Upload:    "file",
+		Params:    []openapi.Parameter{importMode},
+		Responses: importResponses(models.ImportReport{}),
+		Errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	// Custom fields
+	openapi.Key("POST", "/api/v1/custom-fields/"): {
+		Summary: "Define a custom employee field", Tag: "Custom Fields",
+		Description: "Employees carry custom field values in custom_fields, keyed by name and checked against " +
+			"field_type: text, number, date (YYYY-MM-DD), boolean or select, which takes one of options.",
+		Body: models.CreateCustomFieldRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {Body: openapi.Object{"message": "", "custom_field": models.CustomField{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/custom-fields/"): {
+		Summary: "List custom employee fields", Tag: "Custom Fields",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"custom_fields": []models.CustomField{}, "count": 0}},
+		},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/custom-fields/:id"): {
+		Summary: "Update a custom employee field", Tag: "Custom Fields",
+		Description: "The name and type cannot change. A select field cannot drop an option employees still have.",
+		Body:        models.UpdateCustomFieldRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"message": "", "custom_field": models.CustomField{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/custom-fields/:id"): {
+		Summary: "Delete a custom employee field", Tag: "Custom Fields",
+		Description: "Every employee's value for the field is deleted with it.",
+		Responses:   map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+
+	// Departments
+	openapi.Key("POST", "/api/v1/departments/"): {
+		Summary: "Create a department", Tag: "Departments",
+		Body: models.CreateDepartmentRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {Body: openapi.Object{"message": "", "department": models.Department{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/departments/"): {
+		Summary: "List departments", Tag: "Departments",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"departments": []models.Department{}, "count": 0}},
+		},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/departments/:id"): {
+		Summary: "Get a department", Tag: "Departments",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"department": models.Department{}}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/departments/:id"): {
+		Summary: "Update a department", Tag: "Departments",
+		Body:      models.UpdateDepartmentRequest{},
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/departments/:id"): {
+		Summary: "Delete a department", Tag: "Departments",
+		Description: "Departments that still have employees cannot be deleted, and neither can departments anyone has ever belonged to: their assignments are kept for good.",
+		Responses:   map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/departments/export/csv"): {
+		Summary: "Download departments as CSV", Tag: "Departments",
+		Responses: map[int]openapi.Response{http.StatusOK: csvFile},
+		Errors:    []int{http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/departments/import"): {
+		Summary: "Import departments from CSV or XLSX", Tag: "Departments",
+		Upload:    "file",
+		Params:    []openapi.Parameter{importMode},
+		Responses: importResponses(models.ImportReport{}),
+		Errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	// Attendance
+	openapi.Key("POST", "/api/v1/attendance/clock-in"): {
+		Summary: "Clock in", Tag: "Attendance",
+		Description: "Records the first clock-in of the day and whether it was within the department's limit. " +
+			"Employees who are suspended, deleted, not yet hired or past their termination date get EMPLOYEE_NOT_ACTIVE.",
+		Body: models.ClockInRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{
+				"message":       "",
+				"attendance_id": "",
+				"clock_in_time": "",
+				"is_on_time":    false,
+			}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/attendance/clock-out"): {
+		Summary: "Clock out", Tag: "Attendance",
+		Description: "Closes today's open clock-in and records whether it was after the department's limit.",
+		Body:        models.ClockOutRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{
+				"message":        "",
+				"attendance_id":  "",
+				"clock_in_time":  "",
+				"clock_out_time": "",
+				"is_on_time":     false,
+			}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/attendance/logs"): {
+		Summary: "List attendance logs", Tag: "Attendance",
+		Description: "date is YYYY-MM-DD. manager_id limits the logs to that manager's team, or their direct reports " +
+			"with direct_only. Without filters every log is returned, newest first. An X-Actor naming an employee " +
+			"limits the logs to their team.",
+		Query: models.AttendanceFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{
+				"attendance_logs": []models.AttendanceLog{},
+				"count":           0,
+				"filters":         models.AttendanceFilter{},
+			}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/attendance/absences"): {
+		Summary: "List absences", Tag: "Attendance",
+		Description: "Lists the working days between start_date and end_date (YYYY-MM-DD, at most 366 days apart) on " +
+			"which an employee expected at work did not clock in, oldest first. Working days come from the " +
+			"employee's working-time contract, or are weekdays without one. An X-Actor naming an employee limits " +
+			"the absences to their team.",
+		Query: models.AbsenceFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{
+				"absences": []models.Absence{},
+				"count":    0,
+				"filters":  models.AbsenceFilter{},
+			}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/attendance/stream"): {
+		Summary: "Stream clock-ins and clock-outs", Tag: "Attendance",
+		Description: "Server-Sent Events named attendance.clock_in or attendance.clock_out, each carrying an " +
+			"attendance event with its history ID as the event ID. Idle streams get a heartbeat comment " +
+			"every 15 seconds. Reconnecting with Last-Event-ID replays the events missed since. manager_id limits " +
+			"the stream to that manager's team, or their direct reports with direct_only. An X-Actor naming an " +
+			"employee limits the stream to their team.",
+		Query: models.AttendanceStreamFilter{},
+		Params: []openapi.Parameter{{
+			Name:        "Last-Event-ID",
+			In:          "header",
+			Description: "History ID of the last event received; takes precedence over last_event_id",
+			Schema:      &openapi.Schema{Type: "integer"},
+		}},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Event stream", Body: models.AttendanceEvent{}, ContentType: "text/event-stream"},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/attendance/export/csv"): {
+		Summary: "Download attendance logs as CSV", Tag: "Attendance",
+		Query:     models.AttendanceFilter{},
+		Responses: map[int]openapi.Response{http.StatusOK: csvFile},
+		Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/attendance/import"): {
+		Summary: "Import legacy punch logs", Tag: "Attendance",
+		Upload:    "file",
+		Params:    []openapi.Parameter{importMode},
+		Responses: importResponses(models.PunchImportReport{}),
+		Errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	// Export jobs
+	openapi.Key("POST", "/api/v1/exports"): {
+		Summary: "Queue an export job", Tag: "Exports",
+		Description: "filters.manager_id limits attendance and employee exports to that manager's team. An X-Actor " +
+			"naming an employee limits the export to their team.",
+		Body: models.CreateExportJobRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusAccepted: {Body: openapi.Object{"message": "", "job": models.ExportJob{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	},
+	openapi.Key("GET", "/api/v1/exports/:id"): {
+		Summary: "Get export job status and progress", Tag: "Exports",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"job": models.ExportJob{}}},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/exports/:id/download"): {
+		Summary: "Download the file of a completed export job", Tag: "Exports",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: csvFile,
+			http.StatusConflict: {
+				Description: "The job has not completed yet",
+				Body:        openapi.Object{"error": models.APIError{}, "status": ""},
+			},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusGone, http.StatusInternalServerError},
+	},
+
+	// Report schedules
+	openapi.Key("POST", "/api/v1/report-schedules/"): {
+		Summary: "Create a report schedule", Tag: "Reports",
+		Body: models.CreateReportScheduleRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {Body: openapi.Object{"message": "", "report_schedule": models.ReportSchedule{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/report-schedules/"): {
+		Summary: "List report schedules", Tag: "Reports",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"report_schedules": []models.ReportSchedule{}, "count": 0}},
+		},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/report-schedules/:id"): {
+		Summary: "Get a report schedule", Tag: "Reports",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"report_schedule": models.ReportSchedule{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/report-schedules/:id"): {
+		Summary: "Update a report schedule", Tag: "Reports",
+		Body:      models.UpdateReportScheduleRequest{},
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/report-schedules/:id"): {
+		Summary: "Delete a report schedule", Tag: "Reports",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/report-schedules/:id/run"): {
+		Summary: "Send a scheduled report now", Tag: "Reports",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusBadGateway},
+	},
+
+	// Webhooks
+	openapi.Key("POST", "/api/v1/webhooks/"): {
+		Summary: "Subscribe a URL to events", Tag: "Webhooks",
+		Description: "Payloads are signed with HMAC-SHA256 over \"<X-Webhook-Timestamp>.<body>\" and sent in " +
+			"X-Webhook-Signature as sha256=<hex>. The secret is generated when omitted and only returned here.",
+		Body: models.CreateWebhookRequest{},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {Body: openapi.Object{"message": "", "webhook": models.Webhook{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/webhooks/"): {
+		Summary: "List webhooks", Tag: "Webhooks",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"webhooks": []models.Webhook{}, "count": 0}},
+		},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/webhooks/:id"): {
+		Summary: "Get a webhook", Tag: "Webhooks",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"webhook": models.Webhook{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("PUT", "/api/v1/webhooks/:id"): {
+		Summary: "Update a webhook", Tag: "Webhooks",
+		Body:      models.UpdateWebhookRequest{},
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("DELETE", "/api/v1/webhooks/:id"): {
+		Summary: "Delete a webhook and its deliveries", Tag: "Webhooks",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: messageBody}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/webhooks/:id/deliveries"): {
+		Summary: "List deliveries of a webhook", Tag: "Webhooks",
+		Description: "Deliveries that ran out of retries have status dead.",
+		Query:       models.WebhookDeliveryFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"deliveries": []models.WebhookDelivery{}, "count": 0}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	openapi.Key("POST", "/api/v1/webhooks/:id/deliveries/:delivery_id/redeliver"): {
+		Summary: "Send a delivery again", Tag: "Webhooks",
+		Description: "Resets the attempt count so the delivery gets a full set of retries.",
+		Responses: map[int]openapi.Response{
+			http.StatusAccepted: {Body: openapi.Object{"message": "", "delivery": models.WebhookDelivery{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+
+	// Audit log
+	openapi.Key("GET", "/api/v1/audit"): {
+		Summary: "Search the audit log", Tag: "Audit",
+		Description: "Entries are returned newest first, 100 at a time unless limit is set. " +
+			"The actor is taken from the X-Actor header of the request that made the change.",
+		Query: models.AuditFilter{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"entries": []models.AuditEntry{}, "count": 0, "filters": models.AuditFilter{}}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/audit/export/csv"): {
+		Summary: "Export the audit log as CSV", Tag: "Audit",
+		Description: "Exports every matching entry, hashes included; limit and offset page the export when set.",
+		Query:       models.AuditFilter{},
+		Responses:   map[int]openapi.Response{http.StatusOK: csvFile},
+		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	openapi.Key("GET", "/api/v1/audit/verify"): {
+		Summary: "Verify the audit log hash chain", Tag: "Audit",
+		Description: "Recomputes every entry's hash and reports the first entry that was altered or unlinked. " +
+			"Compare last_hash with a copy kept outside the database to detect a rewritten chain.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"verification": models.AuditVerification{}}},
+		},
+		Errors: []int{http.StatusInternalServerError},
+	},
+
+	// Operations
+	openapi.Key("GET", "/metrics"): {
+		Summary: "Prometheus metrics", Tag: "Operations",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Metrics in the Prometheus text format", Body: "", ContentType: "text/plain"},
+		},
+	},
+	openapi.Key("GET", "/livez"): {
+		Summary: "Liveness probe", Tag: "Operations",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: openapi.Object{"status": ""}}},
+	},
+	openapi.Key("GET", "/readyz"): {
+		Summary: "Readiness probe with a breakdown per dependency", Tag: "Operations",
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                 {Description: "Every check passed", Body: models.ReadinessReport{}},
+			http.StatusServiceUnavailable: {Description: "At least one check failed", Body: models.ReadinessReport{}},
+		},
+	},
+	openapi.Key("GET", "/health"): {
+		Summary: "Health summary", Tag: "Operations",
+		Description: "Prefer /livez and /readyz for orchestrator probes.",
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                 {Body: healthBody},
+			http.StatusServiceUnavailable: {Description: "The database is unreachable", Body: healthBody},
+		},
+	},
+	openapi.Key("GET", "/"): {
+		Summary: "API index", Tag: "Operations",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Body: openapi.Object{"message": "", "version": "", "endpoints": map[string]string{}}},
+		},
+	},
+	openapi.Key("GET", "/openapi.json"): {
+		Summary: "This OpenAPI document", Tag: "Operations",
+		Responses: map[int]openapi.Response{http.StatusOK: {Body: openapi.Object{}}},
+	},
+	openapi.Key("GET", "/docs/*filepath"): {
+		Summary: "Swagger UI", Hidden: true,
+	},
+}
@@ -3,10 +3,17 @@ package routes
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"attendance-system/config"
 	"attendance-system/handlers"
+	"attendance-system/logging"
+	"attendance-system/metrics"
+	"attendance-system/models"
+	"attendance-system/openapi"
+	"attendance-system/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,18 +22,34 @@ import (
 var startTime = time.Now()
 
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(r *gin.Engine, db *sql.DB) {
+func SetupRoutes(r *gin.Engine, db *sql.DB, corsConfig config.CORSConfig, exportService *services.ExportJobService, reportScheduler *services.ReportScheduler, webhooks *services.WebhookDispatcher, outbox *services.Outbox, feed *services.AttendanceFeed, healthChecker *services.HealthChecker, blobs services.BlobStore) {
+	// Request IDs come first so every later log line and error carries one
+	r.Use(logging.RequestID(), logging.AccessLog(), logging.Recovery())
+
 	// CORS configuration
 	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
+	if corsConfig.AllowAllOrigins() {
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOrigins = corsConfig.AllowedOrigins
+	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", logging.RequestIDHeader, handlers.ActorHeader}
+	config.ExposeHeaders = []string{logging.RequestIDHeader}
 	r.Use(cors.New(config))
+	r.Use(metrics.Middleware())
 
 	// Initialize handlers
-	employeeHandler := handlers.NewEmployeeHandler(db)
-	departmentHandler := handlers.NewDepartmentHandler(db)
-	attendanceHandler := handlers.NewAttendanceHandler(db)
+	employeeHandler := handlers.NewEmployeeHandler(db, outbox, blobs, exportService.Dir())
+	contractHandler := handlers.NewContractHandler(db)
+	customFieldHandler := handlers.NewCustomFieldHandler(db)
+	departmentHandler := handlers.NewDepartmentHandler(db, outbox, exportService.Dir())
+	attendanceHandler := handlers.NewAttendanceHandler(db, outbox, feed, exportService.Dir())
+	exportHandler := handlers.NewExportHandler(db, exportService)
+	reportScheduleHandler := handlers.NewReportScheduleHandler(db, reportScheduler)
+	webhookHandler := handlers.NewWebhookHandler(db, webhooks)
+	auditHandler := handlers.NewAuditHandler(db)
+	healthHandler := handlers.NewHealthHandler(healthChecker)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
@@ -39,7 +62,28 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			employees.GET("/:id", employeeHandler.GetEmployee)
 			employees.PUT("/:id", employeeHandler.UpdateEmployee)
 			employees.DELETE("/:id", employeeHandler.DeleteEmployee)
+			employees.GET("/:id/assignments", employeeHandler.GetEmployeeAssignments)
+			employees.GET("/:id/reports", employeeHandler.GetDirectReports)
+			employees.GET("/:id/org-chart", employeeHandler.GetOrgChart)
+			employees.POST("/:id/transfers", employeeHandler.TransferEmployee)
+			employees.PUT("/:id/photo", employeeHandler.UploadEmployeePhoto)
+			employees.GET("/:id/photo", employeeHandler.GetEmployeePhoto)
+			employees.DELETE("/:id/photo", employeeHandler.DeleteEmployeePhoto)
+			employees.GET("/:id/contracts", contractHandler.GetContracts)
+			employees.POST("/:id/contracts", contractHandler.SetContract)
+			employees.DELETE("/:id/contracts/:contract_id", contractHandler.DeleteContract)
+			employees.GET("/:id/flex-time", contractHandler.GetFlexTime)
 			employees.GET("/export/csv", employeeHandler.ExportEmployeesCSV)
+			employees.POST("/import", employeeHandler.ImportEmployees)
+		}
+
+		// Custom employee field routes
+		customFields := v1.Group("/custom-fields")
+		{
+			customFields.POST("/", customFieldHandler.CreateCustomField)
+			customFields.GET("/", customFieldHandler.GetCustomFields)
+			customFields.PUT("/:id", customFieldHandler.UpdateCustomField)
+			customFields.DELETE("/:id", customFieldHandler.DeleteCustomField)
 		}
 
 		// Department routes
@@ -51,6 +95,7 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			departments.PUT("/:id", departmentHandler.UpdateDepartment)
 			departments.DELETE("/:id", departmentHandler.DeleteDepartment)
 			departments.GET("/export/csv", departmentHandler.ExportDepartmentsCSV)
+			departments.POST("/import", departmentHandler.ImportDepartments)
 		}
 
 		// Attendance routes
@@ -60,24 +105,78 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			attendance.PUT("/clock-out", attendanceHandler.ClockOut)
 			attendance.GET("/export/csv", attendanceHandler.ExportAttendanceLogsCSV)
 			attendance.GET("/logs", attendanceHandler.GetAttendanceLogs)
+			attendance.GET("/absences", attendanceHandler.GetAbsences)
+			attendance.GET("/stream", attendanceHandler.StreamAttendance)
+			attendance.POST("/import", attendanceHandler.ImportPunches)
+		}
+
+		// Export job routes
+		exports := v1.Group("/exports")
+		{
+			exports.POST("", exportHandler.CreateExportJob)
+			exports.GET("/:id", exportHandler.GetExportJob)
+			exports.GET("/:id/download", exportHandler.DownloadExportJob)
+		}
+
+		// Report schedule routes
+		reportSchedules := v1.Group("/report-schedules")
+		{
+			reportSchedules.POST("/", reportScheduleHandler.CreateReportSchedule)
+			reportSchedules.GET("/", reportScheduleHandler.GetReportSchedules)
+			reportSchedules.GET("/:id", reportScheduleHandler.GetReportSchedule)
+			reportSchedules.PUT("/:id", reportScheduleHandler.UpdateReportSchedule)
+			reportSchedules.DELETE("/:id", reportScheduleHandler.DeleteReportSchedule)
+			reportSchedules.POST("/:id/run", reportScheduleHandler.RunReportSchedule)
+		}
+
+		// Webhook routes
+		webhookRoutes := v1.Group("/webhooks")
+		{
+			webhookRoutes.POST("/", webhookHandler.CreateWebhook)
+			webhookRoutes.GET("/", webhookHandler.GetWebhooks)
+			webhookRoutes.GET("/:id", webhookHandler.GetWebhook)
+			webhookRoutes.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhookRoutes.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhookRoutes.GET("/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+			webhookRoutes.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhookDelivery)
+		}
+
+		// Audit log routes
+		audit := v1.Group("/audit")
+		{
+			audit.GET("", auditHandler.GetAuditLog)
+			audit.GET("/export/csv", auditHandler.ExportAuditLogCSV)
+			audit.GET("/verify", auditHandler.VerifyAuditLog)
 		}
 	}
 
+	// Prometheus metrics
+	r.GET("/metrics", metrics.Handler())
+
+	// API documentation, generated once every route is registered
+	var spec *openapi.Document
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	r.GET("/docs/*filepath", openapi.SwaggerUI("/openapi.json"))
+
+	// Liveness and readiness probes
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+
 	// Enhanced health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		// Check database connection
-		var dbStatus string
-		if err := db.Ping(); err != nil {
-			dbStatus = "disconnected"
-		} else {
-			dbStatus = "connected"
+		code, status, dbStatus := http.StatusOK, "ok", "connected"
+		if err := db.PingContext(c.Request.Context()); err != nil {
+			code, status, dbStatus = http.StatusServiceUnavailable, "unavailable", "disconnected"
 		}
 
 		uptime := time.Since(startTime)
 		uptimeStr := fmt.Sprintf("%.0fs", uptime.Seconds())
 
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
+		c.JSON(code, gin.H{
+			"status":    status,
 			"message":   "Attendance System API is running",
 			"uptime":    uptimeStr,
 			"database":  dbStatus,
@@ -94,8 +193,25 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 				"employees":   "/api/v1/employees",
 				"departments": "/api/v1/departments",
 				"attendance":  "/api/v1/attendance",
+				"exports":     "/api/v1/exports",
+				"reports":     "/api/v1/report-schedules",
+				"webhooks":    "/api/v1/webhooks",
+				"audit":       "/api/v1/audit",
 				"health":      "/health",
+				"livez":       "/livez",
+				"readyz":      "/readyz",
+				"metrics":     "/metrics",
+				"openapi":     "/openapi.json",
+				"docs":        "/docs/",
 			},
 		})
 	})
+
+	r.NoRoute(handlers.RouteNotFound)
+
+	spec, undocumented := openapi.Build(apiInfo, r.Routes(), apiOperations, models.ErrorResponse{})
+	documentErrorCodes(spec)
+	for _, route := range undocumented {
+		slog.Warn("route missing from OpenAPI document", "route", route)
+	}
 }
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"attendance-system/config"
+	"attendance-system/errcodes"
+	"attendance-system/models"
+	"attendance-system/openapi"
+	"attendance-system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	exportService := services.NewExportJobService(nil, os.TempDir(), 1, time.Hour)
+	SetupRoutes(r, nil, config.CORSConfig{AllowedOrigins: []string{"*"}}, exportService, nil, nil, nil, services.NewAttendanceFeed(), nil, nil)
+	return r
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	r := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc openapi.Document
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc)) {
+		return
+	}
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		key := openapi.Key(route.Method, route.Path)
+		registered[key] = true
+		if op, ok := apiOperations[key]; ok && op.Hidden {
+			continue
+		}
+
+		path := route.Path
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				path = strings.Replace(path, segment, "{"+segment[1:]+"}", 1)
+			}
+		}
+		item, ok := doc.Paths[path]
+		if assert.True(t, ok, "%s is missing from the OpenAPI document", key) {
+			assert.NotNil(t, item[strings.ToLower(route.Method)], "%s is missing from the OpenAPI document", key)
+		}
+	}
+
+	for key := range apiOperations {
+		assert.True(t, registered[key], "%s is documented but not registered", key)
+	}
+}
+
+func TestOpenAPISchemasFollowModels(t *testing.T) {
+	r := setupTestRouter()
+	doc, _ := openapi.Build(apiInfo, r.Routes(), apiOperations, models.ErrorResponse{})
+	documentErrorCodes(doc)
+
+	apiError := doc.Components.Schemas["APIError"]
+	if assert.NotNil(t, apiError) {
+		assert.Contains(t, apiError.Properties["code"].Enum, string(errcodes.EmployeeNotFound))
+	}
+
+	request := doc.Components.Schemas["CreateReportScheduleRequest"]
+	if assert.NotNil(t, request) {
+		assert.ElementsMatch(t, []string{"cron_expr", "name", "recipients", "report_type"}, request.Required)
+		assert.Equal(t, []string{"late_arrivals", "attendance_summary", "absences"}, request.Properties["report_type"].Enum)
+		assert.Equal(t, "email", request.Properties["recipients"].Items.Format)
+		assert.Equal(t, "#/components/schemas/ReportFilters", request.Properties["filters"].Ref)
+	}
+
+	logs := doc.Paths["/api/v1/attendance/logs"]["get"]
+	if assert.NotNil(t, logs) {
+		var names []string
+		for _, param := range logs.Parameters {
+			names = append(names, param.Name)
+		}
+		assert.ElementsMatch(t, []string{"date", "department_id", "manager_id", "direct_only"}, names)
+		assert.Equal(t, "GetAttendanceLogs", logs.OperationID)
+	}
+
+	employee := doc.Paths["/api/v1/employees/{id}"]["get"]
+	if assert.NotNil(t, employee) && assert.Len(t, employee.Parameters, 1) {
+		assert.Equal(t, "path", employee.Parameters[0].In)
+	}
+}
+
+func TestRouteNotFound(t *testing.T) {
+	r := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/nope", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var body models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errcodes.RouteNotFound, body.Error.Code)
+}
+
+func TestSwaggerUI(t *testing.T) {
+	r := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "swagger-ui")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs/swagger-initializer.js", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"/openapi.json"`)
+}
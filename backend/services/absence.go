@@ -0,0 +1,144 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"attendance-system/models"
+)
+
+// maxAbsenceDays bounds the range of an absence report
+const maxAbsenceDays = 366
+
+// QueryAbsences lists, oldest first, the working days between
+// filters.StartDate and filters.EndDate on which an employee expected at work
+// did not clock in. Working days are those of the employee's working-time
+// contract, or weekdays without one. Employees count from their hire date up
+// to their termination date and not while suspended or after being deleted,
+// so former employees drop out of the report after they leave. Each absence
+// is reported under the department the employee belonged to that day. A
+// manager filter keeps their team only. Invalid filters return an error
+// wrapping ErrInvalidExportFilters.
+func QueryAbsences(db *sql.DB, filters models.ExportFilters) ([]models.Absence, error) {
+	if err := validateExportFilters(filters); err != nil {
+		return nil, err
+	}
+	if filters.StartDate == "" || filters.EndDate == "" {
+		return nil, fmt.Errorf("%w: start_date and end_date are required", ErrInvalidExportFilters)
+	}
+	first, _ := parseDay(filters.StartDate)
+	last, _ := parseDay(filters.EndDate)
+	if last.Sub(first) >= maxAbsenceDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: the range must not exceed %d days", ErrInvalidExportFilters, maxAbsenceDays)
+	}
+
+	rows, err := db.Query(`
+		SELECT `+EmployeeColumns+`
+		FROM employee e
+		WHERE e.deleted_at IS NULL OR e.deleted_at >= ?
+		ORDER BY e.employee_id
+	`, first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %v", err)
+	}
+	defer rows.Close()
+
+	var roster []models.Employee
+	for rows.Next() {
+		emp, err := ScanEmployee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read employee: %v", err)
+		}
+		roster = append(roster, emp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	spans, err := assignmentSpans(db, "")
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := contractSpans(db, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var team map[string]bool
+	if filters.ManagerID != "" {
+		members, err := TeamMembers(db, filters.ManagerID, filters.DirectOnly)
+		if err != nil {
+			return nil, err
+		}
+		team = make(map[string]bool)
+		for _, employeeID := range members {
+			team[employeeID] = true
+		}
+	}
+
+	_, end := DayBounds(last)
+	present, err := clockInDays(db, first, end)
+	if err != nil {
+		return nil, err
+	}
+
+	absences := []models.Absence{}
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+		date := day.Format("2006-01-02")
+		for _, emp := range roster {
+			if present[emp.EmployeeID+"|"+date] || InactiveReason(emp, day) != "" {
+				continue
+			}
+			if contract := contractAt(contracts[emp.EmployeeID], day); contract != nil {
+				if !worksOn(contract, day) {
+					continue
+				}
+			} else if weekend {
+				continue
+			}
+			if team != nil && !team[emp.EmployeeID] {
+				continue
+			}
+			dept, ok := departmentAt(spans[emp.EmployeeID], day)
+			if !ok {
+				continue
+			}
+			if filters.DepartmentID > 0 && dept.ID != filters.DepartmentID {
+				continue
+			}
+			absences = append(absences, models.Absence{
+				Date:           date,
+				EmployeeID:     emp.EmployeeID,
+				EmployeeName:   emp.Name,
+				DepartmentID:   dept.ID,
+				DepartmentName: dept.DepartementName,
+			})
+		}
+	}
+
+	return absences, nil
+}
+
+// clockInDays returns the set of "employee|YYYY-MM-DD" keys for every local
+// day between start and end on which an employee clocked in
+func clockInDays(db *sql.DB, start, end time.Time) (map[string]bool, error) {
+	rows, err := db.Query("SELECT employee_id, clock_in FROM attendance WHERE clock_in >= ? AND clock_in < ?", start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attendance: %v", err)
+	}
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var employeeID string
+		var clockIn time.Time
+		if err := rows.Scan(&employeeID, &clockIn); err != nil {
+			return nil, fmt.Errorf("failed to read attendance: %v", err)
+		}
+		present[employeeID+"|"+clockIn.In(start.Location()).Format("2006-01-02")] = true
+	}
+
+	return present, rows.Err()
+}
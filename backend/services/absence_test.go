@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryAbsencesFollowsEmployment(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?), (2, 'HR', '08:00:00', '16:00:00', ?, ?)`,
+		created, created, created, created)
+	assert.NoError(t, err)
+
+	for _, emp := range []struct {
+		id, status      string
+		hire, terminate interface{}
+		deleted         interface{}
+	}{
+		{"A", models.EmploymentActive, "2024-01-01", nil, nil},
+		{"B", models.EmploymentTerminated, nil, "2024-01-03", nil},
+		{"C", models.EmploymentSuspended, nil, nil, nil},
+		{"D", models.EmploymentActive, "2024-01-04", nil, nil},
+		{"E", models.EmploymentActive, nil, nil, time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)},
+	} {
+		_, err := db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, employment_status, hire_date,
+				termination_date, deleted_at, created_at, updated_at)
+			VALUES (?, 1, ?, '', ?, ?, ?, ?, ?, ?)`,
+			emp.id, "Employee "+emp.id, emp.status, emp.hire, emp.terminate, emp.deleted, created, created)
+		assert.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+			VALUES (?, 1, '2023-06-01', ?)`, emp.id, created)
+		assert.NoError(t, err)
+	}
+
+	// A moves to HR from Thursday 4 January
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assignments, err := AssignDepartment(db, tx, "A", 2, "2024-01-04", time.Now())
+	assert.NoError(t, tx.Commit())
+	if assert.NoError(t, err) && assert.Len(t, assignments, 2) {
+		assert.Equal(t, "2024-01-03", *assignments[0].EffectiveUntil)
+		assert.Equal(t, "HR", assignments[1].DepartmentName)
+		assert.Nil(t, assignments[1].EffectiveUntil)
+	}
+	clockIn := time.Date(2024, 1, 2, 8, 30, 0, 0, time.Local)
+	_, err = db.Exec(`INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at)
+		VALUES ('A', 'att-1', ?, ?, ?)`, clockIn, clockIn, clockIn)
+	assert.NoError(t, err)
+
+	// Monday 1 to Sunday 7 January 2024
+	absences, err := QueryAbsences(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-01-07"})
+	assert.NoError(t, err)
+
+	var got []string
+	for _, absence := range absences {
+		got = append(got, absence.Date+" "+absence.EmployeeID)
+	}
+	assert.Equal(t, []string{
+		"2024-01-01 A", "2024-01-01 B", "2024-01-01 E",
+		"2024-01-02 B",
+		"2024-01-03 A", "2024-01-03 B",
+		"2024-01-04 A", "2024-01-04 D",
+		"2024-01-05 A", "2024-01-05 D",
+	}, got)
+	departments := map[string]string{}
+	for _, absence := range absences {
+		if absence.EmployeeID == "A" {
+			departments[absence.Date] = absence.DepartmentName
+		}
+	}
+	assert.Equal(t, map[string]string{"2024-01-01": "IT", "2024-01-03": "IT", "2024-01-04": "HR", "2024-01-05": "HR"}, departments)
+
+	hr, err := QueryAbsences(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-01-07", DepartmentID: 2})
+	assert.NoError(t, err)
+	assert.Len(t, hr, 2)
+
+	_, err = QueryAbsences(db, models.ExportFilters{StartDate: "2024-01-01"})
+	assert.ErrorIs(t, err, ErrInvalidExportFilters)
+	_, err = QueryAbsences(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2025-06-01"})
+	assert.ErrorIs(t, err, ErrInvalidExportFilters)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"sync"
+
+	"attendance-system/models"
+)
+
+// attendanceFeedBuffer is how many events a subscriber may fall behind by
+// before it is disconnected
+const attendanceFeedBuffer = 64
+
+// AttendanceFeed fans clock-in and clock-out events out to live subscribers
+// in this process. Subscribers that cannot keep up are closed rather than
+// slowing down publishers; they are expected to reconnect and catch up from
+// the attendance history.
+type AttendanceFeed struct {
+	mu          sync.Mutex
+	subscribers map[*AttendanceSubscription]struct{}
+	closed      bool
+}
+
+// AttendanceSubscription receives attendance events on C until it is closed
+type AttendanceSubscription struct {
+	C <-chan models.AttendanceEvent
+
+	feed         *AttendanceFeed
+	ch           chan models.AttendanceEvent
+	departmentID int
+}
+
+// NewAttendanceFeed creates a new attendance feed
+func NewAttendanceFeed() *AttendanceFeed {
+	return &AttendanceFeed{subscribers: make(map[*AttendanceSubscription]struct{})}
+}
+
+// Subscribe returns a subscription to attendance events, limited to one
+// department when departmentID is set. It must be closed when done. Once the
+// feed is closed, C is closed straight away.
+func (f *AttendanceFeed) Subscribe(departmentID int) *AttendanceSubscription {
+	ch := make(chan models.AttendanceEvent, attendanceFeedBuffer)
+	sub := &AttendanceSubscription{C: ch, feed: f, ch: ch, departmentID: departmentID}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(ch)
+		return sub
+	}
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close ends every subscription so that streams return, as the server shuts
+// down. Later subscriptions are closed as soon as they are made.
+func (f *AttendanceFeed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for sub := range f.subscribers {
+		f.remove(sub)
+	}
+}
+
+// Subscribers returns the number of open subscriptions
+func (f *AttendanceFeed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers)
+}
+
+// Publish delivers clock-in and clock-out events to matching subscribers.
+// Other events are ignored; attendance.late repeats a clock-in.
+func (f *AttendanceFeed) Publish(event models.Event) {
+	if event.Type != models.EventAttendanceClockIn && event.Type != models.EventAttendanceClockOut {
+		return
+	}
+	data, ok := event.Data.(models.AttendanceEvent)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subscribers {
+		if sub.departmentID > 0 && sub.departmentID != data.DepartementID {
+			continue
+		}
+		select {
+		case sub.ch <- data:
+		default:
+			f.remove(sub)
+		}
+	}
+}
+
+// Close ends the subscription and closes C. It is safe to call more than once.
+func (s *AttendanceSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
+
+// remove drops sub and closes its channel. f.mu must be held.
+func (f *AttendanceFeed) remove(sub *AttendanceSubscription) {
+	if _, ok := f.subscribers[sub]; ok {
+		delete(f.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// AttendanceEventFromLog rebuilds the event recorded by an attendance
+// history row, for replaying history to stream clients
+func AttendanceEventFromLog(log models.AttendanceLog) models.AttendanceEvent {
+	return models.AttendanceEvent{
+		HistoryID:      log.ID,
+		AttendanceID:   log.AttendanceID,
+		AttendanceType: log.AttendanceType,
+		EmployeeID:     log.EmployeeID,
+		EmployeeName:   log.EmployeeName,
+		DepartementID:  log.DepartmentID,
+		DepartmentName: log.DepartmentName,
+		Time:           log.DateAttendance,
+		IsOnTime:       log.IsOnTime,
+		LateMinutes:    log.LateMinutes,
+		EarlyMinutes:   log.EarlyMinutes,
+		Description:    log.Description,
+	}
+}
+
+// AttendanceEventType returns the event type of an attendance history type
+func AttendanceEventType(attendanceType int) string {
+	if attendanceType == 2 {
+		return models.EventAttendanceClockOut
+	}
+	return models.EventAttendanceClockIn
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttendanceFeedFiltersByDepartment(t *testing.T) {
+	feed := NewAttendanceFeed()
+	all := feed.Subscribe(0)
+	defer all.Close()
+	it := feed.Subscribe(1)
+	defer it.Close()
+
+	feed.Publish(NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{HistoryID: 1, DepartementID: 2}))
+	feed.Publish(NewEvent(models.EventAttendanceLate, models.AttendanceEvent{HistoryID: 1, DepartementID: 2}))
+	feed.Publish(NewEvent(models.EventAttendanceClockOut, models.AttendanceEvent{HistoryID: 2, DepartementID: 1}))
+	feed.Publish(NewEvent(models.EventEmployeeCreated, models.Employee{ID: 1}))
+
+	assert.Equal(t, 1, (<-all.C).HistoryID)
+	assert.Equal(t, 2, (<-all.C).HistoryID)
+	assert.Len(t, all.C, 0, "late events repeat a clock-in")
+	assert.Equal(t, 2, (<-it.C).HistoryID)
+	assert.Len(t, it.C, 0)
+}
+
+func TestAttendanceFeedDropsSlowSubscribers(t *testing.T) {
+	feed := NewAttendanceFeed()
+	slow := feed.Subscribe(0)
+
+	for i := 0; i <= attendanceFeedBuffer; i++ {
+		feed.Publish(NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{HistoryID: i + 1}))
+	}
+	assert.Equal(t, 0, feed.Subscribers())
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	assert.Equal(t, attendanceFeedBuffer, received, "buffered events are still delivered before C closes")
+
+	// Closing again after being dropped is harmless
+	slow.Close()
+}
+
+func TestAttendanceFeedClose(t *testing.T) {
+	feed := NewAttendanceFeed()
+	open := feed.Subscribe(0)
+	defer open.Close()
+
+	feed.Close()
+	assert.Equal(t, 0, feed.Subscribers())
+	_, ok := <-open.C
+	assert.False(t, ok)
+
+	// Streams opened while shutting down end at once
+	late := feed.Subscribe(0)
+	defer late.Close()
+	_, ok = <-late.C
+	assert.False(t, ok)
+	assert.Equal(t, 0, feed.Subscribers())
+}
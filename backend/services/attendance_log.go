@@ -0,0 +1,173 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// DayBounds returns the start of t's calendar day and the start of the next
+// day. Filtering on a [start, end) range instead of DATE(column) keeps the
+// queries portable across database backends and lets them use indexes.
+func DayBounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// parseDay parses a YYYY-MM-DD date in the local time zone
+func parseDay(date string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", date, time.Local)
+}
+
+// QueryAttendanceLogs fetches attendance history rows matching the filters,
+// with the evaluation stored when each punch was recorded. A manager filter
+// keeps their team's punches only. Invalid filters return an error wrapping
+// ErrInvalidExportFilters.
+func QueryAttendanceLogs(db *sql.DB, filters models.ExportFilters) ([]models.AttendanceLog, error) {
+	if err := validateExportFilters(filters); err != nil {
+		return nil, err
+	}
+
+	query := attendanceLogQuery
+
+	var args []interface{}
+
+	if filters.Date != "" {
+		day, _ := parseDay(filters.Date)
+		start, end := DayBounds(day)
+		query += " AND ah.date_attendance >= ? AND ah.date_attendance < ?"
+		args = append(args, start, end)
+	}
+	if filters.StartDate != "" {
+		start, _ := parseDay(filters.StartDate)
+		query += " AND ah.date_attendance >= ?"
+		args = append(args, start)
+	}
+	if filters.EndDate != "" {
+		day, _ := parseDay(filters.EndDate)
+		_, end := DayBounds(day)
+		query += " AND ah.date_attendance < ?"
+		args = append(args, end)
+	}
+	if filters.DepartmentID > 0 {
+		query += " AND ah.departement_id = ?"
+		args = append(args, filters.DepartmentID)
+	}
+	if filters.ManagerID != "" {
+		condition, team, err := TeamCondition(db, "ah.employee_id", filters.ManagerID, filters.DirectOnly)
+		if err != nil {
+			return nil, err
+		}
+		query += condition
+		args = append(args, team...)
+	}
+
+	query += " ORDER BY ah.date_attendance DESC"
+
+	return queryAttendanceLogs(db, query, args...)
+}
+
+// AttendanceLogsAfter fetches up to limit attendance history rows with an ID
+// above afterID, oldest first, optionally for one department
+func AttendanceLogsAfter(db *sql.DB, afterID, departmentID, limit int) ([]models.AttendanceLog, error) {
+	query := attendanceLogQuery + " AND ah.id > ?"
+	args := []interface{}{afterID}
+	if departmentID > 0 {
+		query += " AND ah.departement_id = ?"
+		args = append(args, departmentID)
+	}
+	query += " ORDER BY ah.id LIMIT ?"
+	args = append(args, limit)
+
+	return queryAttendanceLogs(db, query, args...)
+}
+
+// attendanceLogQuery selects attendance history rows with the employee, the
+// department, schedule and contract the punch was evaluated against and the
+// result.
+// Callers append conditions.
+const attendanceLogQuery = `
+	SELECT
+		ah.id,
+		ah.employee_id,
+		e.name as employee_name,
+		ah.departement_id as department_id,
+		d.departement_name as department_name,
+		ah.attendance_id,
+		ah.date_attendance,
+		ah.attendance_type,
+		ah.description,
+		ah.max_clock_in_time,
+		ah.max_clock_out_time,
+		ah.contract_id,
+		ah.is_on_time,
+		ah.late_minutes,
+		ah.early_minutes,
+		ah.created_at
+	FROM attendance_history ah
+	LEFT JOIN employee e ON ah.employee_id = e.employee_id
+	LEFT JOIN departement d ON ah.departement_id = d.id
+	WHERE 1=1
+`
+
+// queryAttendanceLogs runs a query built on attendanceLogQuery
+func queryAttendanceLogs(db *sql.DB, query string, args ...interface{}) ([]models.AttendanceLog, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attendance logs: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []models.AttendanceLog
+	for rows.Next() {
+		var log models.AttendanceLog
+		var maxClockIn, maxClockOut sql.NullString
+		var contractID sql.NullInt64
+		err := rows.Scan(
+			&log.ID,
+			&log.EmployeeID,
+			&log.EmployeeName,
+			&log.DepartmentID,
+			&log.DepartmentName,
+			&log.AttendanceID,
+			&log.DateAttendance,
+			&log.AttendanceType,
+			&log.Description,
+			&maxClockIn,
+			&maxClockOut,
+			&contractID,
+			&log.IsOnTime,
+			&log.LateMinutes,
+			&log.EarlyMinutes,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attendance log: %v", err)
+		}
+		log.MaxClockInTime = maxClockIn.String
+		log.MaxClockOutTime = maxClockOut.String
+		if contractID.Valid {
+			id := int(contractID.Int64)
+			log.ContractID = &id
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+// RecordPunch inserts the attendance_history row of a punch of
+// attendanceType at t, with its evaluation, and returns its ID
+func RecordPunch(db *sql.DB, tx *sql.Tx, employeeID, attendanceID string, attendanceType int, t time.Time, eval PunchEvaluation, now time.Time) (int64, error) {
+	return database.TxInsertReturningID(db, tx, `
+		INSERT INTO attendance_history (employee_id, attendance_id, date_attendance, attendance_type, description,
+			departement_id, contract_id, max_clock_in_time, max_clock_out_time, is_on_time, late_minutes, early_minutes,
+			created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, employeeID, attendanceID, t, attendanceType, eval.Description, eval.DepartementID, nullIfZero(eval.ContractID),
+		nullIfEmpty(eval.MaxClockInTime), nullIfEmpty(eval.MaxClockOutTime), eval.IsOnTime, eval.LateMinutes, eval.EarlyMinutes,
+		now, now)
+}
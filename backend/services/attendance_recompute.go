@@ -0,0 +1,127 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// storedPunch is an attendance history row with the evaluation stored on it
+type storedPunch struct {
+	id             int64
+	employeeID     string
+	date           time.Time
+	attendanceType int
+	eval           PunchEvaluation
+}
+
+// RecomputeAttendance re-evaluates in tx the attendance history of
+// employeeID, or of everyone when it is empty, recorded from from up to but
+// not including to; a zero time leaves that side open. Each punch is
+// evaluated against the working-time contract the employee worked under that
+// day or, without one, the current schedule of the department they belonged
+// to, replacing the stored schedule, status and description. It returns the
+// number of rows that changed.
+func RecomputeAttendance(tx *sql.Tx, employeeID string, from, to, now time.Time) (int, error) {
+	query := `
+		SELECT id, employee_id, date_attendance, attendance_type, description,
+			COALESCE(departement_id, 0), COALESCE(contract_id, 0), max_clock_in_time, max_clock_out_time,
+			is_on_time, late_minutes, early_minutes
+		FROM attendance_history
+		WHERE 1=1
+	`
+	var args []interface{}
+	if employeeID != "" {
+		query += " AND employee_id = ?"
+		args = append(args, employeeID)
+	}
+	if !from.IsZero() {
+		query += " AND date_attendance >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		query += " AND date_attendance < ?"
+		args = append(args, to)
+	}
+	query += " ORDER BY id"
+
+	punches, err := storedPunches(tx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	spans, err := assignmentSpans(tx, employeeID)
+	if err != nil {
+		return 0, err
+	}
+	contracts, err := contractSpans(tx, employeeID)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, punch := range punches {
+		dept, ok := departmentAt(spans[punch.employeeID], punch.date)
+		if !ok {
+			continue
+		}
+		eval := EvaluatePunch(punch.attendanceType, punch.date, dept, contractAt(contracts[punch.employeeID], punch.date))
+		if eval == punch.eval {
+			continue
+		}
+		_, err := tx.Exec(`
+			UPDATE attendance_history
+			SET departement_id = ?, contract_id = ?, max_clock_in_time = ?, max_clock_out_time = ?, is_on_time = ?,
+				late_minutes = ?, early_minutes = ?, description = ?, updated_at = ?
+			WHERE id = ?
+		`, eval.DepartementID, nullIfZero(eval.ContractID), nullIfEmpty(eval.MaxClockInTime), nullIfEmpty(eval.MaxClockOutTime),
+			eval.IsOnTime, eval.LateMinutes, eval.EarlyMinutes, eval.Description, now, punch.id)
+		if err != nil {
+			return changed, fmt.Errorf("failed to update attendance history %d: %v", punch.id, err)
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+// EvaluatePunchOn evaluates a punch of attendanceType by employeeID at t
+// against the contract or department schedule that applied to them then
+func EvaluatePunchOn(tx *sql.Tx, employeeID string, attendanceType int, t time.Time) (PunchEvaluation, error) {
+	dept, err := DepartmentOn(tx, employeeID, t)
+	if err != nil {
+		return PunchEvaluation{}, err
+	}
+	contract, err := ContractOn(tx, employeeID, t)
+	if err != nil {
+		return PunchEvaluation{}, err
+	}
+	return EvaluatePunch(attendanceType, t, dept, contract), nil
+}
+
+// storedPunches reads the rows selected by query, closing them before the
+// caller writes in the same transaction
+func storedPunches(tx *sql.Tx, query string, args ...interface{}) ([]storedPunch, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attendance history: %v", err)
+	}
+	defer rows.Close()
+
+	var punches []storedPunch
+	for rows.Next() {
+		var punch storedPunch
+		var description, maxClockIn, maxClockOut sql.NullString
+		err := rows.Scan(&punch.id, &punch.employeeID, &punch.date, &punch.attendanceType, &description,
+			&punch.eval.DepartementID, &punch.eval.ContractID, &maxClockIn, &maxClockOut,
+			&punch.eval.IsOnTime, &punch.eval.LateMinutes, &punch.eval.EarlyMinutes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attendance history: %v", err)
+		}
+		punch.eval.Description = description.String
+		punch.eval.MaxClockInTime = maxClockIn.String
+		punch.eval.MaxClockOutTime = maxClockOut.String
+		punches = append(punches, punch)
+	}
+
+	return punches, rows.Err()
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePunch(t *testing.T) {
+	dept := models.Department{ID: 3, MaxClockInTime: "09:00:00", MaxClockOutTime: "17:00:00"}
+
+	late := EvaluatePunch(1, time.Date(2024, 1, 2, 9, 10, 30, 0, time.Local), dept, nil)
+	assert.Equal(t, PunchEvaluation{
+		DepartementID: 3, MaxClockInTime: "09:00:00", MaxClockOutTime: "17:00:00",
+		IsOnTime: false, LateMinutes: 11, Description: "Clock In (Late)",
+	}, late)
+
+	onTime := EvaluatePunch(1, time.Date(2024, 1, 2, 9, 0, 0, 500, time.Local), dept, nil)
+	assert.True(t, onTime.IsOnTime)
+	assert.Equal(t, 0, onTime.LateMinutes)
+
+	early := EvaluatePunch(2, time.Date(2024, 1, 2, 16, 0, 0, 0, time.Local), dept, nil)
+	assert.False(t, early.IsOnTime)
+	assert.Equal(t, 60, early.EarlyMinutes)
+	assert.Equal(t, "Clock Out (Early)", early.Description)
+}
+
+func TestRecomputeAttendance(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, created_at, updated_at)
+		VALUES ('A', 1, 'Employee A', '', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+		VALUES ('A', 1, '2023-06-01', ?)`, created)
+	assert.NoError(t, err)
+
+	clockIns := []time.Time{
+		time.Date(2024, 1, 2, 8, 50, 0, 0, time.Local),
+		time.Date(2024, 1, 3, 8, 50, 0, 0, time.Local),
+	}
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	for i, clockIn := range clockIns {
+		attendanceID := []string{"att-1", "att-2"}[i]
+		_, err = tx.Exec(`INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at)
+			VALUES ('A', ?, ?, ?, ?)`, attendanceID, clockIn, clockIn, clockIn)
+		assert.NoError(t, err)
+		eval, err := EvaluatePunchOn(tx, "A", 1, clockIn)
+		assert.NoError(t, err)
+		_, err = RecordPunch(db, tx, "A", attendanceID, 1, clockIn, eval, clockIn)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tx.Commit())
+
+	// Moving the deadline earlier does not touch what was recorded
+	_, err = db.Exec("UPDATE departement SET max_clock_in_time = '08:30:00' WHERE id = 1")
+	assert.NoError(t, err)
+	logs, err := QueryAttendanceLogs(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-01-31"})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 2) {
+		assert.True(t, logs[0].IsOnTime)
+		assert.Equal(t, "09:00:00", logs[0].MaxClockInTime)
+	}
+
+	// Until it is recomputed, here for the second day only
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	changed, err := RecomputeAttendance(tx, "", clockIns[1], time.Time{}, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, 1, changed)
+
+	logs, err = QueryAttendanceLogs(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-01-31"})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 2) {
+		// Newest first
+		assert.False(t, logs[0].IsOnTime)
+		assert.Equal(t, 20, logs[0].LateMinutes)
+		assert.Equal(t, "08:30:00", logs[0].MaxClockInTime)
+		assert.Equal(t, "Clock In (Late)", logs[0].Description)
+		assert.True(t, logs[1].IsOnTime)
+	}
+
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	changed, err = RecomputeAttendance(tx, "A", time.Time{}, time.Time{}, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, 1, changed, "rows already up to date are left alone")
+}
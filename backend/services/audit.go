@@ -0,0 +1,289 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// ErrInvalidAuditFilters is returned when audit log filters fail validation
+var ErrInvalidAuditFilters = errors.New("invalid audit filters")
+
+// errStopAuditScan ends a scan of the audit log early
+var errStopAuditScan = errors.New("stop audit scan")
+
+const auditColumns = `id, occurred_at, actor, action, entity_type, entity_id, before_data, after_data,
+	ip_address, request_id, prev_hash, hash`
+
+// AuditLog is the append-only record of data changes. Entries are chained by
+// hash, and the head of the chain is kept in audit_chain so removing entries
+// from the end is detected as well.
+type AuditLog struct {
+	db *sql.DB
+}
+
+// NewAuditLog creates a new audit log
+func NewAuditLog(db *sql.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// Append records change in tx, so the entry exists exactly when the change
+// was committed. The chain head stays locked until tx ends, which chains
+// concurrent changes one after the other.
+func (a *AuditLog) Append(tx *sql.Tx, actor models.AuditActor, change models.AuditChange) error {
+	before, err := encodeAuditData(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encodeAuditData(change.After)
+	if err != nil {
+		return err
+	}
+
+	var entries int
+	var prevHash string
+	err = tx.QueryRow("SELECT entries, last_hash FROM audit_chain WHERE id = 1"+database.ForUpdate(a.db)).
+		Scan(&entries, &prevHash)
+	if err != nil {
+		return fmt.Errorf("failed to read audit chain: %v", err)
+	}
+
+	entry := models.AuditEntry{
+		OccurredAt: time.Now().UTC().Truncate(time.Second),
+		Actor:      actor.Actor,
+		Action:     change.Action,
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		Before:     before,
+		After:      after,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		PrevHash:   prevHash,
+	}
+	entry.Hash = AuditEntryHash(entry)
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_log (occurred_at, actor, action, entity_type, entity_id, before_data, after_data,
+			ip_address, request_id, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.OccurredAt, entry.Actor, entry.Action, entry.EntityType, entry.EntityID,
+		nullableJSON(entry.Before), nullableJSON(entry.After), entry.IP, entry.RequestID, entry.PrevHash, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE audit_chain SET entries = ?, last_hash = ? WHERE id = 1", entries+1, entry.Hash); err != nil {
+		return fmt.Errorf("failed to advance audit chain: %v", err)
+	}
+	return nil
+}
+
+// AuditEntryHash returns the hex SHA-256 of entry's content and the hash of
+// the entry before it
+func AuditEntryHash(entry models.AuditEntry) string {
+	h := sha256.New()
+	fields := []string{
+		entry.PrevHash,
+		entry.OccurredAt.UTC().Format(time.RFC3339),
+		entry.Actor,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		string(entry.Before),
+		string(entry.After),
+		entry.IP,
+		entry.RequestID,
+	}
+	for _, field := range fields {
+		// Length prefixes keep the boundaries between fields unambiguous
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Query returns the entries matching filter, newest first. A zero limit
+// returns every match. Invalid filters return an error wrapping
+// ErrInvalidAuditFilters.
+func (a *AuditLog) Query(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	err := a.Each(filter, func(entry models.AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Each calls fn for the entries matching filter, newest first, without
+// holding them all in memory. An error from fn stops the scan and is returned.
+func (a *AuditLog) Each(filter models.AuditFilter, fn func(models.AuditEntry) error) error {
+	where, args, err := auditConditions(filter)
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	return a.scan(query, args, fn)
+}
+
+// Verify walks the whole chain, oldest first, and reports the first entry
+// whose hash or link to the previous entry does not match
+func (a *AuditLog) Verify() (*models.AuditVerification, error) {
+	result := &models.AuditVerification{Valid: true}
+	prevHash := ""
+
+	err := a.scan("SELECT "+auditColumns+" FROM audit_log ORDER BY id", nil, func(entry models.AuditEntry) error {
+		result.Entries++
+		switch {
+		case entry.PrevHash != prevHash:
+			result.Reason = "entry does not link to the entry before it"
+		case AuditEntryHash(entry) != entry.Hash:
+			result.Reason = "entry content does not match its hash"
+		default:
+			prevHash = entry.Hash
+			return nil
+		}
+		result.Valid = false
+		result.BrokenAt = entry.ID
+		return errStopAuditScan
+	})
+	if err != nil && !errors.Is(err, errStopAuditScan) {
+		return nil, err
+	}
+	result.LastHash = prevHash
+	if !result.Valid {
+		return result, nil
+	}
+
+	var entries int
+	var lastHash string
+	if err := a.db.QueryRow("SELECT entries, last_hash FROM audit_chain WHERE id = 1").Scan(&entries, &lastHash); err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %v", err)
+	}
+	if entries != result.Entries || lastHash != prevHash {
+		result.Valid = false
+		result.Reason = fmt.Sprintf("chain head records %d entries ending in %s", entries, lastHash)
+	}
+	return result, nil
+}
+
+// scan runs query and calls fn for each audit entry it returns
+func (a *AuditLog) scan(query string, args []interface{}, fn func(models.AuditEntry) error) error {
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch audit log: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after sql.NullString
+		err := rows.Scan(&entry.ID, &entry.OccurredAt, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.IP, &entry.RequestID, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to read audit entry: %v", err)
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ValidateAuditFilter checks filter the way Query does, returning an error
+// wrapping ErrInvalidAuditFilters
+func ValidateAuditFilter(filter models.AuditFilter) error {
+	_, _, err := auditConditions(filter)
+	return err
+}
+
+// auditConditions translates filter into WHERE conditions and their arguments
+func auditConditions(filter models.AuditFilter) ([]string, []interface{}, error) {
+	var where []string
+	var args []interface{}
+	for _, condition := range []struct{ column, value string }{
+		{"actor", filter.Actor},
+		{"action", filter.Action},
+		{"entity_type", filter.EntityType},
+		{"entity_id", filter.EntityID},
+	} {
+		if condition.value != "" {
+			where = append(where, condition.column+" = ?")
+			args = append(args, condition.value)
+		}
+	}
+
+	var from, to time.Time
+	if filter.From != "" {
+		day, err := parseDay(filter.From)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidAuditFilters)
+		}
+		from, _ = DayBounds(day)
+		where = append(where, "occurred_at >= ?")
+		args = append(args, from)
+	}
+	if filter.To != "" {
+		day, err := parseDay(filter.To)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidAuditFilters)
+		}
+		_, to = DayBounds(day)
+		where = append(where, "occurred_at < ?")
+		args = append(args, to)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, nil, fmt.Errorf("%w: from must not be after to", ErrInvalidAuditFilters)
+	}
+
+	return where, args, nil
+}
+
+// encodeAuditData encodes an entity snapshot, keeping an absent one as nil
+func encodeAuditData(data interface{}) (json.RawMessage, error) {
+	if data == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit data: %v", err)
+	}
+	if string(encoded) == "null" {
+		return nil, nil
+	}
+	return encoded, nil
+}
+
+// nullableJSON stores an absent snapshot as NULL
+func nullableJSON(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// appendAudit records each change in a transaction of its own
+func appendAudit(t *testing.T, db *sql.DB, auditLog *AuditLog, changes ...models.AuditChange) {
+	actor := models.AuditActor{Actor: "alice", IP: "192.0.2.1", RequestID: "req-1"}
+	for _, change := range changes {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := auditLog.Append(tx, actor, change); err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAuditLogDetectsTampering(t *testing.T) {
+	db := openServicesTestDB(t)
+	auditLog := NewAuditLog(db)
+
+	department := models.Department{ID: 1, DepartementName: "IT", MaxClockInTime: "09:00:00", MaxClockOutTime: "17:00:00"}
+	moved := department
+	moved.MaxClockInTime = "09:30:00"
+	appendAudit(t, db, auditLog,
+		models.AuditChange{Action: models.AuditActionCreate, EntityType: models.AuditEntityDepartment, EntityID: "1", After: department},
+		models.AuditChange{Action: models.AuditActionUpdate, EntityType: models.AuditEntityDepartment, EntityID: "1", Before: department, After: moved},
+		models.AuditChange{Action: models.AuditActionDelete, EntityType: models.AuditEntityDepartment, EntityID: "1", Before: moved},
+	)
+
+	entries, err := auditLog.Query(models.AuditFilter{})
+	if !assert.NoError(t, err) || !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, entries[1].Hash, entries[0].PrevHash)
+	assert.Equal(t, entries[2].Hash, entries[1].PrevHash)
+	assert.Empty(t, entries[2].PrevHash)
+	assert.Nil(t, entries[2].Before)
+	assert.Nil(t, entries[0].After)
+
+	result, err := auditLog.Verify()
+	assert.NoError(t, err)
+	assert.Equal(t, &models.AuditVerification{Valid: true, Entries: 3, LastHash: entries[0].Hash}, result)
+
+	// Rewriting history breaks the entry that was changed
+	_, err = db.Exec("UPDATE audit_log SET after_data = ? WHERE id = ?", `{"max_clock_in_time":"08:00:00"}`, entries[1].ID)
+	assert.NoError(t, err)
+	result, err = auditLog.Verify()
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[1].ID, result.BrokenAt)
+
+	// Removing it breaks the link of the entry after it
+	_, err = db.Exec("DELETE FROM audit_log WHERE id = ?", entries[1].ID)
+	assert.NoError(t, err)
+	result, err = auditLog.Verify()
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[0].ID, result.BrokenAt)
+
+	// Removing the newest entries leaves a valid chain that falls short of
+	// the recorded head
+	_, err = db.Exec("DELETE FROM audit_log WHERE id <> ?", entries[2].ID)
+	assert.NoError(t, err)
+	result, err = auditLog.Verify()
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Zero(t, result.BrokenAt)
+	assert.Equal(t, 1, result.Entries)
+}
+
+func TestAuditLogQueryFilters(t *testing.T) {
+	db := openServicesTestDB(t)
+	auditLog := NewAuditLog(db)
+	appendAudit(t, db, auditLog,
+		models.AuditChange{Action: models.AuditActionCreate, EntityType: models.AuditEntityEmployee, EntityID: "1", After: models.Employee{ID: 1}},
+		models.AuditChange{Action: models.AuditActionCreate, EntityType: models.AuditEntityEmployee, EntityID: "2", After: models.Employee{ID: 2}},
+		models.AuditChange{Action: models.AuditActionDelete, EntityType: models.AuditEntityEmployee, EntityID: "1", Before: models.Employee{ID: 1}},
+	)
+
+	entries, err := auditLog.Query(models.AuditFilter{EntityID: "1"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = auditLog.Query(models.AuditFilter{Action: models.AuditActionCreate, Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "1", entries[0].EntityID)
+	}
+
+	_, err = auditLog.Query(models.AuditFilter{From: "2024-02-01", To: "2024-01-01"})
+	assert.ErrorIs(t, err, ErrInvalidAuditFilters)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBlobNotFound is returned for a key the blob store does not hold
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores uploaded files under slash-separated keys
+type BlobStore interface {
+	Put(key string, data io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalBlobStore keeps blobs as files below a directory
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore creates a blob store writing below dir, which is created
+// on the first write
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{dir: dir}
+}
+
+// Put stores data under key, replacing any blob already there. The file is
+// written under a temporary name first so readers never see half of it.
+func (s *LocalBlobStore) Put(key string, data io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %v", err)
+	}
+	return nil
+}
+
+// Open returns the blob stored under key
+func (s *LocalBlobStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+// Delete removes the blob stored under key; a missing blob is not an error
+func (s *LocalBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %v", err)
+	}
+	return nil
+}
+
+// path maps key to a file below the store's directory, refusing keys that
+// would escape it
+func (s *LocalBlobStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"attendance-system/models"
@@ -41,6 +42,8 @@ func (s *CSVExportService) ExportAttendanceLogs(logs []models.AttendanceLog, fil
 		"Type",
 		"Description",
 		"Status",
+		"Minutes Late",
+		"Minutes Early",
 		"Max Clock In",
 		"Max Clock Out",
 	}
@@ -60,6 +63,8 @@ func (s *CSVExportService) ExportAttendanceLogs(logs []models.AttendanceLog, fil
 			s.getAttendanceTypeText(log.AttendanceType),
 			log.Description,
 			s.getStatusText(log.IsOnTime),
+			fmt.Sprintf("%d", log.LateMinutes),
+			fmt.Sprintf("%d", log.EarlyMinutes),
 			log.MaxClockInTime,
 			log.MaxClockOutTime,
 		}
@@ -71,8 +76,9 @@ func (s *CSVExportService) ExportAttendanceLogs(logs []models.AttendanceLog, fil
 	return nil
 }
 
-// ExportEmployeeList exports employee list to CSV format
-func (s *CSVExportService) ExportEmployeeList(employees []models.EmployeeWithDepartment, filename string) error {
+// ExportEmployeeList exports employee list to CSV format, with a column for
+// each of fields after the standard ones
+func (s *CSVExportService) ExportEmployeeList(employees []models.EmployeeWithDepartment, fields []models.CustomField, filename string) error {
 	// Create file
 	file, err := os.Create(filename)
 	if err != nil {
@@ -91,10 +97,21 @@ func (s *CSVExportService) ExportEmployeeList(employees []models.EmployeeWithDep
 		"Name",
 		"Department",
 		"Address",
+		"Email",
+		"Phone",
+		"Job Title",
+		"Employment Type",
+		"Work Location",
+		"Employment Status",
+		"Hire Date",
+		"Termination Date",
 		"Max Clock In",
 		"Max Clock Out",
 		"Created At",
 	}
+	for _, field := range fields {
+		header = append(header, field.Label)
+	}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write header: %v", err)
 	}
@@ -107,10 +124,21 @@ func (s *CSVExportService) ExportEmployeeList(employees []models.EmployeeWithDep
 			emp.Name,
 			emp.Department.DepartementName,
 			emp.Address,
+			emp.Email,
+			emp.Phone,
+			emp.JobTitle,
+			emp.EmploymentType,
+			emp.WorkLocation,
+			emp.EmploymentStatus,
+			optionalString(emp.HireDate),
+			optionalString(emp.TerminationDate),
 			emp.Department.MaxClockInTime,
 			emp.Department.MaxClockOutTime,
 			emp.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
+		for _, field := range fields {
+			row = append(row, customValueCSV(emp.CustomFields[field.Name]))
+		}
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write row: %v", err)
 		}
@@ -184,3 +212,93 @@ func (s *CSVExportService) getStatusText(isOnTime bool) string {
 	}
 	return "Late/Early"
 }
+
+// ExportAbsences exports an absence report to CSV format
+func (s *CSVExportService) ExportAbsences(absences []models.Absence, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"No", "Date", "Employee ID", "Employee Name", "Department"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %v", err)
+	}
+
+	for i, absence := range absences {
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			absence.Date,
+			absence.EmployeeID,
+			absence.EmployeeName,
+			absence.DepartmentName,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ExportAttendanceSummary exports per-employee attendance totals to CSV format
+func (s *CSVExportService) ExportAttendanceSummary(summaries []models.AttendanceSummary, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"No", "Employee ID", "Employee Name", "Department", "Days Present", "Late Arrivals",
+		"Early Leaves", "Late Minutes", "Early Minutes"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %v", err)
+	}
+
+	for i, summary := range summaries {
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			summary.EmployeeID,
+			summary.EmployeeName,
+			summary.DepartmentName,
+			strconv.Itoa(summary.DaysPresent),
+			strconv.Itoa(summary.LateArrivals),
+			strconv.Itoa(summary.EarlyLeaves),
+			strconv.Itoa(summary.LateMinutes),
+			strconv.Itoa(summary.EarlyMinutes),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// customValueCSV formats a custom field value for a CSV cell, leaving missing
+// values empty
+func customValueCSV(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// optionalString returns the value of s, or "" when it is nil
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
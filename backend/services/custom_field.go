@@ -0,0 +1,296 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"attendance-system/models"
+)
+
+// maxCustomTextLength is the longest text custom field value, in characters
+const maxCustomTextLength = 1000
+
+// customFieldNamePattern is the form of a custom field name
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// CustomFieldColumns lists the columns read by ScanCustomField
+const CustomFieldColumns = "id, name, label, field_type, options, created_at, updated_at"
+
+// ScanCustomField reads a row selected with CustomFieldColumns
+func ScanCustomField(row rowScanner) (models.CustomField, error) {
+	var field models.CustomField
+	var options sql.NullString
+	err := row.Scan(&field.ID, &field.Name, &field.Label, &field.FieldType, &options, &field.CreatedAt, &field.UpdatedAt)
+	if err != nil {
+		return field, err
+	}
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &field.Options); err != nil {
+			return field, fmt.Errorf("invalid options of custom field %s: %v", field.Name, err)
+		}
+	}
+	return field, nil
+}
+
+// CustomFields lists the defined custom fields ordered by name
+func CustomFields(q queryer) ([]models.CustomField, error) {
+	rows, err := q.Query("SELECT " + CustomFieldColumns + " FROM custom_field ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch custom fields: %v", err)
+	}
+	defer rows.Close()
+
+	fields := []models.CustomField{}
+	for rows.Next() {
+		field, err := ScanCustomField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read custom field: %v", err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, rows.Err()
+}
+
+// CustomFieldOptions encodes the options of a select field for the options
+// column, keeping nil for other types
+func CustomFieldOptions(field models.CustomField) (*string, error) {
+	if field.FieldType != models.CustomFieldSelect {
+		return nil, nil
+	}
+	data, err := json.Marshal(field.Options)
+	if err != nil {
+		return nil, err
+	}
+	options := string(data)
+	return &options, nil
+}
+
+// ValidateCustomField checks a custom field definition: the form of its name
+// and that only select fields, and all of them, list distinct, non-blank
+// options. It returns the name of the offending field on failure.
+func ValidateCustomField(field models.CustomField) (string, error) {
+	if !customFieldNamePattern.MatchString(field.Name) {
+		return "name", fmt.Errorf("must be lowercase letters, digits and underscores, starting with a letter")
+	}
+	if field.FieldType != models.CustomFieldSelect {
+		if len(field.Options) > 0 {
+			return "options", fmt.Errorf("are only allowed for select fields")
+		}
+		return "", nil
+	}
+	if len(field.Options) == 0 {
+		return "options", fmt.Errorf("are required for select fields")
+	}
+	seen := make(map[string]bool)
+	for _, option := range field.Options {
+		if strings.TrimSpace(option) == "" {
+			return "options", fmt.Errorf("must not be blank")
+		}
+		if seen[option] {
+			return "options", fmt.Errorf("must not repeat %q", option)
+		}
+		seen[option] = true
+	}
+	return "", nil
+}
+
+// CustomValuesInUse lists the distinct values employees have for a custom
+// field
+func CustomValuesInUse(q queryer, fieldID int) ([]string, error) {
+	rows, err := q.Query("SELECT DISTINCT value FROM employee_custom_value WHERE custom_field_id = ?", fieldID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch custom field values: %v", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to read custom field value: %v", err)
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// ValidateCustomValues checks values, keyed by custom field name, against the
+// types of fields and returns them as stored, keyed by custom field ID. A nil
+// value removes the field from the employee. Text must be a string, number a
+// number, date a YYYY-MM-DD string, boolean true or false and select one of
+// its options. It returns the offending key, as custom_fields.<name>, on
+// failure.
+func ValidateCustomValues(fields []models.CustomField, values map[string]interface{}) (map[int]*string, string, error) {
+	byName := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	stored := make(map[int]*string, len(values))
+	for name, value := range values {
+		key := "custom_fields." + name
+		field, ok := byName[name]
+		if !ok {
+			return nil, key, fmt.Errorf("is not a custom field")
+		}
+		if value == nil {
+			stored[field.ID] = nil
+			continue
+		}
+		text, err := customValueText(field, value)
+		if err != nil {
+			return nil, key, err
+		}
+		stored[field.ID] = &text
+	}
+	return stored, "", nil
+}
+
+// customValueText converts a JSON value of field to the text stored for it
+func customValueText(field models.CustomField, value interface{}) (string, error) {
+	switch field.FieldType {
+	case models.CustomFieldNumber:
+		if n, ok := value.(float64); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("must be a number")
+	case models.CustomFieldBoolean:
+		if b, ok := value.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("must be true or false")
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("must be a string")
+	}
+	switch field.FieldType {
+	case models.CustomFieldDate:
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "", fmt.Errorf("must be a YYYY-MM-DD date")
+		}
+	case models.CustomFieldSelect:
+		if !slices.Contains(field.Options, s) {
+			return "", fmt.Errorf("must be one of %s", strings.Join(field.Options, ", "))
+		}
+	default:
+		if utf8.RuneCountInString(s) > maxCustomTextLength {
+			return "", fmt.Errorf("must be at most %d characters", maxCustomTextLength)
+		}
+	}
+	return s, nil
+}
+
+// customValue converts a stored value back to the JSON type of fieldType
+func customValue(fieldType, text string) interface{} {
+	switch fieldType {
+	case models.CustomFieldNumber:
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n
+		}
+	case models.CustomFieldBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
+
+// SaveCustomValues writes in tx the custom field values of employeeID
+// returned by ValidateCustomValues, leaving their other fields alone
+func SaveCustomValues(tx *sql.Tx, employeeID string, values map[int]*string) error {
+	for fieldID, value := range values {
+		_, err := tx.Exec("DELETE FROM employee_custom_value WHERE employee_id = ? AND custom_field_id = ?", employeeID, fieldID)
+		if err != nil {
+			return fmt.Errorf("failed to clear custom field %d: %v", fieldID, err)
+		}
+		if value == nil {
+			continue
+		}
+		_, err = tx.Exec("INSERT INTO employee_custom_value (employee_id, custom_field_id, value) VALUES (?, ?, ?)",
+			employeeID, fieldID, *value)
+		if err != nil {
+			return fmt.Errorf("failed to save custom field %d: %v", fieldID, err)
+		}
+	}
+	return nil
+}
+
+// CustomValues returns the custom field values of employeeIDs, or of every
+// employee when none are given, keyed by employee_id and then field name
+func CustomValues(q queryer, employeeIDs ...string) (map[string]map[string]interface{}, error) {
+	query := `
+		SELECT v.employee_id, f.name, f.field_type, v.value
+		FROM employee_custom_value v
+		JOIN custom_field f ON f.id = v.custom_field_id
+	`
+	args := make([]interface{}, len(employeeIDs))
+	for i, employeeID := range employeeIDs {
+		args[i] = employeeID
+	}
+	if len(employeeIDs) > 0 {
+		query += " WHERE v.employee_id IN (?" + strings.Repeat(", ?", len(employeeIDs)-1) + ")"
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch custom field values: %v", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]map[string]interface{})
+	for rows.Next() {
+		var employeeID, name, fieldType, text string
+		if err := rows.Scan(&employeeID, &name, &fieldType, &text); err != nil {
+			return nil, fmt.Errorf("failed to read custom field value: %v", err)
+		}
+		if values[employeeID] == nil {
+			values[employeeID] = make(map[string]interface{})
+		}
+		values[employeeID][name] = customValue(fieldType, text)
+	}
+	return values, rows.Err()
+}
+
+// EmployeeCustomFields returns the custom field values of one employee by
+// field name, empty rather than nil when they have none
+func EmployeeCustomFields(q queryer, employeeID string) (map[string]interface{}, error) {
+	values, err := CustomValues(q, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if values[employeeID] == nil {
+		return map[string]interface{}{}, nil
+	}
+	return values[employeeID], nil
+}
+
+// WithCustomFields fills in the custom field values of employees
+func WithCustomFields(q queryer, employees []models.EmployeeWithDepartment) error {
+	if len(employees) == 0 {
+		return nil
+	}
+	employeeIDs := make([]string, len(employees))
+	for i, emp := range employees {
+		employeeIDs[i] = emp.EmployeeID
+	}
+	values, err := CustomValues(q, employeeIDs...)
+	if err != nil {
+		return err
+	}
+	for i := range employees {
+		employees[i].CustomFields = values[employees[i].EmployeeID]
+		if employees[i].CustomFields == nil {
+			employees[i].CustomFields = map[string]interface{}{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCustomValues(t *testing.T) {
+	fields := []models.CustomField{
+		{ID: 1, Name: "shirt_size", FieldType: models.CustomFieldSelect, Options: []string{"S", "M", "L"}},
+		{ID: 2, Name: "badge_number", FieldType: models.CustomFieldNumber},
+		{ID: 3, Name: "start_of_probation", FieldType: models.CustomFieldDate},
+		{ID: 4, Name: "remote", FieldType: models.CustomFieldBoolean},
+		{ID: 5, Name: "notes", FieldType: models.CustomFieldText},
+	}
+
+	stored, _, err := ValidateCustomValues(fields, map[string]interface{}{
+		"shirt_size": "M", "badge_number": 42.5, "start_of_probation": "2024-03-01", "remote": true, "notes": nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "M", *stored[1])
+	assert.Equal(t, "42.5", *stored[2])
+	assert.Equal(t, "2024-03-01", *stored[3])
+	assert.Equal(t, "true", *stored[4])
+	assert.Nil(t, stored[5])
+	assert.Equal(t, 42.5, customValue(models.CustomFieldNumber, *stored[2]))
+	assert.Equal(t, true, customValue(models.CustomFieldBoolean, *stored[4]))
+
+	for name, value := range map[string]interface{}{
+		"shirt_size":         "XXL",
+		"badge_number":       "42",
+		"start_of_probation": "01/03/2024",
+		"remote":             "yes",
+		"notes":              strings.Repeat("x", maxCustomTextLength+1),
+		"unknown":            "x",
+	} {
+		_, field, err := ValidateCustomValues(fields, map[string]interface{}{name: value})
+		assert.Error(t, err, name)
+		assert.Equal(t, "custom_fields."+name, field)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir())
+
+	assert.NoError(t, store.Put("employees/1/photo.png", strings.NewReader("first")))
+	assert.NoError(t, store.Put("employees/1/photo.png", strings.NewReader("second")))
+	blob, err := store.Open("employees/1/photo.png")
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(blob)
+		blob.Close()
+		assert.Equal(t, "second", string(data))
+	}
+
+	assert.NoError(t, store.Delete("employees/1/photo.png"))
+	assert.NoError(t, store.Delete("employees/1/photo.png"))
+	_, err = store.Open("employees/1/photo.png")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	assert.Error(t, store.Put("../outside", strings.NewReader("x")))
+	assert.Error(t, store.Put("/etc/passwd", strings.NewReader("x")))
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// ErrFutureTransfer is returned for a department assignment dated after today
+var ErrFutureTransfer = errors.New("effective_from must not be in the future")
+
+// assignmentSpanQuery selects the department and schedule of each
+// assignment with the times it covers. Callers append conditions.
+const assignmentSpanQuery = `
+	SELECT a.employee_id, d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time, a.starts_at, a.ends_at
+	FROM department_assignment a
+	JOIN departement d ON a.departement_id = d.id
+	WHERE 1=1
+`
+
+// assignmentSpan is the department an employee belonged to between two
+// times; a zero start or end leaves that side open
+type assignmentSpan struct {
+	department models.Department
+	startsAt   sql.NullTime
+	endsAt     sql.NullTime
+}
+
+// covers reports whether t falls within the span
+func (s assignmentSpan) covers(t time.Time) bool {
+	return (!s.startsAt.Valid || !t.Before(s.startsAt.Time)) && (!s.endsAt.Valid || t.Before(s.endsAt.Time))
+}
+
+// AssignDepartment records in tx that employeeID belongs to departmentID from
+// effectiveFrom, a YYYY-MM-DD date no later than today, replacing any
+// assignment starting that same day, and re-evaluates the punches the
+// assignment covers against its department's schedule. It returns all of the
+// employee's assignments, oldest first; the last is the department they are
+// in today, which the caller stores on the employee row.
+func AssignDepartment(db *sql.DB, tx *sql.Tx, employeeID string, departmentID int, effectiveFrom string, now time.Time) ([]models.DepartmentAssignment, error) {
+	day, err := parseDay(effectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("effective_from must be YYYY-MM-DD")
+	}
+	if day.After(now) {
+		return nil, ErrFutureTransfer
+	}
+	return assignDepartment(db, tx, employeeID, departmentID, effectiveFrom, day, now)
+}
+
+// MoveDepartment records in tx that employeeID belongs to departmentID from
+// now on, as AssignDepartment does from today, except that punches made
+// earlier today keep the department they were made in. A move replacing an
+// assignment that starts earlier the same day takes effect from that start.
+func MoveDepartment(db *sql.DB, tx *sql.Tx, employeeID string, departmentID int, now time.Time) ([]models.DepartmentAssignment, error) {
+	return assignDepartment(db, tx, employeeID, departmentID, now.Format("2006-01-02"), now, now)
+}
+
+// assignDepartment records the assignment dated effectiveFrom starting at
+// startsAt, which falls on that day
+func assignDepartment(db *sql.DB, tx *sql.Tx, employeeID string, departmentID int, effectiveFrom string, startsAt, now time.Time) ([]models.DepartmentAssignment, error) {
+	// Locking the employee serialises changes to their assignments
+	var id int
+	err := tx.QueryRow("SELECT id FROM employee WHERE employee_id = ?"+database.ForUpdate(db), employeeID).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock employee: %v", err)
+	}
+
+	assignments, err := queryAssignments(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := false
+	for _, assignment := range assignments {
+		if assignment.EffectiveFrom == effectiveFrom {
+			_, err = tx.Exec("UPDATE department_assignment SET departement_id = ? WHERE id = ?", departmentID, assignment.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update department assignment: %v", err)
+			}
+			replaced = true
+		}
+	}
+	if !replaced {
+		_, err = tx.Exec(`
+			INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+			VALUES (?, ?, ?, ?)
+		`, employeeID, departmentID, effectiveFrom, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record department assignment: %v", err)
+		}
+	}
+
+	assignments, err = queryAssignments(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	starts, err := assignmentStarts(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		if assignment.EffectiveFrom != effectiveFrom {
+			continue
+		}
+		// A replaced assignment keeps the earlier of the two starts
+		if start, ok := starts[assignment.ID]; !ok || startsAt.Before(start) {
+			starts[assignment.ID] = startsAt
+		}
+	}
+	bounds, err := chainAssignments(tx, assignments, starts)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+	for i, assignment := range assignments {
+		if assignment.EffectiveFrom != effectiveFrom {
+			continue
+		}
+		from = bounds[i]
+		if i < len(assignments)-1 {
+			to = bounds[i+1]
+		}
+	}
+	if _, err := RecomputeAttendance(tx, employeeID, from, to, now); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// FirstAssignmentDate is the effective date of a new employee's first
+// assignment: their hire date, or today if that is still to come
+func FirstAssignmentDate(hireDate *string, now time.Time) string {
+	today := now.Format("2006-01-02")
+	if hireDate != nil && *hireDate < today {
+		return *hireDate
+	}
+	return today
+}
+
+// chainAssignments sets the time bounds of an employee's assignments, sorted
+// by date, so each ends where the next starts. The first covers everything
+// before it and the last stays open. The others start at the local midnight
+// of their date, or at their time in starts when that falls later the same
+// day. It returns when each assignment starts, zero for the first.
+func chainAssignments(tx *sql.Tx, assignments []models.DepartmentAssignment, starts map[int]time.Time) ([]time.Time, error) {
+	bounds := make([]time.Time, len(assignments))
+	for i := 1; i < len(assignments); i++ {
+		day, _ := parseDay(assignments[i].EffectiveFrom)
+		bounds[i] = day
+		if start, ok := starts[assignments[i].ID]; ok && start.After(day) && start.Before(day.AddDate(0, 0, 1)) {
+			bounds[i] = start
+		}
+	}
+
+	for i, assignment := range assignments {
+		var startsAt, endsAt interface{}
+		if i > 0 {
+			startsAt = bounds[i]
+		}
+		if i < len(assignments)-1 {
+			endsAt = bounds[i+1]
+		}
+		_, err := tx.Exec("UPDATE department_assignment SET starts_at = ?, ends_at = ? WHERE id = ?", startsAt, endsAt, assignment.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update department assignment: %v", err)
+		}
+	}
+	return bounds, nil
+}
+
+// assignmentStarts returns the stored start of each of an employee's
+// assignments that has one, keyed by ID
+func assignmentStarts(q queryer, employeeID string) (map[int]time.Time, error) {
+	rows, err := q.Query("SELECT id, starts_at FROM department_assignment WHERE employee_id = ? AND starts_at IS NOT NULL", employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch department assignments: %v", err)
+	}
+	defer rows.Close()
+
+	starts := make(map[int]time.Time)
+	for rows.Next() {
+		var id int
+		var startsAt time.Time
+		if err := rows.Scan(&id, &startsAt); err != nil {
+			return nil, fmt.Errorf("failed to read department assignment: %v", err)
+		}
+		starts[id] = startsAt
+	}
+	return starts, rows.Err()
+}
+
+// DepartmentAssignments lists an employee's department assignments, oldest
+// first
+func DepartmentAssignments(db *sql.DB, employeeID string) ([]models.DepartmentAssignment, error) {
+	return queryAssignments(db, employeeID)
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryAssignments reads an employee's assignments oldest first, filling in
+// the last day of each
+func queryAssignments(q queryer, employeeID string) ([]models.DepartmentAssignment, error) {
+	rows, err := q.Query(`
+		SELECT a.id, a.employee_id, a.departement_id, d.departement_name, a.effective_from, a.created_at
+		FROM department_assignment a
+		JOIN departement d ON a.departement_id = d.id
+		WHERE a.employee_id = ?
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch department assignments: %v", err)
+	}
+	defer rows.Close()
+
+	assignments := []models.DepartmentAssignment{}
+	for rows.Next() {
+		var assignment models.DepartmentAssignment
+		var effectiveFrom sql.NullTime
+		err := rows.Scan(&assignment.ID, &assignment.EmployeeID, &assignment.DepartementID, &assignment.DepartmentName,
+			&effectiveFrom, &assignment.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read department assignment: %v", err)
+		}
+		assignment.EffectiveFrom = *dateString(effectiveFrom)
+		assignments = append(assignments, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].EffectiveFrom < assignments[j].EffectiveFrom
+	})
+	for i := 0; i < len(assignments)-1; i++ {
+		next, _ := parseDay(assignments[i+1].EffectiveFrom)
+		until := next.AddDate(0, 0, -1).Format("2006-01-02")
+		assignments[i].EffectiveUntil = &until
+	}
+	return assignments, nil
+}
+
+// DepartmentOn returns the department, with its schedule, that employeeID
+// belonged to at t
+func DepartmentOn(tx *sql.Tx, employeeID string, t time.Time) (models.Department, error) {
+	var dept models.Department
+	var assigned string
+	var startsAt, endsAt sql.NullTime
+	err := tx.QueryRow(assignmentSpanQuery+`
+		AND a.employee_id = ? AND (a.starts_at IS NULL OR a.starts_at <= ?) AND (a.ends_at IS NULL OR a.ends_at > ?)
+	`, employeeID, t, t).Scan(&assigned, &dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime,
+		&startsAt, &endsAt)
+	if err != nil {
+		return dept, fmt.Errorf("failed to fetch department of %s on %s: %v", employeeID, t.Format("2006-01-02"), err)
+	}
+	return dept, nil
+}
+
+// assignmentSpans loads the assignments of employeeID, or of everyone when it
+// is empty, keyed by employee ID
+func assignmentSpans(q queryer, employeeID string) (map[string][]assignmentSpan, error) {
+	query := assignmentSpanQuery
+	var args []interface{}
+	if employeeID != "" {
+		query += " AND a.employee_id = ?"
+		args = append(args, employeeID)
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch department assignments: %v", err)
+	}
+	defer rows.Close()
+
+	spans := make(map[string][]assignmentSpan)
+	for rows.Next() {
+		var employeeID string
+		var span assignmentSpan
+		err := rows.Scan(&employeeID, &span.department.ID, &span.department.DepartementName,
+			&span.department.MaxClockInTime, &span.department.MaxClockOutTime, &span.startsAt, &span.endsAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read department assignment: %v", err)
+		}
+		spans[employeeID] = append(spans[employeeID], span)
+	}
+
+	return spans, rows.Err()
+}
+
+// departmentAt returns the department whose span covers t
+func departmentAt(spans []assignmentSpan, t time.Time) (models.Department, bool) {
+	for _, span := range spans {
+		if span.covers(t) {
+			return span.department, true
+		}
+	}
+	return models.Department{}, false
+}
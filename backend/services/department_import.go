@@ -0,0 +1,227 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// Column names shared with CSVExportService.ExportDepartmentList
+const (
+	departmentColumnName     = "department name"
+	departmentColumnClockIn  = "max clock in time"
+	departmentColumnClockOut = "max clock out time"
+)
+
+// DepartmentImportService validates and upserts departments in bulk
+type DepartmentImportService struct {
+	db *sql.DB
+}
+
+// NewDepartmentImportService creates a new department import service
+func NewDepartmentImportService(db *sql.DB) *DepartmentImportService {
+	return &DepartmentImportService{db: db}
+}
+
+// ValidateSchedule checks that both times use HH:MM:SS and that clock-in is
+// before clock-out. It returns the name of the offending field on failure.
+func ValidateSchedule(maxClockIn, maxClockOut string) (string, error) {
+	clockIn, err := time.Parse("15:04:05", maxClockIn)
+	if err != nil {
+		return "max_clock_in_time", fmt.Errorf("must be HH:MM:SS")
+	}
+	clockOut, err := time.Parse("15:04:05", maxClockOut)
+	if err != nil {
+		return "max_clock_out_time", fmt.Errorf("must be HH:MM:SS")
+	}
+	if !clockIn.Before(clockOut) {
+		return "max_clock_out_time", fmt.Errorf("must be after max_clock_in_time")
+	}
+	return "", nil
+}
+
+// Import validates every row, matching departments by name, and builds a diff
+// of what will change. In commit mode the changes are applied in a single
+// transaction and recorded in the audit log on behalf of actor; nothing is
+// written if any row fails validation.
+func (s *DepartmentImportService) Import(rows [][]string, mode string, actor models.AuditActor) (*models.ImportReport, error) {
+	report := &models.ImportReport{
+		Mode:    mode,
+		Errors:  []models.ImportRowError{},
+		Changes: []models.ImportChange{},
+	}
+
+	if err := s.validate(rows, report); err != nil {
+		return nil, err
+	}
+
+	if mode != models.ImportModeCommit || report.ErrorRows > 0 {
+		return report, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	auditLog := NewAuditLog(s.db)
+	for _, change := range report.Changes {
+		after := change.After.(models.Department)
+		audit := models.AuditChange{EntityType: models.AuditEntityDepartment, Before: change.Before}
+		switch change.Action {
+		case models.ImportActionCreate:
+			var id int64
+			id, err = database.TxInsertReturningID(s.db, tx, `
+				INSERT INTO departement (departement_name, max_clock_in_time, max_clock_out_time)
+				VALUES (?, ?, ?)
+			`, after.DepartementName, after.MaxClockInTime, after.MaxClockOutTime)
+			after.ID = int(id)
+			audit.Action = models.AuditActionCreate
+		case models.ImportActionUpdate:
+			_, err = tx.Exec(`
+				UPDATE departement
+				SET max_clock_in_time = ?, max_clock_out_time = ?
+				WHERE id = ?
+			`, after.MaxClockInTime, after.MaxClockOutTime, after.ID)
+			audit.Action = models.AuditActionUpdate
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply row %d: %v", change.Row, err)
+		}
+
+		audit.EntityID = strconv.Itoa(after.ID)
+		audit.After = after
+		if err := auditLog.Append(tx, actor, audit); err != nil {
+			return nil, fmt.Errorf("failed to audit row %d: %v", change.Row, err)
+		}
+		report.Imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %v", err)
+	}
+
+	return report, nil
+}
+
+// validate checks each data row and records errors and changes on the report
+func (s *DepartmentImportService) validate(rows [][]string, report *models.ImportReport) error {
+	if len(rows) == 0 {
+		report.Errors = append(report.Errors, models.ImportRowError{Row: 1, Message: "file is empty"})
+		report.ErrorRows = 1
+		return nil
+	}
+
+	index := headerIndex(rows[0])
+	for _, column := range []string{departmentColumnName, departmentColumnClockIn, departmentColumnClockOut} {
+		if _, ok := index[column]; !ok {
+			report.Errors = append(report.Errors, models.ImportRowError{
+				Row:     1,
+				Field:   column,
+				Message: "missing column",
+			})
+		}
+	}
+	if len(report.Errors) > 0 {
+		report.ErrorRows = 1
+		return nil
+	}
+
+	existing, err := s.departmentsByName()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int)
+	for i, values := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(values) {
+			continue
+		}
+		report.TotalRows++
+
+		after := models.Department{
+			DepartementName: cell(values, index, departmentColumnName),
+			MaxClockInTime:  cell(values, index, departmentColumnClockIn),
+			MaxClockOutTime: cell(values, index, departmentColumnClockOut),
+		}
+		key := strings.ToLower(after.DepartementName)
+
+		var rowErrors []models.ImportRowError
+		if after.DepartementName == "" {
+			rowErrors = append(rowErrors, models.ImportRowError{
+				Row: rowNumber, Field: departmentColumnName, Message: "required",
+			})
+		} else if first, ok := seen[key]; ok {
+			rowErrors = append(rowErrors, models.ImportRowError{
+				Row: rowNumber, Field: departmentColumnName, Value: after.DepartementName,
+				Message: fmt.Sprintf("duplicate of row %d", first),
+			})
+		} else {
+			seen[key] = rowNumber
+		}
+
+		if field, err := ValidateSchedule(after.MaxClockInTime, after.MaxClockOutTime); err != nil {
+			column, value := departmentColumnClockIn, after.MaxClockInTime
+			if field == "max_clock_out_time" {
+				column, value = departmentColumnClockOut, after.MaxClockOutTime
+			}
+			rowErrors = append(rowErrors, models.ImportRowError{
+				Row: rowNumber, Field: column, Value: value, Message: err.Error(),
+			})
+		}
+
+		if len(rowErrors) > 0 {
+			report.ErrorRows++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+		report.ValidRows++
+
+		change := models.ImportChange{Row: rowNumber, Action: models.ImportActionCreate, After: after}
+		if before, ok := existing[key]; ok {
+			after.ID = before.ID
+			after.DepartementName = before.DepartementName
+			change.After = after
+			change.Before = before
+			change.Action = models.ImportActionUpdate
+			if before.MaxClockInTime == after.MaxClockInTime && before.MaxClockOutTime == after.MaxClockOutTime {
+				change.Action = models.ImportActionUnchanged
+			}
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	return nil
+}
+
+// departmentsByName maps lower-cased department names to their current rows
+func (s *DepartmentImportService) departmentsByName() (map[string]models.Department, error) {
+	rows, err := s.db.Query(`
+		SELECT id, departement_name, max_clock_in_time, max_clock_out_time
+		FROM departement
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch departments: %v", err)
+	}
+	defer rows.Close()
+
+	departments := make(map[string]models.Department)
+	for rows.Next() {
+		var dept models.Department
+		if err := rows.Scan(&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime); err != nil {
+			return nil, fmt.Errorf("failed to read department: %v", err)
+		}
+		departments[strings.ToLower(strings.TrimSpace(dept.DepartementName))] = dept
+	}
+
+	return departments, rows.Err()
+}
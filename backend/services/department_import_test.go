@@ -0,0 +1,141 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		clockIn   string
+		clockOut  string
+		wantField string
+	}{
+		{"Valid", "08:30:00", "17:30:00", ""},
+		{"Missing Seconds", "08:30", "17:30:00", "max_clock_in_time"},
+		{"Invalid Clock Out", "08:30:00", "25:00:00", "max_clock_out_time"},
+		{"Clock Out Before Clock In", "17:30:00", "08:30:00", "max_clock_out_time"},
+		{"Equal Times", "08:30:00", "08:30:00", "max_clock_out_time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, err := ValidateSchedule(tt.clockIn, tt.clockOut)
+			assert.Equal(t, tt.wantField, field)
+			assert.Equal(t, tt.wantField != "", err != nil)
+		})
+	}
+}
+
+// openDepartmentImportTestDB returns a migrated database with departments IT
+// and Sales
+func openDepartmentImportTestDB(t *testing.T) *sql.DB {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?), (2, 'Sales', '08:00:00', '16:00:00', ?, ?)`,
+		created, created, created, created)
+	assert.NoError(t, err)
+	return db
+}
+
+// departmentSchedules maps department names to their clock-in and clock-out
+// limits
+func departmentSchedules(t *testing.T, db *sql.DB) map[string][2]string {
+	rows, err := db.Query("SELECT departement_name, max_clock_in_time, max_clock_out_time FROM departement")
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer rows.Close()
+
+	schedules := make(map[string][2]string)
+	for rows.Next() {
+		var name, clockIn, clockOut string
+		assert.NoError(t, rows.Scan(&name, &clockIn, &clockOut))
+		schedules[name] = [2]string{clockIn, clockOut}
+	}
+	assert.NoError(t, rows.Err())
+	return schedules
+}
+
+var departmentImportRows = [][]string{
+	{"Department Name", "Max Clock In Time", "Max Clock Out Time"},
+	{"it", "08:30:00", "17:30:00"},
+	{"Sales", "08:00:00", "16:00:00"},
+	{"Support", "07:00:00", "15:00:00"},
+}
+
+func TestDepartmentImportDryRun(t *testing.T) {
+	db := openDepartmentImportTestDB(t)
+
+	report, err := NewDepartmentImportService(db).Import(departmentImportRows, models.ImportModeDryRun, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.ValidRows)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, []models.ImportChange{
+		{
+			Row: 2, Action: models.ImportActionUpdate,
+			Before: models.Department{ID: 1, DepartementName: "IT", MaxClockInTime: "09:00:00", MaxClockOutTime: "17:00:00"},
+			After:  models.Department{ID: 1, DepartementName: "IT", MaxClockInTime: "08:30:00", MaxClockOutTime: "17:30:00"},
+		},
+		{
+			Row: 3, Action: models.ImportActionUnchanged,
+			Before: models.Department{ID: 2, DepartementName: "Sales", MaxClockInTime: "08:00:00", MaxClockOutTime: "16:00:00"},
+			After:  models.Department{ID: 2, DepartementName: "Sales", MaxClockInTime: "08:00:00", MaxClockOutTime: "16:00:00"},
+		},
+		{
+			Row: 4, Action: models.ImportActionCreate,
+			After: models.Department{DepartementName: "Support", MaxClockInTime: "07:00:00", MaxClockOutTime: "15:00:00"},
+		},
+	}, report.Changes)
+
+	assert.Equal(t, map[string][2]string{
+		"IT":    {"09:00:00", "17:00:00"},
+		"Sales": {"08:00:00", "16:00:00"},
+	}, departmentSchedules(t, db), "dry runs write nothing")
+}
+
+func TestDepartmentImportApply(t *testing.T) {
+	db := openDepartmentImportTestDB(t)
+
+	report, err := NewDepartmentImportService(db).Import(departmentImportRows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.ErrorRows)
+	assert.Equal(t, 2, report.Imported, "unchanged departments are not written")
+	assert.Equal(t, map[string][2]string{
+		"IT":      {"08:30:00", "17:30:00"},
+		"Sales":   {"08:00:00", "16:00:00"},
+		"Support": {"07:00:00", "15:00:00"},
+	}, departmentSchedules(t, db))
+
+	var audited int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE entity_type = ?", models.AuditEntityDepartment).Scan(&audited))
+	assert.Equal(t, 2, audited)
+
+	// A file with an invalid row changes nothing
+	rows := [][]string{
+		departmentImportRows[0],
+		{"IT", "06:00:00", "14:00:00"},
+		{"Finance", "18:00:00", "09:00:00"},
+	}
+	report, err = NewDepartmentImportService(db).Import(rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.ErrorRows)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, "08:30:00", departmentSchedules(t, db)["IT"][0])
+	_, ok := departmentSchedules(t, db)["Finance"]
+	assert.False(t, ok)
+
+	// As does a row the database rejects once earlier rows were applied
+	rejectInserts(t, db, "departement", "departement_name", "Finance")
+	rows[2] = []string{"Finance", "09:00:00", "18:00:00"}
+	_, err = NewDepartmentImportService(db).Import(rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.Error(t, err)
+	assert.Equal(t, "08:30:00", departmentSchedules(t, db)["IT"][0])
+}
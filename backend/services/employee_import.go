@@ -0,0 +1,324 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// Column names shared with CSVExportService.ExportEmployeeList
+const (
+	employeeColumnID         = "employee id"
+	employeeColumnName       = "name"
+	employeeColumnDepartment = "department"
+	employeeColumnAddress    = "address"
+	employeeColumnEmail      = "email"
+	employeeColumnPhone      = "phone"
+	employeeColumnJobTitle   = "job title"
+	employeeColumnType       = "employment type"
+	employeeColumnLocation   = "work location"
+	employeeColumnStatus     = "employment status"
+	employeeColumnHireDate   = "hire date"
+	employeeColumnTerminated = "termination date"
+)
+
+// EmployeeImportRow is a validated row ready to be inserted
+type EmployeeImportRow struct {
+	Row           int
+	EmployeeID    string
+	DepartementID int
+	Name          string
+	Address       string
+	Email         string
+	Phone         string
+	JobTitle      string
+	WorkLocation  string
+	// EmploymentType defaults to full_time
+	EmploymentType string
+	// EmploymentStatus defaults to active; the optional dates are YYYY-MM-DD
+	EmploymentStatus string
+	HireDate         *string
+	TerminationDate  *string
+}
+
+// EmployeeImportService validates and imports employees in bulk
+type EmployeeImportService struct {
+	db *sql.DB
+}
+
+// NewEmployeeImportService creates a new employee import service
+func NewEmployeeImportService(db *sql.DB) *EmployeeImportService {
+	return &EmployeeImportService{db: db}
+}
+
+// Import validates every row and, in commit mode, inserts all of them in a
+// single transaction, recording each in the audit log on behalf of actor.
+// Nothing is written if any row fails validation.
+func (s *EmployeeImportService) Import(rows [][]string, mode string, actor models.AuditActor) (*models.ImportReport, error) {
+	report := &models.ImportReport{Mode: mode, Errors: []models.ImportRowError{}}
+
+	valid, err := s.validate(rows, report)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != models.ImportModeCommit || report.ErrorRows > 0 || len(valid) == 0 {
+		return report, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	auditLog := NewAuditLog(s.db)
+	now := time.Now()
+	for _, row := range valid {
+		id, err := database.TxInsertReturningID(s.db, tx, `
+			INSERT INTO employee (employee_id, departement_id, name, address, email, phone, job_title, employment_type,
+				work_location, employment_status, hire_date, termination_date, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, row.EmployeeID, row.DepartementID, row.Name, row.Address, row.Email, row.Phone, row.JobTitle, row.EmploymentType,
+			row.WorkLocation, row.EmploymentStatus, row.HireDate, row.TerminationDate, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert row %d: %v", row.Row, err)
+		}
+		if _, err := AssignDepartment(s.db, tx, row.EmployeeID, row.DepartementID, FirstAssignmentDate(row.HireDate, now), now); err != nil {
+			return nil, fmt.Errorf("failed to assign department on row %d: %v", row.Row, err)
+		}
+
+		err = auditLog.Append(tx, actor, models.AuditChange{
+			Action:     models.AuditActionCreate,
+			EntityType: models.AuditEntityEmployee,
+			EntityID:   strconv.FormatInt(id, 10),
+			After: models.Employee{
+				ID:               int(id),
+				EmployeeID:       row.EmployeeID,
+				DepartementID:    row.DepartementID,
+				Name:             row.Name,
+				Address:          row.Address,
+				Email:            row.Email,
+				Phone:            row.Phone,
+				JobTitle:         row.JobTitle,
+				EmploymentType:   row.EmploymentType,
+				WorkLocation:     row.WorkLocation,
+				EmploymentStatus: row.EmploymentStatus,
+				HireDate:         row.HireDate,
+				TerminationDate:  row.TerminationDate,
+				CreatedAt:        now,
+				UpdatedAt:        now,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to audit row %d: %v", row.Row, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %v", err)
+	}
+	report.Imported = len(valid)
+
+	return report, nil
+}
+
+// validate checks each data row and records problems on the report
+func (s *EmployeeImportService) validate(rows [][]string, report *models.ImportReport) ([]EmployeeImportRow, error) {
+	if len(rows) == 0 {
+		report.Errors = append(report.Errors, models.ImportRowError{Row: 1, Message: "file is empty"})
+		report.ErrorRows = 1
+		return nil, nil
+	}
+
+	index := headerIndex(rows[0])
+	for _, column := range []string{employeeColumnID, employeeColumnName, employeeColumnDepartment} {
+		if _, ok := index[column]; !ok {
+			report.Errors = append(report.Errors, models.ImportRowError{
+				Row:     1,
+				Field:   column,
+				Message: "missing column",
+			})
+		}
+	}
+	if len(report.Errors) > 0 {
+		report.ErrorRows = 1
+		return nil, nil
+	}
+
+	departments, err := s.departmentIDsByName()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.existingEmployeeIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]int)
+	var valid []EmployeeImportRow
+	for i, values := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(values) {
+			continue
+		}
+		report.TotalRows++
+
+		row := EmployeeImportRow{
+			Row:          rowNumber,
+			EmployeeID:   cell(values, index, employeeColumnID),
+			Name:         cell(values, index, employeeColumnName),
+			Address:      cell(values, index, employeeColumnAddress),
+			Email:        cell(values, index, employeeColumnEmail),
+			Phone:        cell(values, index, employeeColumnPhone),
+			JobTitle:     cell(values, index, employeeColumnJobTitle),
+			WorkLocation: cell(values, index, employeeColumnLocation),
+		}
+		departmentName := cell(values, index, employeeColumnDepartment)
+
+		var rowErrors []models.ImportRowError
+		addError := func(field, value, message string) {
+			rowErrors = append(rowErrors, models.ImportRowError{
+				Row: rowNumber, Field: field, Value: value, Message: message,
+			})
+		}
+
+		if row.EmployeeID == "" {
+			addError(employeeColumnID, "", "required")
+		} else if first, ok := seen[row.EmployeeID]; ok {
+			addError(employeeColumnID, row.EmployeeID, fmt.Sprintf("duplicate of row %d", first))
+		} else if existing[row.EmployeeID] {
+			addError(employeeColumnID, row.EmployeeID, "employee ID already exists")
+		}
+		if row.EmployeeID != "" {
+			if _, ok := seen[row.EmployeeID]; !ok {
+				seen[row.EmployeeID] = rowNumber
+			}
+		}
+
+		if row.Name == "" {
+			addError(employeeColumnName, "", "required")
+		}
+
+		if departmentName == "" {
+			addError(employeeColumnDepartment, "", "required")
+		} else if id, ok := departments[strings.ToLower(departmentName)]; ok {
+			row.DepartementID = id
+		} else {
+			addError(employeeColumnDepartment, departmentName, "unknown department")
+		}
+
+		if field, err := ValidateContact(row.Email, row.Phone); err != nil {
+			addError(field, cell(values, index, field), err.Error())
+		}
+		for _, text := range []struct {
+			column string
+			value  string
+			max    int
+		}{
+			{employeeColumnEmail, row.Email, 255},
+			{employeeColumnPhone, row.Phone, 50},
+			{employeeColumnJobTitle, row.JobTitle, 100},
+			{employeeColumnLocation, row.WorkLocation, 100},
+		} {
+			if len(text.value) > text.max {
+				addError(text.column, text.value, fmt.Sprintf("must be at most %d characters", text.max))
+			}
+		}
+
+		row.EmploymentType = strings.ToLower(cell(values, index, employeeColumnType))
+		switch row.EmploymentType {
+		case "":
+			row.EmploymentType = models.EmploymentFullTime
+		case models.EmploymentFullTime, models.EmploymentPartTime, models.EmploymentContractor:
+		default:
+			addError(employeeColumnType, row.EmploymentType, "must be full_time, part_time or contractor")
+		}
+
+		row.EmploymentStatus = strings.ToLower(cell(values, index, employeeColumnStatus))
+		switch row.EmploymentStatus {
+		case "":
+			row.EmploymentStatus = models.EmploymentActive
+		case models.EmploymentActive, models.EmploymentSuspended, models.EmploymentTerminated:
+		default:
+			addError(employeeColumnStatus, row.EmploymentStatus, "must be active, suspended or terminated")
+		}
+		for _, date := range []struct {
+			column string
+			value  **string
+		}{
+			{employeeColumnHireDate, &row.HireDate},
+			{employeeColumnTerminated, &row.TerminationDate},
+		} {
+			if value := cell(values, index, date.column); value != "" {
+				if _, err := time.Parse("2006-01-02", value); err != nil {
+					addError(date.column, value, "must be YYYY-MM-DD")
+					continue
+				}
+				*date.value = &value
+			}
+		}
+		if len(rowErrors) == 0 {
+			if field, err := ValidateEmployment(row.EmploymentStatus, row.HireDate, row.TerminationDate); err != nil {
+				addError(strings.ReplaceAll(field, "_", " "), "", err.Error())
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			report.ErrorRows++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+
+		report.ValidRows++
+		valid = append(valid, row)
+	}
+
+	return valid, nil
+}
+
+// departmentIDsByName maps lower-cased department names to their IDs
+func (s *EmployeeImportService) departmentIDsByName() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT id, departement_name FROM departement")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch departments: %v", err)
+	}
+	defer rows.Close()
+
+	departments := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to read department: %v", err)
+		}
+		departments[strings.ToLower(strings.TrimSpace(name))] = id
+	}
+
+	return departments, rows.Err()
+}
+
+// existingEmployeeIDs returns the set of employee IDs already in use
+func (s *EmployeeImportService) existingEmployeeIDs() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT employee_id FROM employee")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %v", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to read employee: %v", err)
+		}
+		existing[id] = true
+	}
+
+	return existing, rows.Err()
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openEmployeeImportTestDB returns a migrated database with an IT department
+// and employee EXISTING in it
+func openEmployeeImportTestDB(t *testing.T) *sql.DB {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, created_at, updated_at)
+		VALUES ('EXISTING', 1, 'Existing Employee', '', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	return db
+}
+
+func countEmployees(t *testing.T, db *sql.DB) int {
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM employee").Scan(&count))
+	return count
+}
+
+func TestEmployeeImportCommitsValidFile(t *testing.T) {
+	db := openEmployeeImportTestDB(t)
+	rows := [][]string{
+		{"Employee ID", "Name", "Department", "Email", "Hire Date"},
+		{"E1", "First", "it", "first@example.com", "2024-01-02"},
+		{"E2", "Second", "IT", "", ""},
+	}
+
+	report, err := NewEmployeeImportService(db).Import(rows, models.ImportModeDryRun, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.ValidRows)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 1, countEmployees(t, db), "dry runs write nothing")
+
+	report, err = NewEmployeeImportService(db).Import(rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.ErrorRows)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 3, countEmployees(t, db))
+
+	var assignments int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM department_assignment WHERE employee_id IN ('E1', 'E2')").Scan(&assignments))
+	assert.Equal(t, 2, assignments)
+}
+
+func TestEmployeeImportValidation(t *testing.T) {
+	header := []string{"Employee ID", "Name", "Department"}
+	tests := []struct {
+		name     string
+		rows     [][]string
+		expected []models.ImportRowError
+	}{
+		{
+			name: "duplicate code within the file",
+			rows: [][]string{header, {"E1", "First", "IT"}, {"E1", "Again", "IT"}},
+			expected: []models.ImportRowError{
+				{Row: 3, Field: "employee id", Value: "E1", Message: "duplicate of row 2"},
+			},
+		},
+		{
+			name: "code already in the database",
+			rows: [][]string{header, {"EXISTING", "Someone", "IT"}},
+			expected: []models.ImportRowError{
+				{Row: 2, Field: "employee id", Value: "EXISTING", Message: "employee ID already exists"},
+			},
+		},
+		{
+			name: "unknown department",
+			rows: [][]string{header, {"E1", "First", "Sales"}},
+			expected: []models.ImportRowError{
+				{Row: 2, Field: "department", Value: "Sales", Message: "unknown department"},
+			},
+		},
+		{
+			name: "missing required columns",
+			rows: [][]string{{"Employee ID", "Address"}, {"E1", "Somewhere"}},
+			expected: []models.ImportRowError{
+				{Row: 1, Field: "name", Message: "missing column"},
+				{Row: 1, Field: "department", Message: "missing column"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openEmployeeImportTestDB(t)
+			report, err := NewEmployeeImportService(db).Import(tt.rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, report.Errors)
+			assert.Equal(t, 0, report.Imported)
+			assert.Equal(t, 1, countEmployees(t, db))
+		})
+	}
+}
+
+func TestEmployeeImportIsAllOrNothing(t *testing.T) {
+	db := openEmployeeImportTestDB(t)
+
+	// One invalid row keeps the valid ones out too
+	rows := [][]string{
+		{"Employee ID", "Name", "Department"},
+		{"E1", "First", "IT"},
+		{"E2", "", "IT"},
+		{"E3", "Third", "IT"},
+	}
+	report, err := NewEmployeeImportService(db).Import(rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.ValidRows)
+	assert.Equal(t, 1, report.ErrorRows)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 1, countEmployees(t, db))
+
+	// So does a row the database rejects after earlier rows were inserted
+	rejectInserts(t, db, "employee", "employee_id", "E3")
+	rows[2][1] = "Second"
+	_, err = NewEmployeeImportService(db).Import(rows, models.ImportModeCommit, models.AuditActor{Actor: "test"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, countEmployees(t, db))
+
+	var audited int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log").Scan(&audited))
+	assert.Equal(t, 0, audited)
+}
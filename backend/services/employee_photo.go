@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxPhotoSize is the largest employee photo accepted, in bytes
+const MaxPhotoSize = 5 << 20
+
+// photoExtensions maps the accepted photo content types to file extensions
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// PhotoURL returns the API path serving the photo of the employee with id
+func PhotoURL(id int) *string {
+	url := fmt.Sprintf("/api/v1/employees/%d/photo", id)
+	return &url
+}
+
+// PhotoKey returns a new blob key for a photo of contentType of the employee
+// with id. Each upload gets its own key, so the previous photo stays
+// readable until the new one is recorded.
+func PhotoKey(id int, contentType string, now time.Time) (string, error) {
+	ext, ok := photoExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("must be a JPEG, PNG, GIF or WebP image")
+	}
+	return fmt.Sprintf("employees/%d/photo-%d%s", id, now.UnixNano(), ext), nil
+}
+
+// PhotoContentType returns the content type of the photo stored under key
+func PhotoContentType(key string) string {
+	ext := strings.ToLower(path.Ext(key))
+	for contentType, photoExt := range photoExtensions {
+		if photoExt == ext {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"attendance-system/models"
+)
+
+// EmployeeColumns lists the employee columns read by ScanEmployee, for
+// queries that alias the employee table as e
+const EmployeeColumns = `e.id, e.employee_id, e.departement_id, e.name, e.address,
+	e.email, e.phone, e.job_title, e.employment_type, e.work_location, e.photo_key, e.employment_status, e.hire_date, e.termination_date, e.manager_id, e.deleted_at, e.created_at, e.updated_at`
+
+// EmployeeWithDepartmentColumns lists the columns read by
+// ScanEmployeeWithDepartment, for queries that join departement as d
+const EmployeeWithDepartmentColumns = EmployeeColumns + `,
+	d.id, d.departement_name, d.max_clock_in_time, d.max_clock_out_time`
+
+// EmployeeSearchCondition returns a condition, starting with AND, matching
+// employees, aliased as e, whose ID, name, email, phone, job title, work
+// location or a custom field value contains search, ignoring case
+func EmployeeSearchCondition(search string) (string, []interface{}) {
+	pattern := "%" + strings.ToLower(search) + "%"
+	condition := ` AND (LOWER(e.employee_id) LIKE ? OR LOWER(e.name) LIKE ? OR LOWER(e.email) LIKE ?
+		OR LOWER(e.phone) LIKE ? OR LOWER(e.job_title) LIKE ? OR LOWER(e.work_location) LIKE ?
+		OR EXISTS (SELECT 1 FROM employee_custom_value v WHERE v.employee_id = e.employee_id AND LOWER(v.value) LIKE ?))`
+	args := make([]interface{}, strings.Count(condition, "?"))
+	for i := range args {
+		args[i] = pattern
+	}
+	return condition, args
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanEmployee reads a row selected with EmployeeColumns, followed by any
+// extra columns into dest. Custom fields are not part of the row and are left
+// nil.
+func ScanEmployee(row rowScanner, dest ...interface{}) (models.Employee, error) {
+	var emp models.Employee
+	var hireDate, terminationDate, deletedAt sql.NullTime
+	var managerID, photoKey sql.NullString
+	err := row.Scan(append([]interface{}{
+		&emp.ID, &emp.EmployeeID, &emp.DepartementID, &emp.Name, &emp.Address,
+		&emp.Email, &emp.Phone, &emp.JobTitle, &emp.EmploymentType, &emp.WorkLocation, &photoKey, &emp.EmploymentStatus, &hireDate, &terminationDate, &managerID, &deletedAt, &emp.CreatedAt, &emp.UpdatedAt,
+	}, dest...)...)
+	if err != nil {
+		return emp, err
+	}
+	emp.HireDate = dateString(hireDate)
+	emp.TerminationDate = dateString(terminationDate)
+	if managerID.Valid {
+		emp.ManagerID = &managerID.String
+	}
+	if photoKey.Valid {
+		emp.PhotoKey = &photoKey.String
+		emp.PhotoURL = PhotoURL(emp.ID)
+	}
+	if deletedAt.Valid {
+		emp.DeletedAt = &deletedAt.Time
+	}
+	return emp, nil
+}
+
+// ScanEmployeeWithDepartment reads a row selected with
+// EmployeeWithDepartmentColumns
+func ScanEmployeeWithDepartment(row rowScanner) (models.EmployeeWithDepartment, error) {
+	var dept models.Department
+	emp, err := ScanEmployee(row, &dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime)
+	if err != nil {
+		return models.EmployeeWithDepartment{}, err
+	}
+	return models.EmployeeWithDepartment{
+		ID:               emp.ID,
+		EmployeeID:       emp.EmployeeID,
+		DepartementID:    emp.DepartementID,
+		Name:             emp.Name,
+		Address:          emp.Address,
+		Email:            emp.Email,
+		Phone:            emp.Phone,
+		JobTitle:         emp.JobTitle,
+		EmploymentType:   emp.EmploymentType,
+		WorkLocation:     emp.WorkLocation,
+		PhotoKey:         emp.PhotoKey,
+		PhotoURL:         emp.PhotoURL,
+		EmploymentStatus: emp.EmploymentStatus,
+		HireDate:         emp.HireDate,
+		TerminationDate:  emp.TerminationDate,
+		ManagerID:        emp.ManagerID,
+		DeletedAt:        emp.DeletedAt,
+		Department:       dept,
+		CreatedAt:        emp.CreatedAt,
+		UpdatedAt:        emp.UpdatedAt,
+	}, nil
+}
+
+// dateString formats a DATE column as YYYY-MM-DD, keeping NULL as nil
+func dateString(date sql.NullTime) *string {
+	if !date.Valid {
+		return nil
+	}
+	s := date.Time.Format("2006-01-02")
+	return &s
+}
+
+// ValidateEmployment checks that the employment dates agree with the status:
+// a terminated employee needs a termination date, nobody else may have one,
+// and it cannot be before the hire date. It returns the name of the offending
+// field on failure.
+func ValidateEmployment(status string, hireDate, terminationDate *string) (string, error) {
+	if status == models.EmploymentTerminated && terminationDate == nil {
+		return "termination_date", fmt.Errorf("is required when employment_status is terminated")
+	}
+	if status != models.EmploymentTerminated && terminationDate != nil {
+		return "termination_date", fmt.Errorf("is only allowed when employment_status is terminated")
+	}
+	if hireDate != nil && terminationDate != nil && *terminationDate < *hireDate {
+		return "termination_date", fmt.Errorf("must not be before hire_date")
+	}
+	return "", nil
+}
+
+// phonePattern accepts digits with an optional leading + and the usual
+// separators
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]*[0-9]$`)
+
+// ValidateContact checks that email, when given, is a bare address and phone
+// a phone number. It returns the name of the offending field on failure.
+func ValidateContact(email, phone string) (string, error) {
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return "email", fmt.Errorf("must be a valid email address")
+		}
+	}
+	if phone != "" && !phonePattern.MatchString(phone) {
+		return "phone", fmt.Errorf("must be a phone number")
+	}
+	return "", nil
+}
+
+// InactiveReason explains why employee is not expected at work on day, or
+// returns "" when they are. Employees without a hire date count as hired the
+// day they were created; terminated employees work up to and including their
+// termination date.
+func InactiveReason(employee models.Employee, day time.Time) string {
+	date := day.Format("2006-01-02")
+
+	if employee.DeletedAt != nil && employee.DeletedAt.In(day.Location()).Format("2006-01-02") <= date {
+		return "employee has been deleted"
+	}
+	if employee.EmploymentStatus == models.EmploymentSuspended {
+		return "employee is suspended"
+	}
+
+	hired := employee.CreatedAt.In(day.Location()).Format("2006-01-02")
+	if employee.HireDate != nil {
+		hired = *employee.HireDate
+	}
+	if date < hired {
+		return "employee starts on " + hired
+	}
+	if employee.TerminationDate != nil && date > *employee.TerminationDate {
+		return "employee left on " + *employee.TerminationDate
+	}
+	return ""
+}
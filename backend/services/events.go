@@ -0,0 +1,32 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/google/uuid"
+)
+
+// NewEvent creates an event of eventType carrying data
+func NewEvent(eventType string, data interface{}) models.Event {
+	return models.Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// MatchEventType reports whether a subscription pattern such as
+// "department.*" covers eventType
+func MatchEventType(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return false
+}
@@ -0,0 +1,572 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"attendance-system/metrics"
+	"attendance-system/models"
+
+	"github.com/google/uuid"
+)
+
+// exportCleanupInterval is how often expired export files are swept
+const exportCleanupInterval = 5 * time.Minute
+
+// exportQueueSize is how many jobs can wait for a worker
+const exportQueueSize = 100
+
+var (
+	// ErrExportJobNotFound is returned when an export job does not exist
+	ErrExportJobNotFound = errors.New("export job not found")
+	// ErrExportQueueFull is returned when no more jobs can be queued
+	ErrExportQueueFull = errors.New("export queue is full")
+	// ErrInvalidExportFilters is returned when export filters fail validation
+	ErrInvalidExportFilters = errors.New("invalid export filters")
+)
+
+// ExportJobService runs export jobs on a pool of background workers
+type ExportJobService struct {
+	db         *sql.DB
+	csvService *CSVExportService
+	dir        string
+	workers    int
+	retention  time.Duration
+
+	queue chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	alive atomic.Int32
+
+	// backlog holds unfinished jobs found at Start that did not fit in the
+	// queue; they are moved to it as workers free up
+	backlogMu sync.Mutex
+	backlog   []string
+}
+
+// NewExportJobService creates a new export job service
+func NewExportJobService(db *sql.DB, dir string, workers int, retention time.Duration) *ExportJobService {
+	if workers < 1 {
+		workers = 1
+	}
+	return &ExportJobService{
+		db:         db,
+		csvService: NewCSVExportService(),
+		dir:        dir,
+		workers:    workers,
+		retention:  retention,
+		queue:      make(chan string, exportQueueSize),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start launches the worker pool and the cleanup loop. Jobs left pending or
+// running by a previous process are queued again; those beyond the queue's
+// capacity wait in a backlog until workers free up.
+func (s *ExportJobService) Start() error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create exports directory: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id FROM export_job
+		WHERE status IN (?, ?)
+		ORDER BY created_at
+	`, models.ExportJobPending, models.ExportJobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to load unfinished export jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var unfinished []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("failed to read unfinished export job", "error", err)
+			continue
+		}
+		unfinished = append(unfinished, id)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load unfinished export jobs: %v", err)
+	}
+
+	s.backlogMu.Lock()
+	s.backlog = unfinished
+	s.backlogMu.Unlock()
+	s.drainBacklog()
+	if waiting := s.backlogLen(); waiting > 0 {
+		slog.Warn("export queue full, jobs wait for a free worker", "waiting", waiting)
+	}
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	s.wg.Add(1)
+	go s.cleanupLoop()
+
+	return nil
+}
+
+// Stop signals the workers to exit and waits for in-flight jobs to finish,
+// or until ctx is done. Queued jobs stay pending and are picked up again by
+// the next Start.
+func (s *ExportJobService) Stop(ctx context.Context) error {
+	close(s.stop)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("export workers did not stop: %v", ctx.Err())
+	}
+}
+
+// CheckWorkers returns an error unless every export worker is running
+func (s *ExportJobService) CheckWorkers() error {
+	if alive := int(s.alive.Load()); alive < s.workers {
+		return fmt.Errorf("%d of %d export workers running", alive, s.workers)
+	}
+	return nil
+}
+
+// CheckDir returns an error unless a file can be written to the exports
+// directory
+func (s *ExportJobService) CheckDir() error {
+	file, err := os.CreateTemp(s.dir, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("exports directory is not writable: %v", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Create stores a new export job and queues it for processing
+func (s *ExportJobService) Create(req models.CreateExportJobRequest) (*models.ExportJob, error) {
+	if err := validateExportFilters(req.Filters); err != nil {
+		return nil, err
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "csv"
+	}
+
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filters: %v", err)
+	}
+
+	job := &models.ExportJob{
+		ID:        uuid.New().String(),
+		Entity:    req.Entity,
+		Format:    format,
+		Filters:   req.Filters,
+		Status:    models.ExportJobPending,
+		CreatedAt: time.Now(),
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO export_job (id, entity, format, filters, status, progress, row_count, created_at)
+		VALUES (?, ?, ?, ?, ?, 0, 0, ?)
+	`, job.ID, job.Entity, job.Format, string(filters), job.Status, job.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export job: %v", err)
+	}
+
+	if err := s.enqueue(job.ID); err != nil {
+		s.fail(job.ID, err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Get retrieves an export job by ID
+func (s *ExportJobService) Get(id string) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var filters string
+	var fileName, jobError sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, entity, format, filters, status, progress, row_count, file_name, error,
+		       created_at, started_at, finished_at, expires_at
+		FROM export_job
+		WHERE id = ?
+	`, id).Scan(
+		&job.ID, &job.Entity, &job.Format, &filters, &job.Status, &job.Progress, &job.RowCount,
+		&fileName, &jobError, &job.CreatedAt, &job.StartedAt, &job.FinishedAt, &job.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch export job: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(filters), &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode filters: %v", err)
+	}
+	job.FileName = fileName.String
+	job.Error = jobError.String
+
+	return &job, nil
+}
+
+// Dir returns the exports directory, also used for inline CSV downloads so
+// that the retention sweep removes them
+func (s *ExportJobService) Dir() string {
+	return s.dir
+}
+
+// FilePath returns the location of a finished job's output file
+func (s *ExportJobService) FilePath(job *models.ExportJob) string {
+	return filepath.Join(s.dir, job.FileName)
+}
+
+func (s *ExportJobService) enqueue(id string) error {
+	select {
+	case s.queue <- id:
+		return nil
+	default:
+		return ErrExportQueueFull
+	}
+}
+
+// drainBacklog moves backlogged jobs into the queue while it has room
+func (s *ExportJobService) drainBacklog() {
+	s.backlogMu.Lock()
+	defer s.backlogMu.Unlock()
+
+	for len(s.backlog) > 0 {
+		if err := s.enqueue(s.backlog[0]); err != nil {
+			return
+		}
+		s.backlog = s.backlog[1:]
+	}
+}
+
+func (s *ExportJobService) backlogLen() int {
+	s.backlogMu.Lock()
+	defer s.backlogMu.Unlock()
+	return len(s.backlog)
+}
+
+func (s *ExportJobService) worker() {
+	defer s.wg.Done()
+	s.alive.Add(1)
+	defer s.alive.Add(-1)
+	for {
+		select {
+		case <-s.stop:
+			return
+		case id := <-s.queue:
+			s.drainBacklog()
+			if err := s.run(id); err != nil {
+				slog.Error("export job failed", "job_id", id, "error", err)
+				s.fail(id, err)
+			}
+		}
+	}
+}
+
+// run executes a single export job from start to finish
+func (s *ExportJobService) run(id string) (err error) {
+	job, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	defer func() {
+		status := models.ExportJobCompleted
+		if err != nil {
+			status = models.ExportJobFailed
+		}
+		metrics.ObserveExportJob(job.Entity, status, time.Since(now))
+	}()
+
+	_, err = s.db.Exec(`
+		UPDATE export_job SET status = ?, progress = ?, started_at = ?
+		WHERE id = ?
+	`, models.ExportJobRunning, 10, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark export job running: %v", err)
+	}
+
+	fileName := fmt.Sprintf("%s_%s.%s", job.Entity, job.ID, job.Format)
+	path := filepath.Join(s.dir, fileName)
+
+	var rowCount int
+	switch job.Entity {
+	case "attendance":
+		logs, err := QueryAttendanceLogs(s.db, job.Filters)
+		if err != nil {
+			return err
+		}
+		rowCount = len(logs)
+		s.setProgress(id, 60, rowCount)
+		err = s.csvService.ExportAttendanceLogs(logs, path)
+		if err != nil {
+			return err
+		}
+	case "employees":
+		employees, err := s.fetchEmployees(job.Filters)
+		if err != nil {
+			return err
+		}
+		rowCount = len(employees)
+		fields, err := CustomFields(s.db)
+		if err != nil {
+			return err
+		}
+		s.setProgress(id, 60, rowCount)
+		err = s.csvService.ExportEmployeeList(employees, fields, path)
+		if err != nil {
+			return err
+		}
+	case "departments":
+		departments, err := s.fetchDepartments()
+		if err != nil {
+			return err
+		}
+		rowCount = len(departments)
+		s.setProgress(id, 60, rowCount)
+		err = s.csvService.ExportDepartmentList(departments, path)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported export entity: %s", job.Entity)
+	}
+
+	finished := time.Now()
+	_, err = s.db.Exec(`
+		UPDATE export_job
+		SET status = ?, progress = 100, row_count = ?, file_name = ?, finished_at = ?, expires_at = ?
+		WHERE id = ?
+	`, models.ExportJobCompleted, rowCount, fileName, finished, finished.Add(s.retention), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark export job completed: %v", err)
+	}
+
+	return nil
+}
+
+func (s *ExportJobService) setProgress(id string, progress, rowCount int) {
+	_, err := s.db.Exec("UPDATE export_job SET progress = ?, row_count = ? WHERE id = ?", progress, rowCount, id)
+	if err != nil {
+		slog.Error("failed to update export job progress", "job_id", id, "error", err)
+	}
+}
+
+func (s *ExportJobService) fail(id string, cause error) {
+	_, err := s.db.Exec(`
+		UPDATE export_job SET status = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`, models.ExportJobFailed, cause.Error(), time.Now(), id)
+	if err != nil {
+		slog.Error("failed to record export job failure", "job_id", id, "error", err)
+	}
+}
+
+func (s *ExportJobService) cleanupLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(exportCleanupInterval)
+	defer ticker.Stop()
+
+	s.cleanup()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.drainBacklog()
+			s.cleanup()
+		}
+	}
+}
+
+// cleanup expires finished jobs past their retention window and removes any
+// file in the exports directory older than the retention period
+func (s *ExportJobService) cleanup() {
+	now := time.Now()
+
+	rows, err := s.db.Query(`
+		SELECT id, file_name FROM export_job
+		WHERE status = ? AND expires_at <= ?
+	`, models.ExportJobCompleted, now)
+	if err != nil {
+		slog.Error("export cleanup: failed to fetch expired jobs", "error", err)
+		return
+	}
+
+	var expired []string
+	for rows.Next() {
+		var id string
+		var fileName sql.NullString
+		if err := rows.Scan(&id, &fileName); err != nil {
+			slog.Error("export cleanup: failed to read expired job", "error", err)
+			continue
+		}
+		if fileName.String != "" {
+			if err := os.Remove(filepath.Join(s.dir, fileName.String)); err != nil && !os.IsNotExist(err) {
+				slog.Error("export cleanup: failed to remove file", "job_id", id, "file", fileName.String, "error", err)
+				continue
+			}
+		}
+		expired = append(expired, id)
+	}
+	rows.Close()
+
+	for _, id := range expired {
+		_, err := s.db.Exec("UPDATE export_job SET status = ? WHERE id = ?", models.ExportJobExpired, id)
+		if err != nil {
+			slog.Error("export cleanup: failed to expire job", "job_id", id, "error", err)
+		}
+	}
+
+	s.sweepDir(now)
+}
+
+// sweepDir removes stray files left behind by inline CSV downloads
+func (s *ExportJobService) sweepDir(now time.Time) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		slog.Error("export cleanup: failed to read directory", "dir", s.dir, "error", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			slog.Warn("export cleanup: failed to stat file", "file", entry.Name(), "error", err)
+			continue
+		}
+		if now.Sub(info.ModTime()) > s.retention {
+			if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				slog.Error("export cleanup: failed to remove file", "file", entry.Name(), "error", err)
+			}
+		}
+	}
+}
+
+func (s *ExportJobService) fetchEmployees(filters models.ExportFilters) ([]models.EmployeeWithDepartment, error) {
+	query := `
+		SELECT ` + EmployeeWithDepartmentColumns + `
+		FROM employee e
+		LEFT JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NULL
+	`
+
+	var args []interface{}
+	if filters.DepartmentID > 0 {
+		query += " AND e.departement_id = ?"
+		args = append(args, filters.DepartmentID)
+	}
+	if filters.ManagerID != "" {
+		condition, team, err := TeamCondition(s.db, "e.employee_id", filters.ManagerID, filters.DirectOnly)
+		if err != nil {
+			return nil, err
+		}
+		query += condition
+		args = append(args, team...)
+	}
+	if filters.Search != "" {
+		condition, search := EmployeeSearchCondition(filters.Search)
+		query += condition
+		args = append(args, search...)
+	}
+
+	query += " ORDER BY e.created_at DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %v", err)
+	}
+	defer rows.Close()
+
+	var employees []models.EmployeeWithDepartment
+	for rows.Next() {
+		emp, err := ScanEmployeeWithDepartment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read employee: %v", err)
+		}
+		employees = append(employees, emp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := WithCustomFields(s.db, employees); err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
+
+func (s *ExportJobService) fetchDepartments() ([]models.Department, error) {
+	rows, err := s.db.Query(`
+		SELECT id, departement_name, max_clock_in_time, max_clock_out_time
+		FROM departement
+		ORDER BY departement_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch departments: %v", err)
+	}
+	defer rows.Close()
+
+	var departments []models.Department
+	for rows.Next() {
+		var dept models.Department
+		err := rows.Scan(&dept.ID, &dept.DepartementName, &dept.MaxClockInTime, &dept.MaxClockOutTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read department: %v", err)
+		}
+		departments = append(departments, dept)
+	}
+
+	return departments, rows.Err()
+}
+
+// validateExportFilters checks that date filters use the YYYY-MM-DD format
+func validateExportFilters(filters models.ExportFilters) error {
+	dates := map[string]string{
+		"date":       filters.Date,
+		"start_date": filters.StartDate,
+		"end_date":   filters.EndDate,
+	}
+	for field, value := range dates {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("%w: %s must be YYYY-MM-DD", ErrInvalidExportFilters, field)
+		}
+	}
+
+	if filters.StartDate != "" && filters.EndDate != "" && filters.StartDate > filters.EndDate {
+		return fmt.Errorf("%w: start_date must not be after end_date", ErrInvalidExportFilters)
+	}
+
+	return nil
+}
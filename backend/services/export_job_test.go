@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateExportFilters(t *testing.T) {
+	t.Run("Valid Date Range", func(t *testing.T) {
+		err := validateExportFilters(models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-12-31"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid Date Format", func(t *testing.T) {
+		err := validateExportFilters(models.ExportFilters{Date: "15/01/2024"})
+		assert.True(t, errors.Is(err, ErrInvalidExportFilters))
+	})
+
+	t.Run("Start After End", func(t *testing.T) {
+		err := validateExportFilters(models.ExportFilters{StartDate: "2024-12-31", EndDate: "2024-01-01"})
+		assert.True(t, errors.Is(err, ErrInvalidExportFilters))
+	})
+}
+
+func TestExportJobServiceStopDeadline(t *testing.T) {
+	s := NewExportJobService(nil, t.TempDir(), 1, time.Hour)
+	assert.NoError(t, s.Stop(context.Background()))
+
+	// A worker that never finishes must not block shutdown past the deadline
+	s = NewExportJobService(nil, t.TempDir(), 1, time.Hour)
+	s.wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, s.Stop(ctx))
+}
+
+func TestExportJobServiceRequeuesBacklog(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	// More unfinished jobs than the queue holds, as left by a stopped process
+	jobs := exportQueueSize + 5
+	createdAt := time.Now().Add(-time.Hour)
+	for i := 0; i < jobs; i++ {
+		_, err := db.Exec(`
+			INSERT INTO export_job (id, entity, format, filters, status, progress, row_count, created_at)
+			VALUES (?, 'departments', 'csv', '{}', ?, 0, 0, ?)
+		`, fmt.Sprintf("job-%03d", i), models.ExportJobPending, createdAt.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewExportJobService(db, t.TempDir(), 1, time.Hour)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop(context.Background())
+
+	assert.Eventually(t, func() bool {
+		var completed int
+		err := db.QueryRow("SELECT COUNT(*) FROM export_job WHERE status = ?", models.ExportJobCompleted).Scan(&completed)
+		return err == nil && completed == jobs
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Zero(t, s.backlogLen())
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"attendance-system/models"
+)
+
+// FlexTime computes employee's flex-time account between startDate and
+// endDate, inclusive YYYY-MM-DD dates. Each day covered by a working-time
+// contract, up to today, adds the minutes worked and subtracts the contract's
+// daily target on its working days while the employee is expected at work.
+// Worked minutes run from clock-in to clock-out, so a day still clocked in
+// counts nothing yet. Invalid dates return an error wrapping
+// ErrInvalidExportFilters.
+func FlexTime(db *sql.DB, employee models.Employee, startDate, endDate string, now time.Time) (models.FlexTimeAccount, error) {
+	account := models.FlexTimeAccount{
+		EmployeeID: employee.EmployeeID,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Days:       []models.FlexTimeDay{},
+	}
+	if err := validateExportFilters(models.ExportFilters{StartDate: startDate, EndDate: endDate}); err != nil {
+		return account, err
+	}
+	first, _ := parseDay(startDate)
+	last, _ := parseDay(endDate)
+	if last.Sub(first) >= maxAbsenceDays*24*time.Hour {
+		return account, fmt.Errorf("%w: the range must not exceed %d days", ErrInvalidExportFilters, maxAbsenceDays)
+	}
+
+	spans, err := queryContractSpans(db, employee.EmployeeID)
+	if err != nil {
+		return account, err
+	}
+	_, end := DayBounds(last)
+	if _, tomorrow := DayBounds(now); tomorrow.Before(end) {
+		end = tomorrow
+	}
+	worked, err := workedMinutes(db, employee.EmployeeID, first, end)
+	if err != nil {
+		return account, err
+	}
+
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		contract := contractAt(spans, day)
+		if contract == nil {
+			continue
+		}
+		date := day.Format("2006-01-02")
+		target := 0
+		if worksOn(contract, day) && InactiveReason(employee, day) == "" {
+			target = dailyTargetMinutes(contract)
+		}
+		if target == 0 && worked[date] == 0 {
+			continue
+		}
+		account.Days = append(account.Days, models.FlexTimeDay{
+			Date:           date,
+			ContractID:     contract.ID,
+			TargetMinutes:  target,
+			WorkedMinutes:  worked[date],
+			BalanceMinutes: worked[date] - target,
+		})
+		account.TargetMinutes += target
+		account.WorkedMinutes += worked[date]
+	}
+	account.BalanceMinutes = account.WorkedMinutes - account.TargetMinutes
+
+	return account, nil
+}
+
+// workedMinutes returns the whole minutes employeeID worked on each local day
+// between start and end, keyed by YYYY-MM-DD, counting sessions they have
+// clocked out of
+func workedMinutes(db *sql.DB, employeeID string, start, end time.Time) (map[string]int, error) {
+	rows, err := db.Query(`
+		SELECT clock_in, clock_out FROM attendance
+		WHERE employee_id = ? AND clock_in >= ? AND clock_in < ? AND clock_out IS NOT NULL
+	`, employeeID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attendance: %v", err)
+	}
+	defer rows.Close()
+
+	worked := make(map[string]int)
+	for rows.Next() {
+		var clockIn, clockOut time.Time
+		if err := rows.Scan(&clockIn, &clockOut); err != nil {
+			return nil, fmt.Errorf("failed to read attendance: %v", err)
+		}
+		if clockOut.After(clockIn) {
+			worked[clockIn.In(start.Location()).Format("2006-01-02")] += int(clockOut.Sub(clockIn) / time.Minute)
+		}
+	}
+
+	return worked, rows.Err()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// HealthChecker checks the dependencies the service needs to handle traffic
+type HealthChecker struct {
+	db              *sql.DB
+	migrator        *database.Migrator
+	exportService   *ExportJobService
+	reportScheduler *ReportScheduler
+	webhooks        *WebhookDispatcher
+	outbox          *Outbox
+	timeout         time.Duration
+}
+
+// NewHealthChecker creates a new health checker. Checks that talk to the
+// database give up after timeout.
+func NewHealthChecker(db *sql.DB, migrator *database.Migrator, exportService *ExportJobService, reportScheduler *ReportScheduler, webhooks *WebhookDispatcher, outbox *Outbox, timeout time.Duration) *HealthChecker {
+	return &HealthChecker{
+		db:              db,
+		migrator:        migrator,
+		exportService:   exportService,
+		reportScheduler: reportScheduler,
+		webhooks:        webhooks,
+		outbox:          outbox,
+		timeout:         timeout,
+	}
+}
+
+// Readiness runs every check concurrently and reports each result
+func (h *HealthChecker) Readiness(ctx context.Context) models.ReadinessReport {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) error{
+		"database":         h.db.PingContext,
+		"migrations":       h.checkMigrations,
+		"export_dir":       func(context.Context) error { return h.exportService.CheckDir() },
+		"export_workers":   func(context.Context) error { return h.exportService.CheckWorkers() },
+		"report_scheduler": func(context.Context) error { return h.reportScheduler.Check() },
+		"webhook_workers":  func(context.Context) error { return h.webhooks.CheckWorkers() },
+		"outbox_relay":     func(context.Context) error { return h.outbox.CheckRelay() },
+	}
+
+	report := models.ReadinessReport{
+		Status:    models.HealthStatusOK,
+		Checks:    make(map[string]models.HealthCheck, len(checks)),
+		Timestamp: time.Now(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := runHealthCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != models.HealthStatusOK {
+				report.Status = models.HealthStatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// checkMigrations fails while embedded migrations have not been applied
+func (h *HealthChecker) checkMigrations(ctx context.Context) error {
+	pending, err := h.migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(pending))
+	for i, migration := range pending {
+		names[i] = fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+	}
+	return fmt.Errorf("%d pending migrations: %s", len(pending), strings.Join(names, ", "))
+}
+
+// runHealthCheck times check, failing it if ctx is done first
+func runHealthCheck(ctx context.Context, check func(context.Context) error) models.HealthCheck {
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out: %v", ctx.Err())
+	}
+
+	result := models.HealthCheck{
+		Status:     models.HealthStatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = models.HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
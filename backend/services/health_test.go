@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestHealthCheckerReadiness(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, database.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportService := NewExportJobService(db, t.TempDir(), 2, time.Hour)
+	reportScheduler := NewReportScheduler(db, nil)
+	webhooks := NewWebhookDispatcher(db, http.DefaultClient, 1, 3, time.Second)
+	outbox := NewOutbox(db, time.Second, time.Second, 10, webhooks)
+	checker := NewHealthChecker(db, migrator, exportService, reportScheduler, webhooks, outbox, time.Second)
+
+	report := checker.Readiness(context.Background())
+	assert.Equal(t, models.HealthStatusFail, report.Status)
+	assert.Equal(t, models.HealthStatusOK, report.Checks["database"].Status)
+	assert.Equal(t, models.HealthStatusOK, report.Checks["export_dir"].Status)
+	assert.Equal(t, models.HealthStatusFail, report.Checks["migrations"].Status)
+	assert.Contains(t, report.Checks["migrations"].Error, "pending migrations")
+	assert.Equal(t, "0 of 2 export workers running", report.Checks["export_workers"].Error)
+	assert.Equal(t, models.HealthStatusFail, report.Checks["report_scheduler"].Status)
+	assert.Equal(t, "0 of 1 webhook workers running", report.Checks["webhook_workers"].Error)
+	assert.Equal(t, "outbox relay is not running", report.Checks["outbox_relay"].Error)
+
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, exportService.Start())
+	assert.NoError(t, reportScheduler.Start())
+	webhooks.Start()
+	outbox.Start()
+
+	assert.Eventually(t, func() bool {
+		return checker.Readiness(context.Background()).Status == models.HealthStatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, reportScheduler.Stop(context.Background()))
+	assert.NoError(t, exportService.Stop(context.Background()))
+	assert.NoError(t, webhooks.Stop(context.Background()))
+	assert.NoError(t, outbox.Stop(context.Background()))
+
+	report = checker.Readiness(context.Background())
+	assert.Equal(t, models.HealthStatusFail, report.Status)
+	assert.Equal(t, models.HealthStatusFail, report.Checks["export_workers"].Status)
+	assert.Equal(t, models.HealthStatusFail, report.Checks["report_scheduler"].Status)
+}
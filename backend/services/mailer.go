@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// EmailAttachment represents a file attached to an email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// EmailMessage represents an outgoing email
+type EmailMessage struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []EmailAttachment
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(msg EmailMessage) error
+}
+
+// SMTPMailer sends email through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers the message to all recipients
+func (m *SMTPMailer) Send(msg EmailMessage) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+
+	data, err := m.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := net.JoinHostPort(m.host, m.port)
+	if err := smtp.SendMail(addr, auth, m.from, msg.To, data); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// buildMessage renders the message as a multipart/mixed MIME document
+func (m *SMTPMailer) buildMessage(msg EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	body, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write email body: %v", err)
+	}
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, fmt.Errorf("failed to write email body: %v", err)
+	}
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", attachment.Filename)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write attachment: %v", err)
+		}
+		if _, err := part.Write([]byte(wrapBase64(attachment.Data))); err != nil {
+			return nil, fmt.Errorf("failed to write attachment: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish email: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// wrapBase64 encodes data as base64 split into 76 character lines
+func wrapBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var sb strings.Builder
+	for len(encoded) > 76 {
+		sb.WriteString(encoded[:76])
+		sb.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	sb.WriteString(encoded)
+	sb.WriteString("\r\n")
+
+	return sb.String()
+}
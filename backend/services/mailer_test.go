@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts a single SMTP session and records the message data
+type fakeSMTPServer struct {
+	listener   net.Listener
+	recipients []string
+	data       chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, data: make(chan string, 1)}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO"):
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var sb strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				sb.WriteString(dataLine)
+			}
+			s.data <- sb.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+
+	mailer := NewSMTPMailer(host, port, "", "", "reports@example.com")
+	err := mailer.Send(EmailMessage{
+		To:      []string{"head@example.com", "hr@example.com"},
+		Subject: "Late arrivals report - 2024-01-15",
+		Body:    "2 record(s) attached.",
+		Attachments: []EmailAttachment{{
+			Filename:    "late_arrivals.csv",
+			ContentType: "text/csv",
+			Data:        []byte("No,Employee ID\n1,EMP001\n"),
+		}},
+	})
+	assert.NoError(t, err)
+
+	data := <-server.data
+	assert.Equal(t, []string{"head@example.com", "hr@example.com"}, server.recipients)
+	assert.Contains(t, data, "Subject: Late arrivals report - 2024-01-15")
+	assert.Contains(t, data, `filename="late_arrivals.csv"`)
+	assert.Contains(t, data, wrapBase64([]byte("No,Employee ID\n1,EMP001\n")))
+}
+
+func TestSMTPMailerRequiresRecipients(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", "25", "", "", "reports@example.com")
+	err := mailer.Send(EmailMessage{Subject: "No one"})
+	assert.Error(t, err)
+}
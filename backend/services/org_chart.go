@@ -0,0 +1,208 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// ErrManagerNotFound is returned for a manager who does not exist or has
+// been deleted
+var ErrManagerNotFound = errors.New("manager not found")
+
+// ErrManagerCycle is returned when a manager reports, directly or
+// indirectly, to the employee they would manage
+var ErrManagerCycle = errors.New("manager reports to this employee")
+
+// ErrOutsideTeam is returned when an employee asks for the team of a
+// manager who does not report to them
+var ErrOutsideTeam = errors.New("manager is outside the actor's team")
+
+// CheckManager verifies in tx that managerID may manage employeeID: the
+// manager exists, has not been deleted, and is neither employeeID nor anyone
+// below them. The management chain above the employee is locked, so a
+// concurrent change cannot close a loop before tx commits.
+func CheckManager(db *sql.DB, tx *sql.Tx, employeeID, managerID string) error {
+	seen := make(map[string]bool)
+	for current := managerID; current != ""; {
+		if current == employeeID || seen[current] {
+			return ErrManagerCycle
+		}
+		seen[current] = true
+
+		var next sql.NullString
+		var deletedAt sql.NullTime
+		err := tx.QueryRow("SELECT manager_id, deleted_at FROM employee WHERE employee_id = ?"+database.ForUpdate(db), current).
+			Scan(&next, &deletedAt)
+		if current == managerID && (err == sql.ErrNoRows || err == nil && deletedAt.Valid) {
+			return ErrManagerNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch manager %s: %v", current, err)
+		}
+		current = next.String
+	}
+	return nil
+}
+
+// TeamMembers returns the employee IDs of everyone reporting to managerID,
+// directly or through their own reports, or only directly with directOnly.
+// Deleted employees are left out.
+func TeamMembers(q queryer, managerID string, directOnly bool) ([]string, error) {
+	rows, err := q.Query("SELECT employee_id, manager_id FROM employee WHERE deleted_at IS NULL AND manager_id IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reporting lines: %v", err)
+	}
+	defer rows.Close()
+
+	reports := make(map[string][]string)
+	for rows.Next() {
+		var employeeID, manager string
+		if err := rows.Scan(&employeeID, &manager); err != nil {
+			return nil, fmt.Errorf("failed to read reporting line: %v", err)
+		}
+		reports[manager] = append(reports[manager], employeeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var team []string
+	seen := map[string]bool{managerID: true}
+	queue := []string{managerID}
+	for len(queue) > 0 {
+		manager := queue[0]
+		queue = queue[1:]
+		for _, employeeID := range reports[manager] {
+			if seen[employeeID] {
+				continue
+			}
+			seen[employeeID] = true
+			team = append(team, employeeID)
+			if !directOnly {
+				queue = append(queue, employeeID)
+			}
+		}
+	}
+	return team, nil
+}
+
+// TeamCondition returns a condition, starting with AND, limiting column to
+// the team of managerID as found by TeamMembers
+func TeamCondition(q queryer, column, managerID string, directOnly bool) (string, []interface{}, error) {
+	team, err := TeamMembers(q, managerID, directOnly)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(team) == 0 {
+		return " AND 1=0", nil, nil
+	}
+
+	args := make([]interface{}, len(team))
+	for i, employeeID := range team {
+		args[i] = employeeID
+	}
+	return " AND " + column + " IN (?" + strings.Repeat(", ?", len(team)-1) + ")", args, nil
+}
+
+// ScopeToTeam returns the manager_id filter an actor may use. When actor is
+// an employee who has not been deleted, the filter is limited to their own
+// team: an empty managerID becomes actor, and any other manager must report
+// to them, directly or indirectly, or ErrOutsideTeam is returned. Actors who
+// are not employees, such as HR staff or integrations, keep managerID.
+func ScopeToTeam(db *sql.DB, actor, managerID string) (string, error) {
+	if actor == "" || actor == managerID {
+		return managerID, nil
+	}
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM employee WHERE employee_id = ? AND deleted_at IS NULL", actor).Scan(&count)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch actor %s: %v", actor, err)
+	}
+	if count == 0 {
+		return managerID, nil
+	}
+	if managerID == "" {
+		return actor, nil
+	}
+
+	team, err := TeamMembers(db, actor, false)
+	if err != nil {
+		return "", err
+	}
+	for _, employeeID := range team {
+		if employeeID == managerID {
+			return managerID, nil
+		}
+	}
+	return "", ErrOutsideTeam
+}
+
+// OrgChart returns employeeID with everyone below them, each level sorted by
+// name, and the number of people below them. Deleted employees are left out;
+// an unknown or deleted employeeID returns sql.ErrNoRows.
+func OrgChart(db *sql.DB, employeeID string) (models.OrgChartNode, int, error) {
+	rows, err := db.Query(`
+		SELECT ` + EmployeeWithDepartmentColumns + `
+		FROM employee e
+		LEFT JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NULL
+		ORDER BY e.name, e.employee_id
+	`)
+	if err != nil {
+		return models.OrgChartNode{}, 0, fmt.Errorf("failed to fetch employees: %v", err)
+	}
+	defer rows.Close()
+
+	employees := make(map[string]models.EmployeeWithDepartment)
+	reports := make(map[string][]string)
+	for rows.Next() {
+		emp, err := ScanEmployeeWithDepartment(rows)
+		if err != nil {
+			return models.OrgChartNode{}, 0, fmt.Errorf("failed to read employee: %v", err)
+		}
+		employees[emp.EmployeeID] = emp
+		if emp.ManagerID != nil {
+			reports[*emp.ManagerID] = append(reports[*emp.ManagerID], emp.EmployeeID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.OrgChartNode{}, 0, err
+	}
+	rows.Close()
+
+	if _, ok := employees[employeeID]; !ok {
+		return models.OrgChartNode{}, 0, sql.ErrNoRows
+	}
+	values, err := CustomValues(db)
+	if err != nil {
+		return models.OrgChartNode{}, 0, err
+	}
+	for id, emp := range employees {
+		emp.CustomFields = values[id]
+		if emp.CustomFields == nil {
+			emp.CustomFields = map[string]interface{}{}
+		}
+		employees[id] = emp
+	}
+	size := 0
+	seen := make(map[string]bool)
+	var build func(id string) models.OrgChartNode
+	build = func(id string) models.OrgChartNode {
+		seen[id] = true
+		node := models.OrgChartNode{Employee: employees[id], Reports: []models.OrgChartNode{}}
+		for _, reportID := range reports[id] {
+			if seen[reportID] {
+				continue
+			}
+			size++
+			node.Reports = append(node.Reports, build(reportID))
+		}
+		return node
+	}
+	return build(employeeID), size, nil
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"attendance-system/metrics"
+	"attendance-system/models"
+)
+
+const (
+	// outboxBatchSize is how many pending events are read per relay pass
+	outboxBatchSize = 100
+	// outboxMaxBackoff caps the delay between two attempts of an event
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// EventSink receives events relayed from the outbox. An event is sent again
+// after any error, and may reach a sink more than once after a crash, so
+// sinks that must not repeat side effects deduplicate on the event ID.
+type EventSink interface {
+	Name() string
+	Send(ctx context.Context, event models.Event) error
+}
+
+// Outbox records events in the transaction of the change they describe, so
+// an event exists exactly when its change was committed, and relays them to
+// the sinks from a background worker. Delivery is at least once. Events
+// sharing an ordering key are relayed in the order they were recorded: a
+// failed event holds back the later events with its key until it succeeds
+// or, after maxAttempts, is marked dead.
+type Outbox struct {
+	db          *sql.DB
+	sinks       []EventSink
+	backoff     time.Duration
+	maxAttempts int
+	// pollInterval is how often the relay looks for events committed by
+	// other processes and for due retries
+	pollInterval time.Duration
+	// lease is how long a claimed event is reserved for the relay sending it
+	lease time.Duration
+
+	wake    chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	running atomic.Bool
+}
+
+// outboxEntry is an event read back from the outbox
+type outboxEntry struct {
+	id       int
+	key      string
+	attempts int
+	event    models.Event
+}
+
+// NewOutbox creates a new outbox relaying to sinks. backoff is the delay
+// before the first retry of a failed event; each later retry waits twice as
+// long as the one before, until the event has failed maxAttempts times.
+func NewOutbox(db *sql.DB, pollInterval, backoff time.Duration, maxAttempts int, sinks ...EventSink) *Outbox {
+	return &Outbox{
+		db:           db,
+		sinks:        sinks,
+		backoff:      backoff,
+		maxAttempts:  maxAttempts,
+		pollInterval: pollInterval,
+		lease:        time.Minute,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// EmployeeEventKey is the ordering key of events about an employee
+func EmployeeEventKey(employeeID string) string {
+	return "employee:" + employeeID
+}
+
+// DepartmentEventKey is the ordering key of events about a department
+func DepartmentEventKey(id int) string {
+	return "department:" + strconv.Itoa(id)
+}
+
+// Add records event in tx. It is relayed once tx is committed, after every
+// earlier event with the same ordering key.
+func (o *Outbox) Add(tx *sql.Tx, key string, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO event_outbox (event_id, event_type, ordering_key, payload, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?)
+	`, event.ID, event.Type, key, string(payload), now, now)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %v", err)
+	}
+	return nil
+}
+
+// Commit commits tx and wakes the relay to send the events added to it
+func (o *Outbox) Commit(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start launches the relay. Events left unsent by a previous process are
+// relayed first.
+func (o *Outbox) Start() {
+	o.running.Store(true)
+	o.wg.Add(1)
+	go o.run()
+}
+
+// Stop waits for the relay to finish the event it is sending, or for ctx to
+// expire. Unsent events stay in the outbox for the next start.
+func (o *Outbox) Stop(ctx context.Context) error {
+	close(o.stop)
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckRelay reports whether the relay is running
+func (o *Outbox) CheckRelay() error {
+	if !o.running.Load() {
+		return errors.New("outbox relay is not running")
+	}
+	return nil
+}
+
+func (o *Outbox) run() {
+	defer o.wg.Done()
+	defer o.running.Store(false)
+
+	// Stopping interrupts a sink that is still sending
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-o.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back so a backlog drains
+		// without waiting for the ticker
+		for {
+			relayed, err := o.Relay(ctx)
+			if err != nil {
+				slog.Error("failed to relay outbox events", "error", err)
+			}
+			if err != nil || relayed < outboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay makes one pass over the due events in the outbox, oldest first, and
+// returns how many it attempted. An event is only sent once every earlier
+// event with its ordering key has been.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	entries, err := o.due()
+	if err != nil {
+		return 0, err
+	}
+
+	// Keys with an event that could not be sent in this pass
+	held := make(map[string]bool)
+	attempted := 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if held[entry.key] {
+			continue
+		}
+
+		claimed, err := o.claim(entry)
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			// Another relay has it; later events with the key must wait
+			held[entry.key] = true
+			continue
+		}
+
+		attempted++
+		if err := o.send(ctx, entry); err != nil {
+			held[entry.key] = true
+			slog.Warn("outbox event not relayed",
+				"event_id", entry.event.ID, "event_type", entry.event.Type, "attempts", entry.attempts+1, "error", err)
+		}
+	}
+
+	return attempted, nil
+}
+
+// due returns the oldest unsent, live events whose next attempt is due
+func (o *Outbox) due() ([]outboxEntry, error) {
+	rows, err := o.db.Query(`
+		SELECT id, ordering_key, attempts, payload FROM event_outbox
+		WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`, time.Now(), outboxBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch outbox events: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []outboxEntry
+	for rows.Next() {
+		var entry outboxEntry
+		var payload string
+		if err := rows.Scan(&entry.id, &entry.key, &entry.attempts, &payload); err != nil {
+			return nil, fmt.Errorf("failed to read outbox event: %v", err)
+		}
+
+		// The data is passed on as recorded rather than decoded into maps
+		var stored struct {
+			models.Event
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(payload), &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox event %d: %v", entry.id, err)
+		}
+		entry.event = stored.Event
+		entry.event.Data = stored.Data
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// claim leases entry to this relay by pushing its next attempt past the
+// lease, so another process polling the outbox does not send it at the same
+// time and an event interrupted by a crash is retried once the lease runs
+// out. Entries with an earlier unsent, live event of the same key are not
+// claimed.
+func (o *Outbox) claim(entry outboxEntry) (bool, error) {
+	now := time.Now()
+	result, err := o.db.Exec(`
+		UPDATE event_outbox SET next_attempt_at = ?
+		WHERE id = ? AND published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+	`, now.Add(o.lease), entry.id, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim outbox event: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected != 1 {
+		return false, err
+	}
+
+	var earlier int
+	err = o.db.QueryRow(`
+		SELECT COUNT(*) FROM event_outbox
+		WHERE ordering_key = ? AND published_at IS NULL AND failed_at IS NULL AND id < ?
+	`, entry.key, entry.id).Scan(&earlier)
+	if err == nil && earlier == 0 {
+		return true, nil
+	}
+
+	// Give the claim back; the event goes once the earlier ones have
+	if _, releaseErr := o.db.Exec("UPDATE event_outbox SET next_attempt_at = ? WHERE id = ?", now, entry.id); releaseErr != nil {
+		slog.Error("failed to release outbox event", "event_id", entry.event.ID, "error", releaseErr)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check earlier outbox events: %v", err)
+	}
+	return false, nil
+}
+
+// send delivers entry to every sink and records the outcome. A sink error
+// schedules the event to be sent to all sinks again after a backoff, or
+// marks it dead once it has failed maxAttempts times.
+func (o *Outbox) send(ctx context.Context, entry outboxEntry) error {
+	attempts := entry.attempts + 1
+	for _, sink := range o.sinks {
+		if err := sink.Send(ctx, entry.event); err != nil {
+			metrics.RecordOutboxRelay(sink.Name(), "failed")
+			err = fmt.Errorf("%s: %v", sink.Name(), err)
+
+			now := time.Now()
+			var failedAt interface{}
+			if attempts >= o.maxAttempts {
+				failedAt = now
+				slog.Error("outbox event dead after retries",
+					"event_id", entry.event.ID, "event_type", entry.event.Type, "attempts", attempts, "error", err)
+			}
+			_, dbErr := o.db.Exec(`
+				UPDATE event_outbox SET attempts = ?, last_error = ?, next_attempt_at = ?, failed_at = ?
+				WHERE id = ?
+			`, attempts, err.Error(), now.Add(o.retryDelay(attempts)), failedAt, entry.id)
+			if dbErr != nil {
+				slog.Error("failed to record outbox failure", "event_id", entry.event.ID, "error", dbErr)
+			}
+			return err
+		}
+		metrics.RecordOutboxRelay(sink.Name(), "sent")
+	}
+
+	_, err := o.db.Exec(`
+		UPDATE event_outbox SET attempts = ?, last_error = NULL, published_at = ?
+		WHERE id = ?
+	`, attempts, time.Now(), entry.id)
+	if err != nil {
+		// The lease runs out and the event is sent again, which at least
+		// once delivery allows
+		return fmt.Errorf("failed to mark outbox event published: %v", err)
+	}
+	return nil
+}
+
+// retryDelay is the wait after the given number of failed attempts
+func (o *Outbox) retryDelay(attempts int) time.Duration {
+	delay := o.backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return delay
+}
+
+// LogSink writes each relayed event to the application log
+type LogSink struct{}
+
+// Name implements EventSink
+func (LogSink) Name() string { return "log" }
+
+// Send implements EventSink
+func (LogSink) Send(ctx context.Context, event models.Event) error {
+	slog.Info("event", "event_id", event.ID, "event_type", event.Type, "occurred_at", event.OccurredAt, "data", event.Data)
+	return nil
+}
+
+// FileSink appends each relayed event to a file as a line of JSON
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink creates a sink appending to the file at path
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Name implements EventSink
+func (s *FileSink) Name() string { return "file" }
+
+// Send implements EventSink. The file is synced before returning so an
+// event is never marked published without being on disk.
+func (s *FileSink) Send(ctx context.Context, event models.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink collects the IDs of the events it is sent, failing the
+// events listed in failures once each and those in broken every time
+type recordingSink struct {
+	mu       sync.Mutex
+	sent     []string
+	failures map[string]bool
+	broken   map[string]bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(ctx context.Context, event models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures[event.ID] {
+		delete(s.failures, event.ID)
+		return errors.New("sink unavailable")
+	}
+	if s.broken[event.ID] {
+		return errors.New("event rejected")
+	}
+	s.sent = append(s.sent, event.ID)
+	return nil
+}
+
+func (s *recordingSink) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// recordEvents commits events under key in one transaction
+func recordEvents(t *testing.T, db *sql.DB, o *Outbox, key string, events ...models.Event) {
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, event := range events {
+		if err := o.Add(tx, key, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Commit(tx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutboxRelaysEventsCommittedBeforeCrash(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	// The first process commits its changes and dies before relaying
+	crashed := NewOutbox(db, time.Hour, time.Second, 10)
+	created := NewEvent(models.EventEmployeeCreated, models.Employee{ID: 1, EmployeeID: "EMP001", Name: "John Doe"})
+	clockIn := NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{EmployeeID: "EMP001"})
+	recordEvents(t, db, crashed, EmployeeEventKey("EMP001"), created)
+	recordEvents(t, db, crashed, EmployeeEventKey("EMP001"), clockIn)
+
+	// Events of a rolled back change never existed
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, crashed.Add(tx, EmployeeEventKey("EMP002"), NewEvent(models.EventEmployeeCreated, models.Employee{ID: 2})))
+	assert.NoError(t, tx.Rollback())
+
+	sink := &recordingSink{}
+	restarted := NewOutbox(db, 10*time.Millisecond, time.Second, 10, sink)
+	restarted.Start()
+	defer restarted.Stop(context.Background())
+
+	assert.Eventually(t, func() bool {
+		return len(sink.ids()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{created.ID, clockIn.ID}, sink.ids())
+
+	var pending int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM event_outbox WHERE published_at IS NULL").Scan(&pending))
+	assert.Equal(t, 0, pending)
+
+	// New events wake the relay without waiting for a poll
+	updated := NewEvent(models.EventEmployeeUpdated, models.Employee{ID: 1, EmployeeID: "EMP001"})
+	recordEvents(t, db, restarted, EmployeeEventKey("EMP001"), updated)
+	assert.Eventually(t, func() bool {
+		return len(sink.ids()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestOutboxRetriesEventInterruptedMidRelay(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	// The first process claims the event and dies before sending it
+	crashed := NewOutbox(db, time.Hour, time.Second, 10)
+	crashed.lease = 100*time.Millisecond + timePrecision(t)
+	event := NewEvent(models.EventDepartmentCreated, models.Department{ID: 1, DepartementName: "IT"})
+	recordEvents(t, db, crashed, DepartmentEventKey(1), event)
+
+	entries, err := crashed.due()
+	if !assert.NoError(t, err) || !assert.Len(t, entries, 1) {
+		return
+	}
+	claimed, err := crashed.claim(entries[0])
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	sink := &recordingSink{}
+	restarted := NewOutbox(db, time.Hour, time.Second, 10, sink)
+
+	relayed, err := restarted.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relayed, "the event is leased to the crashed process")
+
+	time.Sleep(150*time.Millisecond + 2*timePrecision(t))
+	relayed, err = restarted.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, []string{event.ID}, sink.ids())
+}
+
+func TestOutboxKeepsOrderPerKey(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	first := NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{EmployeeID: "EMP001"})
+	other := NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{EmployeeID: "EMP002"})
+	second := NewEvent(models.EventAttendanceClockOut, models.AttendanceEvent{EmployeeID: "EMP001"})
+
+	sink := &recordingSink{failures: map[string]bool{first.ID: true}}
+	o := NewOutbox(db, time.Hour, time.Millisecond, 10, sink)
+	recordEvents(t, db, o, EmployeeEventKey("EMP001"), first)
+	recordEvents(t, db, o, EmployeeEventKey("EMP002"), other)
+	recordEvents(t, db, o, EmployeeEventKey("EMP001"), second)
+
+	// The failed event holds back the later one with its key only
+	relayed, err := o.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, []string{other.ID}, sink.ids())
+
+	var attempts int
+	var lastError string
+	assert.NoError(t, db.QueryRow("SELECT attempts, last_error FROM event_outbox WHERE event_id = ?", first.ID).
+		Scan(&attempts, &lastError))
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "recording: sink unavailable", lastError)
+
+	time.Sleep(10*time.Millisecond + timePrecision(t))
+	_, err = o.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{other.ID, first.ID, second.ID}, sink.ids())
+}
+
+func TestOutboxDeadEventReleasesKey(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	rejected := NewEvent(models.EventAttendanceClockIn, models.AttendanceEvent{EmployeeID: "EMP001"})
+	later := NewEvent(models.EventAttendanceClockOut, models.AttendanceEvent{EmployeeID: "EMP001"})
+
+	sink := &recordingSink{broken: map[string]bool{rejected.ID: true}}
+	o := NewOutbox(db, time.Hour, time.Millisecond, 2, sink)
+	recordEvents(t, db, o, EmployeeEventKey("EMP001"), rejected, later)
+
+	relay := func() {
+		time.Sleep(10*time.Millisecond + timePrecision(t))
+		_, err := o.Relay(context.Background())
+		assert.NoError(t, err)
+	}
+	relay()
+	relay()
+	assert.Empty(t, sink.ids(), "the event holds back its key while it has attempts left")
+
+	var attempts int
+	var failedAt sql.NullTime
+	var published sql.NullTime
+	assert.NoError(t, db.QueryRow("SELECT attempts, failed_at, published_at FROM event_outbox WHERE event_id = ?", rejected.ID).
+		Scan(&attempts, &failedAt, &published))
+	assert.Equal(t, 2, attempts)
+	assert.True(t, failedAt.Valid)
+	assert.False(t, published.Valid)
+
+	// Once dead it is left alone and the later event goes
+	relay()
+	assert.Equal(t, []string{later.ID}, sink.ids())
+	relay()
+	assert.Equal(t, []string{later.ID}, sink.ids())
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	o := NewOutbox(nil, time.Second, time.Second, 10)
+	assert.Equal(t, time.Second, o.retryDelay(1))
+	assert.Equal(t, 4*time.Second, o.retryDelay(3))
+	assert.Equal(t, outboxMaxBackoff, o.retryDelay(30))
+}
+
+func TestFileSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+
+	events := []models.Event{
+		NewEvent(models.EventDepartmentCreated, models.Department{ID: 1}),
+		NewEvent(models.EventDepartmentDeleted, models.Department{ID: 1}),
+	}
+	for _, event := range events {
+		assert.NoError(t, sink.Send(context.Background(), event))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event models.Event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{events[0].ID, events[1].ID}, ids)
+}
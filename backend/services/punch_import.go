@@ -0,0 +1,465 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+
+	"github.com/google/uuid"
+)
+
+// maxSessionLength is the longest gap accepted between a clock-in punch and
+// the clock-out punch it is paired with
+const maxSessionLength = 24 * time.Hour
+
+// Accepted header names for each punch log column
+var (
+	punchColumnEmployee  = []string{"employee code", "employee_code", "employee id", "employee_id"}
+	punchColumnTimestamp = []string{"timestamp", "time", "datetime"}
+	punchColumnDirection = []string{"direction", "type"}
+)
+
+// punchTimestampLayouts are the timestamp formats accepted from time clocks
+var punchTimestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+// legacyAttendanceNamespace seeds the deterministic IDs of imported sessions
+var legacyAttendanceNamespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte("attendance-system/legacy-punch"))
+
+// punch is a single parsed time clock event
+type punch struct {
+	row        int
+	employeeID string
+	timestamp  time.Time
+	clockIn    bool
+}
+
+// punchSession is a clock-in optionally paired with its clock-out
+type punchSession struct {
+	employeeID string
+	in         punch
+	out        *punch
+}
+
+// PunchImportService imports legacy time clock punch logs into attendance
+type PunchImportService struct {
+	db *sql.DB
+}
+
+// NewPunchImportService creates a new punch import service
+func NewPunchImportService(db *sql.DB) *PunchImportService {
+	return &PunchImportService{db: db}
+}
+
+// Import parses punch rows, pairs them into sessions and writes them to
+// attendance and attendance_history. Sessions that already exist (same
+// employee and clock-in time) are skipped, so overlapping files can be
+// re-imported safely, and a clock-out whose clock-in came in an earlier file
+// closes the attendance that file left open. Dry-run mode performs the same
+// work inside a transaction that is rolled back. Committed sessions are
+// recorded in the audit log on behalf of actor.
+func (s *PunchImportService) Import(rows [][]string, mode string, actor models.AuditActor) (*models.PunchImportReport, error) {
+	report := &models.PunchImportReport{
+		Mode:   mode,
+		Errors: []models.ImportRowError{},
+		Issues: []models.PunchIssue{},
+	}
+
+	employees, err := s.employeeIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	punches := parsePunches(rows, employees, report)
+	sessions, outs := pairPunches(punches, report)
+	report.Sessions = len(sessions)
+
+	if report.ErrorRows > 0 {
+		return report, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, out := range outs {
+		if err := s.closeOpen(tx, out, actor, report); err != nil {
+			return nil, err
+		}
+	}
+	for _, session := range sessions {
+		if err := s.apply(tx, session, actor, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode != models.ImportModeCommit {
+		return report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %v", err)
+	}
+
+	return report, nil
+}
+
+// apply writes a single session unless it has already been imported,
+// evaluating each punch against the department the employee was in at the
+// time and recording the change in the audit log on behalf of actor
+func (s *PunchImportService) apply(tx *sql.Tx, session punchSession, actor models.AuditActor, report *models.PunchImportReport) error {
+	now := time.Now()
+	clockIn := session.in.timestamp
+
+	attendance := models.Attendance{EmployeeID: session.employeeID}
+	err := tx.QueryRow(`
+		SELECT id, attendance_id, clock_in, clock_out, created_at, updated_at FROM attendance
+		WHERE employee_id = ? AND clock_in = ?
+	`, session.employeeID, clockIn).Scan(
+		&attendance.ID, &attendance.AttendanceID, &attendance.ClockIn, &attendance.ClockOut,
+		&attendance.CreatedAt, &attendance.UpdatedAt,
+	)
+
+	change := models.AuditChange{EntityType: models.AuditEntityAttendance}
+	switch {
+	case err == sql.ErrNoRows:
+		attendanceID := uuid.NewSHA1(legacyAttendanceNamespace,
+			[]byte(session.employeeID+"|"+clockIn.UTC().Format(time.RFC3339))).String()
+
+		id, err := database.TxInsertReturningID(s.db, tx, `
+			INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, session.employeeID, attendanceID, clockIn, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to import clock in on row %d: %v", session.in.row, err)
+		}
+
+		eval, err := EvaluatePunchOn(tx, session.employeeID, 1, clockIn)
+		if err != nil {
+			return err
+		}
+		_, err = RecordPunch(s.db, tx, session.employeeID, attendanceID, 1, clockIn, eval, now)
+		if err != nil {
+			return fmt.Errorf("failed to import clock in history on row %d: %v", session.in.row, err)
+		}
+
+		attendance = models.Attendance{
+			ID:           int(id),
+			EmployeeID:   session.employeeID,
+			AttendanceID: attendanceID,
+			ClockIn:      clockIn,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		change.Action = models.AuditActionCreate
+		report.Imported++
+	case err != nil:
+		return fmt.Errorf("failed to check existing attendance on row %d: %v", session.in.row, err)
+	case attendance.ClockOut == nil && session.out != nil:
+		change.Action = models.AuditActionUpdate
+		change.Before = attendance
+		report.Updated++
+	default:
+		report.AlreadyImported++
+		return nil
+	}
+
+	if session.out != nil {
+		if err := s.recordOut(tx, attendance.AttendanceID, *session.out, now); err != nil {
+			return err
+		}
+		out := session.out.timestamp
+		attendance.ClockOut = &out
+		attendance.UpdatedAt = now
+	}
+
+	change.EntityID = attendance.AttendanceID
+	change.After = attendance
+	if err := NewAuditLog(s.db).Append(tx, actor, change); err != nil {
+		return fmt.Errorf("failed to audit row %d: %v", session.in.row, err)
+	}
+	return nil
+}
+
+// closeOpen pairs a clock-out that has no clock-in in the file with the
+// employee's open attendance from an earlier import, the latest clock-in at
+// most maxSessionLength before it. A clock-out already recorded is skipped;
+// one with nothing to close is reported as unpaired.
+func (s *PunchImportService) closeOpen(tx *sql.Tx, out punch, actor models.AuditActor, report *models.PunchImportReport) error {
+	now := time.Now()
+
+	var imported int
+	err := tx.QueryRow("SELECT COUNT(*) FROM attendance WHERE employee_id = ? AND clock_out = ?", out.employeeID, out.timestamp).
+		Scan(&imported)
+	if err != nil {
+		return fmt.Errorf("failed to check existing attendance on row %d: %v", out.row, err)
+	}
+	if imported > 0 {
+		report.AlreadyImported++
+		return nil
+	}
+
+	var attendance models.Attendance
+	err = tx.QueryRow(`
+		SELECT id, employee_id, attendance_id, clock_in, clock_out, created_at, updated_at FROM attendance
+		WHERE employee_id = ? AND clock_in <= ? AND clock_in >= ?
+		ORDER BY clock_in DESC
+		LIMIT 1
+	`, out.employeeID, out.timestamp, out.timestamp.Add(-maxSessionLength)).Scan(
+		&attendance.ID, &attendance.EmployeeID, &attendance.AttendanceID, &attendance.ClockIn, &attendance.ClockOut,
+		&attendance.CreatedAt, &attendance.UpdatedAt,
+	)
+	if err == sql.ErrNoRows || err == nil && attendance.ClockOut != nil {
+		report.Issues = append(report.Issues, punchIssue(out, models.PunchIssueUnpairedOut))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find open attendance on row %d: %v", out.row, err)
+	}
+	before := attendance
+
+	if err := s.recordOut(tx, attendance.AttendanceID, out, now); err != nil {
+		return err
+	}
+	attendance.ClockOut = &out.timestamp
+	attendance.UpdatedAt = now
+
+	change := models.AuditChange{
+		EntityType: models.AuditEntityAttendance,
+		EntityID:   attendance.AttendanceID,
+		Action:     models.AuditActionUpdate,
+		Before:     before,
+		After:      attendance,
+	}
+	if err := NewAuditLog(s.db).Append(tx, actor, change); err != nil {
+		return fmt.Errorf("failed to audit row %d: %v", out.row, err)
+	}
+	report.Updated++
+	return nil
+}
+
+// recordOut sets out as the clock-out of attendanceID and records it in the
+// attendance history
+func (s *PunchImportService) recordOut(tx *sql.Tx, attendanceID string, out punch, now time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE attendance SET clock_out = ?, updated_at = ?
+		WHERE attendance_id = ?
+	`, out.timestamp, now, attendanceID)
+	if err != nil {
+		return fmt.Errorf("failed to import clock out on row %d: %v", out.row, err)
+	}
+
+	eval, err := EvaluatePunchOn(tx, out.employeeID, 2, out.timestamp)
+	if err != nil {
+		return err
+	}
+	_, err = RecordPunch(s.db, tx, out.employeeID, attendanceID, 2, out.timestamp, eval, now)
+	if err != nil {
+		return fmt.Errorf("failed to import clock out history on row %d: %v", out.row, err)
+	}
+	return nil
+}
+
+// employeeIDs returns the set of known employee IDs
+func (s *PunchImportService) employeeIDs() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT employee_id FROM employee")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %v", err)
+	}
+	defer rows.Close()
+
+	employees := make(map[string]bool)
+	for rows.Next() {
+		var employeeID string
+		if err := rows.Scan(&employeeID); err != nil {
+			return nil, fmt.Errorf("failed to read employee: %v", err)
+		}
+		employees[employeeID] = true
+	}
+
+	return employees, rows.Err()
+}
+
+// parsePunches validates each row, recording errors for bad rows and
+// duplicate issues for repeated punches
+func parsePunches(rows [][]string, employees map[string]bool, report *models.PunchImportReport) []punch {
+	if len(rows) == 0 {
+		report.Errors = append(report.Errors, models.ImportRowError{Row: 1, Message: "file is empty"})
+		report.ErrorRows = 1
+		return nil
+	}
+
+	index := headerIndex(rows[0])
+	columns := map[string]string{}
+	for name, aliases := range map[string][]string{
+		"employee code": punchColumnEmployee,
+		"timestamp":     punchColumnTimestamp,
+		"direction":     punchColumnDirection,
+	} {
+		for _, alias := range aliases {
+			if _, ok := index[alias]; ok {
+				columns[name] = alias
+				break
+			}
+		}
+		if columns[name] == "" {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: 1, Field: name, Message: "missing column"})
+		}
+	}
+	if len(report.Errors) > 0 {
+		report.ErrorRows = 1
+		return nil
+	}
+
+	seen := make(map[string]int)
+	var punches []punch
+	for i, values := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(values) {
+			continue
+		}
+		report.TotalRows++
+
+		employeeID := cell(values, index, columns["employee code"])
+		rawTimestamp := cell(values, index, columns["timestamp"])
+		rawDirection := cell(values, index, columns["direction"])
+
+		var rowErrors []models.ImportRowError
+		if employeeID == "" {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rowNumber, Field: "employee code", Message: "required"})
+		} else if !employees[employeeID] {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rowNumber, Field: "employee code", Value: employeeID, Message: "unknown employee"})
+		}
+
+		timestamp, err := parsePunchTimestamp(rawTimestamp)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rowNumber, Field: "timestamp", Value: rawTimestamp, Message: err.Error()})
+		}
+
+		clockIn, err := parsePunchDirection(rawDirection)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rowNumber, Field: "direction", Value: rawDirection, Message: err.Error()})
+		}
+
+		if len(rowErrors) > 0 {
+			report.ErrorRows++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+
+		p := punch{row: rowNumber, employeeID: employeeID, timestamp: timestamp, clockIn: clockIn}
+		key := fmt.Sprintf("%s|%d|%t", employeeID, timestamp.Unix(), clockIn)
+		if _, ok := seen[key]; ok {
+			report.Issues = append(report.Issues, punchIssue(p, models.PunchIssueDuplicate))
+			continue
+		}
+		seen[key] = rowNumber
+		punches = append(punches, p)
+	}
+
+	return punches
+}
+
+// pairPunches sorts each employee's punches by time and pairs every clock-in
+// with the clock-out that follows it. A clock-in with no matching clock-out is
+// reported and kept as an open session. Clock-outs with no preceding clock-in
+// are returned separately, to close sessions opened by an earlier import.
+func pairPunches(punches []punch, report *models.PunchImportReport) ([]punchSession, []punch) {
+	sort.SliceStable(punches, func(i, j int) bool {
+		if punches[i].employeeID != punches[j].employeeID {
+			return punches[i].employeeID < punches[j].employeeID
+		}
+		return punches[i].timestamp.Before(punches[j].timestamp)
+	})
+
+	var sessions []punchSession
+	var outs []punch
+	var pending *punch
+	flush := func() {
+		if pending != nil {
+			report.Issues = append(report.Issues, punchIssue(*pending, models.PunchIssueUnpairedIn))
+			sessions = append(sessions, punchSession{employeeID: pending.employeeID, in: *pending})
+			pending = nil
+		}
+	}
+
+	for i := range punches {
+		p := punches[i]
+		if pending != nil && pending.employeeID != p.employeeID {
+			flush()
+		}
+
+		if p.clockIn {
+			flush()
+			pending = &p
+			continue
+		}
+
+		if pending != nil && p.timestamp.Sub(pending.timestamp) > maxSessionLength {
+			flush()
+		}
+		if pending == nil {
+			outs = append(outs, p)
+			continue
+		}
+
+		sessions = append(sessions, punchSession{employeeID: p.employeeID, in: *pending, out: &p})
+		pending = nil
+	}
+	flush()
+
+	return sessions, outs
+}
+
+func punchIssue(p punch, issue string) models.PunchIssue {
+	direction := "out"
+	if p.clockIn {
+		direction = "in"
+	}
+	return models.PunchIssue{
+		Row:        p.row,
+		EmployeeID: p.employeeID,
+		Timestamp:  p.timestamp,
+		Direction:  direction,
+		Issue:      issue,
+	}
+}
+
+// parsePunchTimestamp parses a time clock timestamp in local time, truncated
+// to whole seconds to match the attendance columns
+func parsePunchTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("required")
+	}
+	for _, layout := range punchTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t.Truncate(time.Second), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("must be YYYY-MM-DD HH:MM:SS")
+}
+
+// parsePunchDirection reports whether a direction value means clock-in
+func parsePunchDirection(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "in", "i", "1", "clock in", "clock-in":
+		return true, nil
+	case "out", "o", "2", "clock out", "clock-out":
+		return false, nil
+	case "":
+		return false, fmt.Errorf("required")
+	default:
+		return false, fmt.Errorf("must be in or out")
+	}
+}
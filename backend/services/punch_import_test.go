@@ -0,0 +1,164 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairPunches(t *testing.T) {
+	employees := map[string]bool{"EMP001": true, "EMP002": true}
+	rows := [][]string{
+		{"Employee Code", "Timestamp", "Direction"},
+		{"EMP001", "2024-01-15 08:20:00", "IN"},
+		{"EMP001", "2024-01-15 17:45:00", "OUT"},
+		{"EMP001", "2024-01-15 17:45:00", "OUT"},
+		{"EMP002", "2024-01-15 17:05:00", "out"},
+		{"EMP002", "2024-01-16 07:55:00", "in"},
+		{"EMP001", "2024-01-16 08:40:00", "in"},
+		{"EMP001", "2024-01-16 17:10:00", "out"},
+	}
+
+	report := &models.PunchImportReport{}
+	sessions, outs := pairPunches(parsePunches(rows, employees, report), report)
+
+	assert.Equal(t, 0, report.ErrorRows)
+	assert.Len(t, sessions, 3)
+	if assert.Len(t, outs, 1) {
+		assert.Equal(t, 5, outs[0].row)
+	}
+
+	assert.Equal(t, "EMP001", sessions[0].employeeID)
+	assert.Equal(t, 2, sessions[0].in.row)
+	assert.Equal(t, 3, sessions[0].out.row)
+	assert.Equal(t, 7, sessions[1].in.row)
+	assert.Equal(t, 8, sessions[1].out.row)
+	assert.Equal(t, "EMP002", sessions[2].employeeID)
+	assert.Nil(t, sessions[2].out)
+
+	issues := map[int]string{}
+	for _, issue := range report.Issues {
+		issues[issue.Row] = issue.Issue
+	}
+	assert.Equal(t, map[int]string{
+		4: models.PunchIssueDuplicate,
+		6: models.PunchIssueUnpairedIn,
+	}, issues)
+}
+
+func TestParsePunchesErrors(t *testing.T) {
+	employees := map[string]bool{"EMP001": true}
+	rows := [][]string{
+		{"employee_id", "time", "type"},
+		{"EMP404", "2024-01-15 08:20:00", "in"},
+		{"EMP001", "15/01/2024", "in"},
+		{"EMP001", "2024-01-15 08:20:00", "sideways"},
+	}
+
+	report := &models.PunchImportReport{}
+	punches := parsePunches(rows, employees, report)
+
+	assert.Empty(t, punches)
+	assert.Equal(t, 3, report.ErrorRows)
+}
+
+func TestPunctuality(t *testing.T) {
+	at := func(clock string) time.Time {
+		ts, _ := time.ParseInLocation("2006-01-02 15:04:05", "2024-01-15 "+clock, time.Local)
+		return ts
+	}
+
+	assert.True(t, IsClockInOnTime(at("08:30:00"), "08:30:00"))
+	assert.False(t, IsClockInOnTime(at("08:30:01"), "08:30:00"))
+	assert.True(t, IsClockInOnTime(at("09:00:00"), ""))
+	assert.True(t, IsClockOutOnTime(at("17:30:00"), "17:30:00"))
+	assert.False(t, IsClockOutOnTime(at("17:29:59"), "17:30:00"))
+	assert.Equal(t, "Clock In (Late)", ClockInDescription(false))
+	assert.Equal(t, "Clock Out (Early)", ClockOutDescription(false))
+}
+
+func TestPunchImportIsIdempotent(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, created_at, updated_at)
+		VALUES ('A', 1, 'Employee A', '', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+		VALUES ('A', 1, '2023-06-01', ?)`, created)
+	assert.NoError(t, err)
+
+	header := []string{"Employee Code", "Timestamp", "Direction"}
+	first := [][]string{
+		header,
+		{"A", "2024-01-15 08:50:00", "in"},
+		{"A", "2024-01-15 17:05:00", "out"},
+		{"A", "2024-01-16 08:55:00", "in"},
+	}
+	// Overlaps the first file and closes the session it left open
+	second := [][]string{
+		header,
+		{"A", "2024-01-15 17:05:00", "out"},
+		{"A", "2024-01-16 17:10:00", "out"},
+		{"A", "2024-01-17 09:20:00", "in"},
+		{"A", "2024-01-17 17:00:00", "out"},
+	}
+	counts := func() [2]int {
+		var attendance, history int
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM attendance").Scan(&attendance))
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM attendance_history").Scan(&history))
+		return [2]int{attendance, history}
+	}
+	service := NewPunchImportService(db)
+	actor := models.AuditActor{Actor: "test"}
+
+	report, err := service.Import(first, models.ImportModeCommit, actor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, [2]int{2, 3}, counts())
+
+	report, err = service.Import(first, models.ImportModeCommit, actor)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 0, report.Updated)
+	assert.Equal(t, 2, report.AlreadyImported)
+	assert.Equal(t, [2]int{2, 3}, counts())
+
+	report, err = service.Import(second, models.ImportModeCommit, actor)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.AlreadyImported)
+	for _, issue := range report.Issues {
+		assert.NotEqual(t, models.PunchIssueUnpairedOut, issue.Issue, "row %d", issue.Row)
+	}
+	assert.Equal(t, [2]int{3, 6}, counts())
+
+	var clockOut sql.NullTime
+	assert.NoError(t, db.QueryRow("SELECT clock_out FROM attendance WHERE clock_in = ?",
+		time.Date(2024, 1, 16, 8, 55, 0, 0, time.Local)).Scan(&clockOut))
+	if assert.True(t, clockOut.Valid) {
+		assert.True(t, clockOut.Time.Equal(time.Date(2024, 1, 16, 17, 10, 0, 0, time.Local)))
+	}
+
+	report, err = service.Import(second, models.ImportModeCommit, actor)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 0, report.Updated)
+	assert.Equal(t, 3, report.AlreadyImported)
+	assert.Equal(t, [2]int{3, 6}, counts())
+
+	// A clock-out with nothing open to close is still reported
+	report, err = service.Import([][]string{header, {"A", "2024-01-20 17:00:00", "out"}}, models.ImportModeCommit, actor)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Issues, 1) {
+		assert.Equal(t, models.PunchIssueUnpairedOut, report.Issues[0].Issue)
+	}
+	assert.Equal(t, [2]int{3, 6}, counts())
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"time"
+
+	"attendance-system/models"
+)
+
+// IsClockInOnTime reports whether a clock-in at t is at or before the
+// department's max clock-in time (HH:MM:SS) on the same day. An empty or
+// unparseable limit counts as on time.
+func IsClockInOnTime(t time.Time, maxClockInTime string) bool {
+	limit, ok := limitOnDay(t, maxClockInTime)
+	if !ok {
+		return true
+	}
+	current := t.Truncate(time.Second)
+	return current.Before(limit) || current.Equal(limit)
+}
+
+// IsClockOutOnTime reports whether a clock-out at t is at or after the
+// department's max clock-out time (HH:MM:SS) on the same day. An empty or
+// unparseable limit counts as on time.
+func IsClockOutOnTime(t time.Time, maxClockOutTime string) bool {
+	limit, ok := limitOnDay(t, maxClockOutTime)
+	if !ok {
+		return true
+	}
+	current := t.Truncate(time.Second)
+	return current.After(limit) || current.Equal(limit)
+}
+
+// LateMinutes returns how many minutes, rounded up, a clock-in at t is past
+// the max clock-in time, or 0 when it is on time
+func LateMinutes(t time.Time, maxClockInTime string) int {
+	limit, ok := limitOnDay(t, maxClockInTime)
+	if !ok {
+		return 0
+	}
+	return minutesBetween(limit, t.Truncate(time.Second))
+}
+
+// EarlyMinutes returns how many minutes, rounded up, a clock-out at t is
+// before the max clock-out time, or 0 when it is on time
+func EarlyMinutes(t time.Time, maxClockOutTime string) int {
+	limit, ok := limitOnDay(t, maxClockOutTime)
+	if !ok {
+		return 0
+	}
+	return minutesBetween(t.Truncate(time.Second), limit)
+}
+
+// minutesBetween returns the whole minutes from start to end, rounded up, or
+// 0 when end is not after start
+func minutesBetween(start, end time.Time) int {
+	if !end.After(start) {
+		return 0
+	}
+	return int((end.Sub(start) + time.Minute - 1) / time.Minute)
+}
+
+// limitOnDay places an HH:MM:SS limit on the calendar day of t
+func limitOnDay(t time.Time, limit string) (time.Time, bool) {
+	if limit == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse("15:04:05", limit)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, t.Location()), true
+}
+
+// ClockInDescription returns the attendance_history description for a clock-in
+func ClockInDescription(isOnTime bool) string {
+	if isOnTime {
+		return "Clock In"
+	}
+	return "Clock In (Late)"
+}
+
+// ClockOutDescription returns the attendance_history description for a clock-out
+func ClockOutDescription(isOnTime bool) string {
+	if isOnTime {
+		return "Clock Out"
+	}
+	return "Clock Out (Early)"
+}
+
+// PunchEvaluation is a punch evaluated against the working-time contract
+// the employee worked under or, without one, the schedule of the department
+// they belonged to, as stored on attendance_history. ContractID is 0 for the
+// department schedule; limits are empty where none applied.
+type PunchEvaluation struct {
+	DepartementID   int
+	ContractID      int
+	MaxClockInTime  string
+	MaxClockOutTime string
+	IsOnTime        bool
+	LateMinutes     int
+	EarlyMinutes    int
+	Description     string
+}
+
+// EvaluatePunch evaluates a punch of attendanceType (1 = in, 2 = out) at t
+// against contract, when the employee has one, and otherwise against dept's
+// schedule. Under a contract clocking in after the start window is late,
+// clocking out before the end of core hours is early and punches on days
+// off are always on time.
+func EvaluatePunch(attendanceType int, t time.Time, dept models.Department, contract *models.WorkingTimeContract) PunchEvaluation {
+	eval := PunchEvaluation{
+		DepartementID:   dept.ID,
+		MaxClockInTime:  dept.MaxClockInTime,
+		MaxClockOutTime: dept.MaxClockOutTime,
+	}
+	if contract != nil {
+		eval.ContractID = contract.ID
+		eval.MaxClockInTime, eval.MaxClockOutTime = contractLimits(contract, t)
+	}
+	if attendanceType == 2 {
+		eval.IsOnTime = IsClockOutOnTime(t, eval.MaxClockOutTime)
+		eval.EarlyMinutes = EarlyMinutes(t, eval.MaxClockOutTime)
+		eval.Description = ClockOutDescription(eval.IsOnTime)
+	} else {
+		eval.IsOnTime = IsClockInOnTime(t, eval.MaxClockInTime)
+		eval.LateMinutes = LateMinutes(t, eval.MaxClockInTime)
+		eval.Description = ClockInDescription(eval.IsOnTime)
+	}
+	return eval
+}
+
+// nullIfEmpty stores an empty limit as NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullIfZero stores a zero ID as NULL
+func nullIfZero(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrReportScheduleNotFound is returned when a report schedule does not exist
+var ErrReportScheduleNotFound = errors.New("report schedule not found")
+
+// ReportScheduler runs stored report schedules and emails the results
+type ReportScheduler struct {
+	db         *sql.DB
+	mailer     Mailer
+	csvService *CSVExportService
+	cron       *cron.Cron
+	running    atomic.Bool
+
+	mu      sync.Mutex
+	entries map[int]cron.EntryID
+}
+
+// NewReportScheduler creates a new report scheduler
+func NewReportScheduler(db *sql.DB, mailer Mailer) *ReportScheduler {
+	return &ReportScheduler{
+		db:         db,
+		mailer:     mailer,
+		csvService: NewCSVExportService(),
+		cron:       cron.New(),
+		entries:    make(map[int]cron.EntryID),
+	}
+}
+
+// ValidateCronExpr checks that expr is a standard five-field cron expression
+func ValidateCronExpr(expr string) error {
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return nil
+}
+
+// Start registers every enabled schedule and starts the scheduler
+func (s *ReportScheduler) Start() error {
+	rows, err := s.db.Query("SELECT id FROM report_schedule WHERE enabled = ?", true)
+	if err != nil {
+		return fmt.Errorf("failed to load report schedules: %v", err)
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("failed to read report schedule", "error", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := s.Reload(id); err != nil {
+			slog.Warn("report schedule not scheduled", "schedule_id", id, "error", err)
+		}
+	}
+
+	s.cron.Start()
+	s.running.Store(true)
+	return nil
+}
+
+// Stop stops the scheduler and waits for running reports to finish, or
+// until ctx is done
+func (s *ReportScheduler) Stop(ctx context.Context) error {
+	s.running.Store(false)
+	select {
+	case <-s.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("report scheduler did not stop: %v", ctx.Err())
+	}
+}
+
+// Check returns an error unless the scheduler has been started and not
+// stopped
+func (s *ReportScheduler) Check() error {
+	if !s.running.Load() {
+		return errors.New("report scheduler is not running")
+	}
+	return nil
+}
+
+// Reload re-reads a schedule from the database and (re)registers it
+func (s *ReportScheduler) Reload(id int) error {
+	s.Remove(id)
+
+	schedule, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !schedule.Enabled {
+		return nil
+	}
+
+	entryID, err := s.cron.AddFunc(schedule.CronExpr, func() {
+		if err := s.runScheduled(id, time.Now().Truncate(time.Minute)); err != nil {
+			slog.Error("report schedule failed", "schedule_id", id, "error", err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %v", err)
+	}
+
+	s.mu.Lock()
+	s.entries[id] = entryID
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Remove unregisters a schedule
+func (s *ReportScheduler) Remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID, ok := s.entries[id]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+}
+
+// Run generates the report for a schedule and emails it to the recipients
+func (s *ReportScheduler) Run(id int) error {
+	schedule, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.run(schedule, time.Now())
+}
+
+// runScheduled runs a schedule for the cron tick at slot. Every replica
+// fires the tick, so only the one whose claim on last_run_at succeeds
+// sends the report.
+func (s *ReportScheduler) runScheduled(id int, slot time.Time) error {
+	claimed, err := s.claim(id, slot)
+	if err != nil || !claimed {
+		return err
+	}
+
+	schedule, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.run(schedule, slot)
+}
+
+// claim marks the tick at slot as run unless the schedule has been disabled
+// or has already run at or after slot. It reports whether the row was
+// updated.
+func (s *ReportScheduler) claim(id int, slot time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE report_schedule SET last_run_at = ?
+		WHERE id = ? AND enabled = ? AND (last_run_at IS NULL OR last_run_at < ?)
+	`, slot, id, true, slot)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim report schedule run: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim report schedule run: %v", err)
+	}
+	return rows == 1, nil
+}
+
+// run delivers a schedule's report and records the outcome as the run at
+// ranAt
+func (s *ReportScheduler) run(schedule *models.ReportSchedule, ranAt time.Time) error {
+	id := schedule.ID
+	runErr := s.deliver(schedule, time.Now())
+
+	var lastRunError interface{}
+	if runErr != nil {
+		lastRunError = runErr.Error()
+	}
+	_, err := s.db.Exec(`
+		UPDATE report_schedule SET last_run_at = ?, last_run_error = ?
+		WHERE id = ?
+	`, ranAt, lastRunError, id)
+	if err != nil {
+		slog.Error("failed to record report schedule run", "schedule_id", id, "error", err)
+	}
+
+	return runErr
+}
+
+// deliver builds the report attachment and sends it
+func (s *ReportScheduler) deliver(schedule *models.ReportSchedule, now time.Time) error {
+	if schedule.Format != models.ReportFormatCSV {
+		return fmt.Errorf("unsupported report format %q", schedule.Format)
+	}
+	filters := reportExportFilters(schedule, now)
+
+	tmpDir, err := os.MkdirTemp("", "report")
+	if err != nil {
+		return fmt.Errorf("failed to create report directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filename := s.csvService.GenerateFilename(schedule.ReportType)
+	path := filepath.Join(tmpDir, filename)
+
+	var title string
+	var records int
+	if schedule.ReportType == models.ReportAbsences {
+		absences, err := QueryAbsences(s.db, filters)
+		if err != nil {
+			return err
+		}
+		title = "Absences"
+		records = len(absences)
+		if err := s.csvService.ExportAbsences(absences, path); err != nil {
+			return err
+		}
+	} else if schedule.ReportType == models.ReportAttendanceSummary {
+		logs, err := QueryAttendanceLogs(s.db, filters)
+		if err != nil {
+			return err
+		}
+		title = "Attendance summary"
+		summaries := SummarizeAttendance(logs)
+		records = len(summaries)
+		if err := s.csvService.ExportAttendanceSummary(summaries, path); err != nil {
+			return err
+		}
+	} else {
+		logs, err := QueryAttendanceLogs(s.db, filters)
+		if err != nil {
+			return err
+		}
+		title = "Late arrivals"
+		logs = filterLateArrivals(logs)
+		records = len(logs)
+		if err := s.csvService.ExportAttendanceLogs(logs, path); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %v", err)
+	}
+
+	period := filters.StartDate
+	if filters.EndDate != filters.StartDate {
+		period = filters.StartDate + " to " + filters.EndDate
+	}
+
+	return s.mailer.Send(EmailMessage{
+		To:      schedule.Recipients,
+		Subject: fmt.Sprintf("%s report - %s", title, period),
+		Body: fmt.Sprintf("%s report \"%s\" for %s.\r\n\r\n%d record(s) attached.\r\n",
+			title, schedule.Name, period, records),
+		Attachments: []EmailAttachment{{
+			Filename:    filename,
+			ContentType: "text/csv",
+			Data:        data,
+		}},
+	})
+}
+
+// Get retrieves a report schedule by ID
+func (s *ReportScheduler) Get(id int) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	var filters, recipients string
+	var lastRunError sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, name, cron_expr, report_type, filters, recipients, format, enabled,
+		       last_run_at, last_run_error, created_at, updated_at
+		FROM report_schedule
+		WHERE id = ?
+	`, id).Scan(
+		&schedule.ID, &schedule.Name, &schedule.CronExpr, &schedule.ReportType, &filters,
+		&recipients, &schedule.Format, &schedule.Enabled, &schedule.LastRunAt, &lastRunError,
+		&schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrReportScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch report schedule: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(filters), &schedule.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode filters: %v", err)
+	}
+	schedule.Recipients = SplitRecipients(recipients)
+	schedule.LastRunError = lastRunError.String
+
+	return &schedule, nil
+}
+
+// reportExportFilters resolves a schedule's lookback window into dates
+func reportExportFilters(schedule *models.ReportSchedule, now time.Time) models.ExportFilters {
+	days := schedule.Filters.LookbackDays
+	if days < 1 {
+		days = 1
+		if schedule.ReportType == models.ReportAttendanceSummary {
+			days = 7
+		}
+	}
+
+	return models.ExportFilters{
+		StartDate:    now.AddDate(0, 0, -(days - 1)).Format("2006-01-02"),
+		EndDate:      now.Format("2006-01-02"),
+		DepartmentID: schedule.Filters.DepartmentID,
+		ManagerID:    schedule.Filters.ManagerID,
+		DirectOnly:   schedule.Filters.DirectOnly,
+	}
+}
+
+// filterLateArrivals keeps only clock-ins after the department limit
+func filterLateArrivals(logs []models.AttendanceLog) []models.AttendanceLog {
+	var late []models.AttendanceLog
+	for _, log := range logs {
+		if log.AttendanceType == 1 && !log.IsOnTime {
+			late = append(late, log)
+		}
+	}
+	return late
+}
+
+// SummarizeAttendance totals logs per employee: the days they clocked in on,
+// their late clock-ins and early clock-outs and the minutes these were off
+// by. Employees are sorted by name; the department is the one of their latest
+// log.
+func SummarizeAttendance(logs []models.AttendanceLog) []models.AttendanceSummary {
+	byEmployee := make(map[string]*models.AttendanceSummary)
+	days := make(map[string]map[string]bool)
+	latest := make(map[string]time.Time)
+	for _, log := range logs {
+		summary, ok := byEmployee[log.EmployeeID]
+		if !ok {
+			summary = &models.AttendanceSummary{EmployeeID: log.EmployeeID}
+			byEmployee[log.EmployeeID] = summary
+			days[log.EmployeeID] = make(map[string]bool)
+		}
+		if !ok || log.DateAttendance.After(latest[log.EmployeeID]) {
+			latest[log.EmployeeID] = log.DateAttendance
+			summary.EmployeeName = log.EmployeeName
+			summary.DepartmentName = log.DepartmentName
+		}
+
+		switch log.AttendanceType {
+		case 1:
+			days[log.EmployeeID][log.DateAttendance.Format("2006-01-02")] = true
+			if !log.IsOnTime {
+				summary.LateArrivals++
+				summary.LateMinutes += log.LateMinutes
+			}
+		case 2:
+			if !log.IsOnTime {
+				summary.EarlyLeaves++
+				summary.EarlyMinutes += log.EarlyMinutes
+			}
+		}
+	}
+
+	summaries := make([]models.AttendanceSummary, 0, len(byEmployee))
+	for employeeID, summary := range byEmployee {
+		summary.DaysPresent = len(days[employeeID])
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].EmployeeName != summaries[j].EmployeeName {
+			return summaries[i].EmployeeName < summaries[j].EmployeeName
+		}
+		return summaries[i].EmployeeID < summaries[j].EmployeeID
+	})
+	return summaries
+}
+
+// JoinRecipients encodes a recipient list for storage
+func JoinRecipients(recipients []string) string {
+	return strings.Join(recipients, ",")
+}
+
+// SplitRecipients decodes a stored recipient list
+func SplitRecipients(recipients string) []string {
+	var result []string
+	for _, r := range strings.Split(recipients, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
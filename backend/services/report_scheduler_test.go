@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sync"
+	"testing"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMailer is a Mailer keeping the messages it is asked to send
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+func (m *recordingMailer) Send(msg EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func TestSummarizeAttendance(t *testing.T) {
+	day := func(d, hour, minute int) time.Time { return time.Date(2024, 1, d, hour, minute, 0, 0, time.Local) }
+	logs := []models.AttendanceLog{
+		{EmployeeID: "B", EmployeeName: "Bea", DepartmentName: "Sales", AttendanceType: 1, DateAttendance: day(3, 8, 0), IsOnTime: true},
+		{EmployeeID: "A", EmployeeName: "Ann", DepartmentName: "IT", AttendanceType: 2, DateAttendance: day(3, 16, 0), EarlyMinutes: 60},
+		{EmployeeID: "A", EmployeeName: "Ann", DepartmentName: "IT", AttendanceType: 1, DateAttendance: day(3, 9, 5), LateMinutes: 5},
+		{EmployeeID: "A", EmployeeName: "Ann", DepartmentName: "Old", AttendanceType: 1, DateAttendance: day(2, 9, 15), LateMinutes: 15},
+	}
+
+	assert.Equal(t, []models.AttendanceSummary{
+		{EmployeeID: "A", EmployeeName: "Ann", DepartmentName: "IT", DaysPresent: 2, LateArrivals: 2, EarlyLeaves: 1,
+			LateMinutes: 20, EarlyMinutes: 60},
+		{EmployeeID: "B", EmployeeName: "Bea", DepartmentName: "Sales", DaysPresent: 1},
+	}, SummarizeAttendance(logs))
+}
+
+func TestReportSchedulerRunSendsSummary(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	for _, employee := range [][2]string{{"A", "Ann"}, {"B", "Bea"}} {
+		_, err = db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, created_at, updated_at)
+			VALUES (?, 1, ?, '', ?, ?)`, employee[0], employee[1], created, created)
+		assert.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+			VALUES (?, 1, '2023-06-01', ?)`, employee[0], created)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	at := func(daysAgo, hour, minute int) time.Time {
+		d := now.AddDate(0, 0, -daysAgo)
+		return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, time.Local)
+	}
+	punches := []struct {
+		employeeID     string
+		attendanceType int
+		at             time.Time
+	}{
+		{"A", 1, at(2, 9, 10)},
+		{"A", 2, at(2, 17, 0)},
+		{"A", 1, at(1, 8, 50)},
+		{"A", 2, at(1, 16, 30)},
+		{"B", 1, at(1, 8, 55)},
+		{"B", 2, at(1, 17, 5)},
+	}
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	for _, punch := range punches {
+		attendanceID := punch.employeeID + punch.at.Format("-2006-01-02")
+		if punch.attendanceType == 1 {
+			_, err = tx.Exec(`INSERT INTO attendance (employee_id, attendance_id, clock_in, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?)`, punch.employeeID, attendanceID, punch.at, punch.at, punch.at)
+			assert.NoError(t, err)
+		}
+		eval, err := EvaluatePunchOn(tx, punch.employeeID, punch.attendanceType, punch.at)
+		assert.NoError(t, err)
+		_, err = RecordPunch(db, tx, punch.employeeID, attendanceID, punch.attendanceType, punch.at, eval, punch.at)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tx.Commit())
+
+	id, err := database.InsertReturningID(db, `
+		INSERT INTO report_schedule (name, cron_expr, report_type, filters, recipients, format, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, "Weekly", "0 8 * * 1", models.ReportAttendanceSummary, "{}", JoinRecipients([]string{"hr@example.com", "ops@example.com"}),
+		"csv", true, created, created)
+	assert.NoError(t, err)
+
+	mailer := &recordingMailer{}
+	scheduler := NewReportScheduler(db, mailer)
+	assert.NoError(t, scheduler.Run(int(id)))
+
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	msg := mailer.messages[0]
+	assert.Equal(t, []string{"hr@example.com", "ops@example.com"}, msg.To)
+	assert.Contains(t, msg.Subject, "Attendance summary report")
+	assert.Contains(t, msg.Body, "2 record(s) attached")
+	if assert.Len(t, msg.Attachments, 1) {
+		assert.Equal(t, "text/csv", msg.Attachments[0].ContentType)
+		records, err := csv.NewReader(bytes.NewReader(msg.Attachments[0].Data)).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"No", "Employee ID", "Employee Name", "Department", "Days Present", "Late Arrivals", "Early Leaves",
+				"Late Minutes", "Early Minutes"},
+			{"1", "A", "Ann", "IT", "2", "1", "1", "10", "30"},
+			{"2", "B", "Bea", "IT", "1", "0", "0", "0", "0"},
+		}, records)
+	}
+
+	schedule, err := scheduler.Get(int(id))
+	assert.NoError(t, err)
+	assert.NotNil(t, schedule.LastRunAt)
+	assert.Empty(t, schedule.LastRunError)
+}
+
+func TestReportSchedulerSendsEachRunOnce(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	insert := func(format string) int {
+		id, err := database.InsertReturningID(db, `
+			INSERT INTO report_schedule (name, cron_expr, report_type, filters, recipients, format, enabled, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, "Daily", "0 8 * * *", models.ReportLateArrivals, "{}", "hr@example.com", format, true, created, created)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(id)
+	}
+	id := insert(models.ReportFormatCSV)
+
+	// Two replicas fire the same tick
+	mailer := &recordingMailer{}
+	replicas := []*ReportScheduler{NewReportScheduler(db, mailer), NewReportScheduler(db, mailer)}
+	slot := time.Now().Truncate(time.Minute)
+	for _, scheduler := range replicas {
+		assert.NoError(t, scheduler.runScheduled(id, slot))
+	}
+	assert.Len(t, mailer.messages, 1)
+
+	// The next tick is claimed again
+	assert.NoError(t, replicas[1].runScheduled(id, slot.Add(time.Minute)))
+	assert.NoError(t, replicas[0].runScheduled(id, slot.Add(time.Minute)))
+	assert.Len(t, mailer.messages, 2)
+
+	// A replica that missed the schedule being disabled sends nothing
+	_, err := db.Exec("UPDATE report_schedule SET enabled = ? WHERE id = ?", false, id)
+	assert.NoError(t, err)
+	assert.NoError(t, replicas[0].runScheduled(id, slot.Add(2*time.Minute)))
+	assert.Len(t, mailer.messages, 2)
+
+	// Formats other than CSV fail rather than send a CSV
+	other := insert("xlsx")
+	assert.Error(t, replicas[0].Run(other))
+	assert.Len(t, mailer.messages, 2)
+	schedule, err := replicas[0].Get(other)
+	assert.NoError(t, err)
+	assert.Contains(t, schedule.LastRunError, "unsupported report format")
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadSpreadsheet reads every row of a CSV or XLSX file. The format is chosen
+// from the file extension; only the first worksheet of a workbook is read.
+func ReadSpreadsheet(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %v", err)
+		}
+		return rows, nil
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open XLSX: %v", err)
+		}
+		defer workbook.Close()
+
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("XLSX file has no worksheets")
+		}
+		rows, err := workbook.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read XLSX: %v", err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type %q: expected .csv or .xlsx", filepath.Ext(filename))
+	}
+}
+
+// headerIndex maps normalised column names to their position in the header row
+func headerIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+// cell returns the trimmed value of a named column, or "" when absent
+func cell(row []string, index map[string]int, name string) string {
+	i, ok := index[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// isBlankRow reports whether every cell in the row is empty
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
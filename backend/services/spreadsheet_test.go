@@ -0,0 +1,52 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadSpreadsheet(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		data := "No,Employee ID,Name,Department\n1,EMP010,New Hire,IT Department\n"
+		rows, err := ReadSpreadsheet("employees.csv", strings.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"No", "Employee ID", "Name", "Department"},
+			{"1", "EMP010", "New Hire", "IT Department"},
+		}, rows)
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		workbook := excelize.NewFile()
+		sheet := workbook.GetSheetName(0)
+		workbook.SetSheetRow(sheet, "A1", &[]string{"Employee ID", "Name", "Department"})
+		workbook.SetSheetRow(sheet, "A2", &[]string{"EMP011", "Other Hire", "HR Department"})
+
+		var buf bytes.Buffer
+		assert.NoError(t, workbook.Write(&buf))
+
+		rows, err := ReadSpreadsheet("employees.XLSX", &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"Employee ID", "Name", "Department"},
+			{"EMP011", "Other Hire", "HR Department"},
+		}, rows)
+	})
+
+	t.Run("Unsupported Type", func(t *testing.T) {
+		_, err := ReadSpreadsheet("employees.txt", strings.NewReader(""))
+		assert.Error(t, err)
+	})
+}
+
+func TestHeaderIndex(t *testing.T) {
+	index := headerIndex([]string{"\ufeffNo", " Employee ID ", "Name"})
+	assert.Equal(t, 0, index["no"])
+	assert.Equal(t, 1, index["employee id"])
+	assert.Equal(t, "EMP001", cell([]string{"1", " EMP001 "}, index, "employee id"))
+	assert.Equal(t, "", cell([]string{"1", "EMP001"}, index, "name"))
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"attendance-system/database"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// testTables lists the tables cleaned between tests, children first
+var testTables = []string{
+	"attendance_history", "attendance", "working_time_contract", "department_assignment", "employee_custom_value",
+	"custom_field", "employee", "departement", "export_job", "report_schedule", "webhook_delivery", "webhook",
+	"event_outbox", "audit_log",
+}
+
+// testDialect returns the dialect of the test database, set with
+// TEST_DB_DRIVER
+func testDialect(t *testing.T) database.Dialect {
+	t.Helper()
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		return database.DialectSQLite
+	}
+	dialect, err := database.ParseDialect(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dialect
+}
+
+// openServicesTestDB returns a migrated, empty database for service tests.
+// SQLite in memory is used by default; as for the handler tests, set
+// TEST_DB_DRIVER to mysql or postgres and TEST_DB_DSN to use MySQL or
+// PostgreSQL.
+func openServicesTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dialect := testDialect(t)
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dialect == database.DialectSQLite && dsn == "" {
+		dsn = ":memory:"
+	}
+	if dsn == "" {
+		t.Skipf("TEST_DB_DSN is not set for %s", dialect)
+	}
+
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dialect == database.DialectSQLite {
+		// Every connection to :memory: is a separate database
+		db.SetMaxOpenConns(1)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range testTables {
+		if _, err := db.Exec("DELETE FROM " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The chain head row is seeded by its migration and only reset
+	if _, err := db.Exec("UPDATE audit_chain SET entries = 0, last_hash = ''"); err != nil {
+		t.Fatal(err)
+	}
+	// Fixtures insert departments with small explicit IDs, which PostgreSQL
+	// sequences do not skip
+	if dialect == database.DialectPostgres {
+		if _, err := db.Exec("SELECT setval(pg_get_serial_sequence('departement', 'id'), 100)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// timePrecision is how finely the test database stores times. MySQL
+// TIMESTAMP columns round to whole seconds, so waits on short delays are
+// lengthened by it.
+func timePrecision(t *testing.T) time.Duration {
+	if testDialect(t) == database.DialectMySQL {
+		return time.Second
+	}
+	return 0
+}
+
+// rejectInserts makes the database fail any insert into table whose column
+// equals value, until the test ends
+func rejectInserts(t *testing.T, db *sql.DB, table, column, value string) {
+	t.Helper()
+	name := "reject_" + table
+	var create, drop []string
+	switch testDialect(t) {
+	case database.DialectSQLite:
+		create = []string{fmt.Sprintf(`CREATE TRIGGER %s BEFORE INSERT ON %s WHEN NEW.%s = '%s'
+			BEGIN SELECT RAISE(ABORT, 'rejected'); END`, name, table, column, value)}
+		drop = []string{"DROP TRIGGER IF EXISTS " + name}
+	case database.DialectPostgres:
+		create = []string{
+			fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+				BEGIN IF NEW.%s = '%s' THEN RAISE EXCEPTION 'rejected'; END IF; RETURN NEW; END
+				$$ LANGUAGE plpgsql`, name, column, value),
+			fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT ON %s FOR EACH ROW EXECUTE FUNCTION %s()", name, table, name),
+		}
+		drop = []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", name, table),
+			fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", name),
+		}
+	case database.DialectMySQL:
+		create = []string{fmt.Sprintf(`CREATE TRIGGER %s BEFORE INSERT ON %s FOR EACH ROW
+			BEGIN IF NEW.%s = '%s' THEN SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'rejected'; END IF; END`,
+			name, table, column, value)}
+		drop = []string{"DROP TRIGGER IF EXISTS " + name}
+	}
+
+	for _, statement := range create {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, statement := range drop {
+			if _, err := db.Exec(statement); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
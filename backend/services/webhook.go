@@ -0,0 +1,512 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/metrics"
+	"attendance-system/models"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	// webhookMaxBackoff caps the delay between two attempts of a delivery
+	webhookMaxBackoff = 6 * time.Hour
+	// webhookBatchSize is how many due deliveries are claimed per poll
+	webhookBatchSize = 50
+)
+
+var (
+	// ErrWebhookNotFound is returned when a webhook does not exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound is returned when a webhook delivery does not exist
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookDispatcher stores a delivery for every webhook subscribed to a
+// published event and sends them from background workers. Failed deliveries
+// are retried with exponential backoff until maxAttempts, after which they
+// are marked dead and kept for redelivery.
+type WebhookDispatcher struct {
+	db          *sql.DB
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	// pollInterval is how often the workers look for due retries
+	pollInterval time.Duration
+
+	queue chan int
+	wake  chan struct{}
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	alive atomic.Int32
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher. backoff is the delay
+// before the first retry; each later retry waits twice as long as the one
+// before.
+func NewWebhookDispatcher(db *sql.DB, client *http.Client, workers, maxAttempts int, backoff time.Duration) *WebhookDispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &WebhookDispatcher{
+		db:           db,
+		client:       client,
+		workers:      workers,
+		maxAttempts:  maxAttempts,
+		backoff:      backoff,
+		pollInterval: time.Second,
+		queue:        make(chan int),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start launches the poller and the delivery workers. Deliveries still
+// pending from a previous process are sent once they are due.
+func (d *WebhookDispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+
+	d.wg.Add(1)
+	go d.poll()
+}
+
+// Stop signals the workers to exit and waits for in-flight deliveries, or
+// until ctx is done. Undelivered events stay pending for the next Start.
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	close(d.stop)
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook workers did not stop: %v", ctx.Err())
+	}
+}
+
+// CheckWorkers returns an error unless every delivery worker is running
+func (d *WebhookDispatcher) CheckWorkers() error {
+	if alive := int(d.alive.Load()); alive < d.workers {
+		return fmt.Errorf("%d of %d webhook workers running", alive, d.workers)
+	}
+	return nil
+}
+
+// Name implements EventSink
+func (d *WebhookDispatcher) Name() string { return "webhook" }
+
+// Send implements EventSink by queueing a delivery of event to every enabled
+// webhook subscribed to its type. Webhooks that already have a delivery of
+// the event are skipped, so an event relayed twice is delivered once.
+func (d *WebhookDispatcher) Send(ctx context.Context, event models.Event) error {
+	if err := d.enqueue(event); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) enqueue(event models.Event) error {
+	rows, err := d.db.Query("SELECT id, event_types FROM webhook WHERE enabled = ?", true)
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %v", err)
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		var eventTypes string
+		if err := rows.Scan(&id, &eventTypes); err != nil {
+			slog.Error("failed to read webhook", "error", err)
+			continue
+		}
+		for _, pattern := range SplitEventTypes(eventTypes) {
+			if MatchEventType(pattern, event.Type) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	now := time.Now()
+	// An event relayed again after a crash, or by two replicas at once, is
+	// queued once per webhook
+	for _, id := range ids {
+		_, err := d.db.Exec(`
+			INSERT INTO webhook_delivery (webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, 0, ?, ?)`+database.OnConflictDoNothing(d.db, "event_id"),
+			id, event.ID, event.Type, string(payload), models.WebhookDeliveryPending, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to create webhook delivery: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Redeliver schedules a delivery of webhookID to be sent again right away
+// with a fresh set of attempts, whatever its current status
+func (d *WebhookDispatcher) Redeliver(webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	result, err := d.db.Exec(`
+		UPDATE webhook_delivery SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND webhook_id = ?
+	`, models.WebhookDeliveryPending, time.Now(), deliveryID, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule redelivery: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+
+	return d.GetDelivery(webhookID, deliveryID)
+}
+
+// Get retrieves a webhook by ID, without its secret
+func (d *WebhookDispatcher) Get(id int) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var eventTypes string
+	err := d.db.QueryRow(`
+		SELECT id, url, event_types, enabled, created_at, updated_at
+		FROM webhook
+		WHERE id = ?
+	`, id).Scan(&webhook.ID, &webhook.URL, &eventTypes, &webhook.Enabled, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch webhook: %v", err)
+	}
+
+	webhook.EventTypes = SplitEventTypes(eventTypes)
+	return &webhook, nil
+}
+
+// GetDelivery retrieves a delivery of webhookID by ID
+func (d *WebhookDispatcher) GetDelivery(webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	deliveries, err := d.queryDeliveries("WHERE id = ? AND webhook_id = ?", deliveryID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return &deliveries[0], nil
+}
+
+// ListDeliveries returns the deliveries of a webhook, newest first,
+// optionally only those with status
+func (d *WebhookDispatcher) ListDeliveries(webhookID int, status string) ([]models.WebhookDelivery, error) {
+	where := "WHERE webhook_id = ?"
+	args := []interface{}{webhookID}
+	if status != "" {
+		where += " AND status = ?"
+		args = append(args, status)
+	}
+	return d.queryDeliveries(where+" ORDER BY id DESC", args...)
+}
+
+func (d *WebhookDispatcher) queryDeliveries(where string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := d.db.Query(`
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_status_code, last_error, created_at, delivered_at
+		FROM webhook_delivery
+	`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var payload string
+		var lastError sql.NullString
+		err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt,
+			&delivery.LastStatusCode, &lastError, &delivery.CreatedAt, &delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook delivery: %v", err)
+		}
+		if err := json.Unmarshal([]byte(payload), &delivery.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook payload: %v", err)
+		}
+		delivery.LastError = lastError.String
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// poll hands due deliveries to the workers whenever an event is published
+// and every pollInterval for retries
+func (d *WebhookDispatcher) poll() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, id := range d.claimDue() {
+			select {
+			case d.queue <- id:
+			case <-d.stop:
+				return
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDue returns the IDs of pending deliveries whose next attempt is due.
+// Each is leased by pushing its next attempt past the client timeout, so
+// another process polling the same table does not send it twice and a
+// delivery interrupted by a crash is retried once the lease runs out.
+func (d *WebhookDispatcher) claimDue() []int {
+	now := time.Now()
+	rows, err := d.db.Query(`
+		SELECT id FROM webhook_delivery
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`, models.WebhookDeliveryPending, now, webhookBatchSize)
+	if err != nil {
+		slog.Error("failed to fetch due webhook deliveries", "error", err)
+		return nil
+	}
+
+	var due []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("failed to read due webhook delivery", "error", err)
+			continue
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+
+	lease := now.Add(d.client.Timeout + time.Minute)
+	var claimed []int
+	for _, id := range due {
+		result, err := d.db.Exec(`
+			UPDATE webhook_delivery SET next_attempt_at = ?
+			WHERE id = ? AND status = ? AND next_attempt_at <= ?
+		`, lease, id, models.WebhookDeliveryPending, now)
+		if err != nil {
+			slog.Error("failed to claim webhook delivery", "delivery_id", id, "error", err)
+			continue
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+
+	return claimed
+}
+
+func (d *WebhookDispatcher) worker() {
+	defer d.wg.Done()
+	d.alive.Add(1)
+	defer d.alive.Add(-1)
+	for {
+		select {
+		case <-d.stop:
+			return
+		case id := <-d.queue:
+			if err := d.deliver(id); err != nil {
+				slog.Error("webhook delivery failed", "delivery_id", id, "error", err)
+			}
+		}
+	}
+}
+
+// deliver makes one attempt at sending a delivery and records the outcome
+func (d *WebhookDispatcher) deliver(id int) error {
+	var webhookID, attempts, statusCode int
+	var eventType, payload, url, secret string
+	err := d.db.QueryRow(`
+		SELECT wd.webhook_id, wd.event_type, wd.payload, wd.attempts, w.url, w.secret
+		FROM webhook_delivery wd
+		JOIN webhook w ON w.id = wd.webhook_id
+		WHERE wd.id = ?
+	`, id).Scan(&webhookID, &eventType, &payload, &attempts, &url, &secret)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook delivery: %v", err)
+	}
+
+	attempts++
+	statusCode, sendErr := d.send(id, url, secret, eventType, []byte(payload))
+	now := time.Now()
+
+	if sendErr == nil {
+		metrics.RecordWebhookDelivery(eventType, models.WebhookDeliverySucceeded)
+		_, err = d.db.Exec(`
+			UPDATE webhook_delivery
+			SET status = ?, attempts = ?, last_status_code = ?, last_error = NULL, next_attempt_at = NULL, delivered_at = ?
+			WHERE id = ?
+		`, models.WebhookDeliverySucceeded, attempts, statusCode, now, id)
+		if err != nil {
+			return fmt.Errorf("failed to record webhook delivery: %v", err)
+		}
+		return nil
+	}
+
+	status, next := models.WebhookDeliveryPending, now.Add(d.retryDelay(attempts))
+	if attempts >= d.maxAttempts {
+		status = models.WebhookDeliveryDead
+		slog.Warn("webhook delivery dead after retries", "delivery_id", id, "webhook_id", webhookID, "attempts", attempts, "error", sendErr)
+	}
+	metrics.RecordWebhookDelivery(eventType, status)
+
+	_, err = d.db.Exec(`
+		UPDATE webhook_delivery
+		SET status = ?, attempts = ?, last_status_code = ?, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`, status, attempts, statusCode, sendErr.Error(), next, id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery failure: %v", err)
+	}
+	return nil
+}
+
+// send posts a signed payload and returns the response status. Any status
+// outside 2xx is an error.
+func (d *WebhookDispatcher) send(id int, url, secret, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %v", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "attendance-system-webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(id))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns how long to wait after the given number of failed
+// attempts: backoff, then doubling each time up to webhookMaxBackoff
+func (d *WebhookDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
+
+// SignWebhookPayload returns the signature header value for a payload sent
+// at timestamp: "sha256=" followed by the hex HMAC-SHA256, keyed with the
+// webhook secret, of the timestamp, a dot and the raw body. Receivers should
+// recompute it and compare in constant time.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateWebhookSecret returns a random secret for signing payloads
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// JoinEventTypes encodes an event type list for storage
+func JoinEventTypes(eventTypes []string) string {
+	return strings.Join(eventTypes, ",")
+}
+
+// SplitEventTypes decodes a stored event type list
+func SplitEventTypes(eventTypes string) []string {
+	var result []string
+	for _, t := range strings.Split(eventTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
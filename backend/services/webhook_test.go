@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestWebhook(t *testing.T, db *sql.DB, url, secret string, eventTypes ...string) int {
+	id, err := database.InsertReturningID(db, `
+		INSERT INTO webhook (url, secret, event_types, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, url, secret, JoinEventTypes(eventTypes), true, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(id)
+}
+
+func startTestDispatcher(t *testing.T, db *sql.DB, maxAttempts int) *WebhookDispatcher {
+	d := NewWebhookDispatcher(db, &http.Client{Timeout: time.Second}, 2, maxAttempts, 10*time.Millisecond)
+	d.pollInterval = 10 * time.Millisecond
+	d.Start()
+	t.Cleanup(func() { d.Stop(context.Background()) })
+	return d
+}
+
+func TestMatchEventType(t *testing.T) {
+	assert.True(t, MatchEventType("*", models.EventEmployeeCreated))
+	assert.True(t, MatchEventType("department.*", models.EventDepartmentDeleted))
+	assert.True(t, MatchEventType("attendance.late", models.EventAttendanceLate))
+	assert.False(t, MatchEventType("department.*", models.EventEmployeeCreated))
+	assert.False(t, MatchEventType("attendance.clock_in", models.EventAttendanceClockOut))
+}
+
+func TestWebhookDeliverySignedAndRetried(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+
+		// Fail the first attempt to exercise the retry
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	webhookID := createTestWebhook(t, db, receiver.URL, "test-secret-0123456789", "department.*")
+	createTestWebhook(t, db, receiver.URL, "other-secret-0123456789", models.EventAttendanceLate)
+	d := startTestDispatcher(t, db, 3)
+
+	event := NewEvent(models.EventDepartmentCreated, models.Department{ID: 7, DepartementName: "IT"})
+	assert.NoError(t, d.Send(context.Background(), event))
+	// A relayed event sent again is not delivered twice
+	assert.NoError(t, d.Send(context.Background(), event))
+
+	assert.Eventually(t, func() bool {
+		deliveries, err := d.ListDeliveries(webhookID, models.WebhookDeliverySucceeded)
+		return err == nil && len(deliveries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	deliveries, err := d.ListDeliveries(webhookID, "")
+	if !assert.NoError(t, err) || !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+	assert.Equal(t, event.ID, delivery.Payload.ID)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, received, 2, "the attendance.late subscriber gets nothing")
+	for i, r := range received {
+		timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, SignWebhookPayload("test-secret-0123456789", timestamp, bodies[i]), r.Header.Get(WebhookSignatureHeader))
+		assert.Equal(t, models.EventDepartmentCreated, r.Header.Get(WebhookEventHeader))
+		assert.Equal(t, strconv.Itoa(delivery.ID), r.Header.Get(WebhookDeliveryHeader))
+
+		var payload models.Event
+		assert.NoError(t, json.Unmarshal(bodies[i], &payload))
+		assert.Equal(t, event.ID, payload.ID)
+		assert.Equal(t, models.EventDepartmentCreated, payload.Type)
+	}
+}
+
+func TestWebhookDeadLetterAndRedelivery(t *testing.T) {
+	db := openServicesTestDB(t)
+
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	webhookID := createTestWebhook(t, db, receiver.URL, "test-secret-0123456789", "*")
+	d := startTestDispatcher(t, db, 3)
+
+	assert.NoError(t, d.Send(context.Background(), NewEvent(models.EventEmployeeDeleted, models.Employee{ID: 1, EmployeeID: "EMP001"})))
+
+	var dead []models.WebhookDelivery
+	assert.Eventually(t, func() bool {
+		var err error
+		dead, err = d.ListDeliveries(webhookID, models.WebhookDeliveryDead)
+		return err == nil && len(dead) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	if len(dead) != 1 {
+		return
+	}
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, dead[0].LastStatusCode)
+	assert.Equal(t, "unexpected response status 503", dead[0].LastError)
+	assert.Equal(t, int32(3), calls.Load(), "dead deliveries are not retried")
+
+	healthy.Store(true)
+	delivery, err := d.Redeliver(webhookID, dead[0].ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, 0, delivery.Attempts)
+	}
+
+	assert.Eventually(t, func() bool {
+		delivery, err := d.GetDelivery(webhookID, dead[0].ID)
+		return err == nil && delivery.Status == models.WebhookDeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = d.Redeliver(webhookID+1, dead[0].ID)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+}
+
+func TestWebhookDeliveryQueuedOncePerEvent(t *testing.T) {
+	db := openServicesTestDB(t)
+	webhookID := createTestWebhook(t, db, "http://127.0.0.1:1", "test-secret-0123456789", "*")
+	d := NewWebhookDispatcher(db, &http.Client{Timeout: time.Second}, 1, 3, time.Second)
+
+	// Replicas relaying the same event at once queue a single delivery
+	event := NewEvent(models.EventDepartmentCreated, models.Department{ID: 7, DepartementName: "IT"})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, d.enqueue(event))
+		}()
+	}
+	wg.Wait()
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM webhook_delivery WHERE event_id = ?", event.ID).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	_, err := db.Exec(`
+		INSERT INTO webhook_delivery (webhook_id, event_id, event_type, payload, status, attempts, created_at)
+		VALUES (?, ?, ?, '{}', ?, 0, ?)
+	`, webhookID, event.ID, event.Type, models.WebhookDeliveryPending, time.Now())
+	assert.Error(t, err, "the database rejects a second delivery of the event")
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	d := NewWebhookDispatcher(nil, http.DefaultClient, 1, 10, 30*time.Second)
+	assert.Equal(t, 30*time.Second, d.retryDelay(1))
+	assert.Equal(t, time.Minute, d.retryDelay(2))
+	assert.Equal(t, 4*time.Minute, d.retryDelay(4))
+	assert.Equal(t, webhookMaxBackoff, d.retryDelay(20))
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"attendance-system/database"
+	"attendance-system/models"
+)
+
+// ErrContractNotFound is returned for a contract the employee does not have
+var ErrContractNotFound = errors.New("working-time contract not found")
+
+// weekdayNames are the working_days names indexed by time.Weekday
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// DefaultWorkingDays are the working days of a contract that names none
+var DefaultWorkingDays = []string{"mon", "tue", "wed", "thu", "fri"}
+
+// contractQuery selects contracts with the times they cover. Callers append
+// conditions.
+const contractQuery = `
+	SELECT id, employee_id, effective_from, weekly_hours, working_days, start_window_start, start_window_end,
+		core_start, core_end, starts_at, ends_at, created_at, updated_at
+	FROM working_time_contract
+	WHERE 1=1
+`
+
+// contractSpan is a contract with the times it covers; a zero end leaves it
+// open
+type contractSpan struct {
+	contract models.WorkingTimeContract
+	startsAt time.Time
+	endsAt   sql.NullTime
+}
+
+// covers reports whether t falls within the span
+func (s contractSpan) covers(t time.Time) bool {
+	return !t.Before(s.startsAt) && (!s.endsAt.Valid || t.Before(s.endsAt.Time))
+}
+
+// scanContract reads a row selected with contractQuery
+func scanContract(row rowScanner) (contractSpan, error) {
+	var span contractSpan
+	var effectiveFrom sql.NullTime
+	var workingDays string
+	var coreStart, coreEnd sql.NullString
+	c := &span.contract
+	err := row.Scan(&c.ID, &c.EmployeeID, &effectiveFrom, &c.WeeklyHours, &workingDays, &c.StartWindowStart,
+		&c.StartWindowEnd, &coreStart, &coreEnd, &span.startsAt, &span.endsAt, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return span, err
+	}
+	c.EffectiveFrom = *dateString(effectiveFrom)
+	c.WorkingDays = strings.Split(workingDays, ",")
+	if coreStart.Valid {
+		c.CoreStart = &coreStart.String
+	}
+	if coreEnd.Valid {
+		c.CoreEnd = &coreEnd.String
+	}
+	return span, nil
+}
+
+// ValidateContract checks that a contract's times are in order: the start
+// window opens before it closes, core hours are given together, start before
+// they end and not before the start window closes. It returns the name of the
+// offending field on failure.
+func ValidateContract(contract models.WorkingTimeContract) (string, error) {
+	if contract.StartWindowStart >= contract.StartWindowEnd {
+		return "start_window_end", fmt.Errorf("must be after start_window_start")
+	}
+	if (contract.CoreStart == nil) != (contract.CoreEnd == nil) {
+		if contract.CoreStart == nil {
+			return "core_start", fmt.Errorf("is required with core_end")
+		}
+		return "core_end", fmt.Errorf("is required with core_start")
+	}
+	if contract.CoreStart == nil {
+		return "", nil
+	}
+	if *contract.CoreStart < contract.StartWindowEnd {
+		return "core_start", fmt.Errorf("must not be before start_window_end")
+	}
+	if *contract.CoreStart >= *contract.CoreEnd {
+		return "core_end", fmt.Errorf("must be after core_start")
+	}
+	return "", nil
+}
+
+// SetContract records in tx that employeeID works under contract from its
+// EffectiveFrom date, replacing any contract starting that same day, and
+// re-evaluates the punches it covers. It returns all of the employee's
+// contracts, oldest first.
+func SetContract(db *sql.DB, tx *sql.Tx, employeeID string, contract models.WorkingTimeContract, now time.Time) ([]models.WorkingTimeContract, error) {
+	day, err := parseDay(contract.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("effective_from must be YYYY-MM-DD")
+	}
+	if err := lockEmployee(db, tx, employeeID); err != nil {
+		return nil, err
+	}
+
+	spans, err := queryContractSpans(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	workingDays := strings.Join(contract.WorkingDays, ",")
+	replaced := false
+	for _, span := range spans {
+		if span.contract.EffectiveFrom == contract.EffectiveFrom {
+			_, err = tx.Exec(`
+				UPDATE working_time_contract
+				SET weekly_hours = ?, working_days = ?, start_window_start = ?, start_window_end = ?,
+					core_start = ?, core_end = ?, updated_at = ?
+				WHERE id = ?
+			`, contract.WeeklyHours, workingDays, contract.StartWindowStart, contract.StartWindowEnd,
+				contract.CoreStart, contract.CoreEnd, now, span.contract.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update working-time contract: %v", err)
+			}
+			replaced = true
+		}
+	}
+	if !replaced {
+		_, err = tx.Exec(`
+			INSERT INTO working_time_contract (employee_id, effective_from, starts_at, weekly_hours, working_days,
+				start_window_start, start_window_end, core_start, core_end, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, employeeID, contract.EffectiveFrom, day, contract.WeeklyHours, workingDays,
+			contract.StartWindowStart, contract.StartWindowEnd, contract.CoreStart, contract.CoreEnd, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record working-time contract: %v", err)
+		}
+	}
+
+	return rechainContracts(tx, employeeID, day, now)
+}
+
+// DeleteContract removes in tx the contract contractID of employeeID and
+// re-evaluates the punches it covered against the contract before it, or the
+// department schedule when there is none. It returns the deleted contract and
+// the remaining ones, oldest first.
+func DeleteContract(db *sql.DB, tx *sql.Tx, employeeID string, contractID int, now time.Time) (models.WorkingTimeContract, []models.WorkingTimeContract, error) {
+	var deleted models.WorkingTimeContract
+	if err := lockEmployee(db, tx, employeeID); err != nil {
+		return deleted, nil, err
+	}
+
+	spans, err := queryContractSpans(tx, employeeID)
+	if err != nil {
+		return deleted, nil, err
+	}
+	var day time.Time
+	found := false
+	for _, span := range spans {
+		if span.contract.ID == contractID {
+			deleted, day, found = span.contract, span.startsAt, true
+		}
+	}
+	if !found {
+		return deleted, nil, ErrContractNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM working_time_contract WHERE id = ?", contractID); err != nil {
+		return deleted, nil, fmt.Errorf("failed to delete working-time contract: %v", err)
+	}
+	contracts, err := rechainContracts(tx, employeeID, day, now)
+	return deleted, contracts, err
+}
+
+// lockEmployee locks the employee row, serialising changes to their contracts
+func lockEmployee(db *sql.DB, tx *sql.Tx, employeeID string) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM employee WHERE employee_id = ?"+database.ForUpdate(db), employeeID).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to lock employee: %v", err)
+	}
+	return nil
+}
+
+// rechainContracts ends each of an employee's contracts where the next one
+// starts, leaving the last open, then re-evaluates the punches from changed,
+// the day whose contract changed, up to the next contract. It returns the
+// contracts, oldest first.
+func rechainContracts(tx *sql.Tx, employeeID string, changed, now time.Time) ([]models.WorkingTimeContract, error) {
+	spans, err := queryContractSpans(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var to time.Time
+	for i, span := range spans {
+		var endsAt interface{}
+		if i < len(spans)-1 {
+			endsAt = spans[i+1].startsAt
+			if to.IsZero() && spans[i+1].startsAt.After(changed) {
+				to = spans[i+1].startsAt
+			}
+		}
+		_, err := tx.Exec("UPDATE working_time_contract SET ends_at = ? WHERE id = ?", endsAt, span.contract.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update working-time contract: %v", err)
+		}
+	}
+
+	if _, err := RecomputeAttendance(tx, employeeID, changed, to, now); err != nil {
+		return nil, err
+	}
+	return WorkingTimeContracts(tx, employeeID)
+}
+
+// WorkingTimeContracts lists an employee's contracts, oldest first, filling
+// in the last day of each
+func WorkingTimeContracts(q queryer, employeeID string) ([]models.WorkingTimeContract, error) {
+	spans, err := queryContractSpans(q, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts := make([]models.WorkingTimeContract, len(spans))
+	for i, span := range spans {
+		contracts[i] = span.contract
+		if i < len(spans)-1 {
+			next, _ := parseDay(spans[i+1].contract.EffectiveFrom)
+			until := next.AddDate(0, 0, -1).Format("2006-01-02")
+			contracts[i].EffectiveUntil = &until
+		}
+	}
+	return contracts, nil
+}
+
+// queryContractSpans reads an employee's contracts oldest first
+func queryContractSpans(q queryer, employeeID string) ([]contractSpan, error) {
+	spans, err := contractSpans(q, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return spans[employeeID], nil
+}
+
+// contractSpans loads the contracts of employeeID, or of everyone when it is
+// empty, keyed by employee ID and sorted oldest first
+func contractSpans(q queryer, employeeID string) (map[string][]contractSpan, error) {
+	query := contractQuery
+	var args []interface{}
+	if employeeID != "" {
+		query += " AND employee_id = ?"
+		args = append(args, employeeID)
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch working-time contracts: %v", err)
+	}
+	defer rows.Close()
+
+	spans := make(map[string][]contractSpan)
+	for rows.Next() {
+		span, err := scanContract(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read working-time contract: %v", err)
+		}
+		spans[span.contract.EmployeeID] = append(spans[span.contract.EmployeeID], span)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, employeeSpans := range spans {
+		sort.Slice(employeeSpans, func(i, j int) bool {
+			return employeeSpans[i].contract.EffectiveFrom < employeeSpans[j].contract.EffectiveFrom
+		})
+	}
+	return spans, nil
+}
+
+// contractAt returns the contract whose span covers t, or nil when none does
+func contractAt(spans []contractSpan, t time.Time) *models.WorkingTimeContract {
+	for i := range spans {
+		if spans[i].covers(t) {
+			return &spans[i].contract
+		}
+	}
+	return nil
+}
+
+// ContractOn returns the contract employeeID worked under at t, or nil when
+// their department's schedule applied
+func ContractOn(q queryer, employeeID string, t time.Time) (*models.WorkingTimeContract, error) {
+	spans, err := queryContractSpans(q, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return contractAt(spans, t), nil
+}
+
+// worksOn reports whether t's day is one of the contract's working days
+func worksOn(contract *models.WorkingTimeContract, t time.Time) bool {
+	return slices.Contains(contract.WorkingDays, weekdayNames[t.Weekday()])
+}
+
+// dailyTargetMinutes is the minutes a contract asks for on each working day
+func dailyTargetMinutes(contract *models.WorkingTimeContract) int {
+	if len(contract.WorkingDays) == 0 {
+		return 0
+	}
+	return int(math.Round(contract.WeeklyHours * 60 / float64(len(contract.WorkingDays))))
+}
+
+// contractLimits returns the latest on-time clock-in and earliest on-time
+// clock-out under contract on t's day. Both are empty on days off, and the
+// clock-out limit is empty without core hours.
+func contractLimits(contract *models.WorkingTimeContract, t time.Time) (string, string) {
+	if !worksOn(contract, t) {
+		return "", ""
+	}
+	if contract.CoreEnd == nil {
+		return contract.StartWindowEnd, ""
+	}
+	return contract.StartWindowEnd, *contract.CoreEnd
+}
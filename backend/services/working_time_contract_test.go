@@ -0,0 +1,186 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"attendance-system/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePunchUnderContract(t *testing.T) {
+	dept := models.Department{ID: 3, MaxClockInTime: "09:00:00", MaxClockOutTime: "17:00:00"}
+	coreStart, coreEnd := "10:00:00", "15:00:00"
+	contract := &models.WorkingTimeContract{
+		ID: 7, WeeklyHours: 20, WorkingDays: []string{"mon", "wed", "fri"},
+		StartWindowStart: "07:00:00", StartWindowEnd: "10:00:00", CoreStart: &coreStart, CoreEnd: &coreEnd,
+	}
+
+	// Tuesday 2 January 2024 is a day off, Wednesday 3 a working day
+	dayOff := EvaluatePunch(1, time.Date(2024, 1, 2, 11, 0, 0, 0, time.Local), dept, contract)
+	assert.Equal(t, PunchEvaluation{DepartementID: 3, ContractID: 7, IsOnTime: true, Description: "Clock In"}, dayOff)
+
+	withinWindow := EvaluatePunch(1, time.Date(2024, 1, 3, 9, 45, 0, 0, time.Local), dept, contract)
+	assert.True(t, withinWindow.IsOnTime, "the department's 09:00 limit does not apply")
+	assert.Equal(t, "10:00:00", withinWindow.MaxClockInTime)
+
+	late := EvaluatePunch(1, time.Date(2024, 1, 3, 10, 5, 0, 0, time.Local), dept, contract)
+	assert.False(t, late.IsOnTime)
+	assert.Equal(t, 5, late.LateMinutes)
+
+	early := EvaluatePunch(2, time.Date(2024, 1, 3, 14, 30, 0, 0, time.Local), dept, contract)
+	assert.False(t, early.IsOnTime)
+	assert.Equal(t, 30, early.EarlyMinutes)
+
+	contract.CoreStart, contract.CoreEnd = nil, nil
+	anyTime := EvaluatePunch(2, time.Date(2024, 1, 3, 11, 0, 0, 0, time.Local), dept, contract)
+	assert.True(t, anyTime.IsOnTime, "without core hours no clock-out is early")
+	assert.Equal(t, "", anyTime.MaxClockOutTime)
+}
+
+func TestValidateContract(t *testing.T) {
+	ten, nine, three := "10:00:00", "09:00:00", "15:00:00"
+	contract := models.WorkingTimeContract{StartWindowStart: "07:00:00", StartWindowEnd: "10:00:00"}
+	field, err := ValidateContract(contract)
+	assert.NoError(t, err)
+	assert.Equal(t, "", field)
+
+	for expected, invalid := range map[string]models.WorkingTimeContract{
+		"start_window_end": {StartWindowStart: "10:00:00", StartWindowEnd: "07:00:00"},
+		"core_end":         {StartWindowStart: "07:00:00", StartWindowEnd: "09:00:00", CoreStart: &ten},
+		"core_start":       {StartWindowStart: "07:00:00", StartWindowEnd: "10:00:00", CoreStart: &nine, CoreEnd: &three},
+	} {
+		field, err := ValidateContract(invalid)
+		assert.Error(t, err, expected)
+		assert.Equal(t, expected, field)
+	}
+}
+
+func TestContractsAndFlexTime(t *testing.T) {
+	db := openServicesTestDB(t)
+	created := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+	_, err := db.Exec(`INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, updated_at)
+		VALUES (1, 'IT', '09:00:00', '17:00:00', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO employee (employee_id, departement_id, name, address, hire_date, created_at, updated_at)
+		VALUES ('A', 1, 'Employee A', '', '2023-06-01', ?, ?)`, created, created)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO department_assignment (employee_id, departement_id, effective_from, created_at)
+		VALUES ('A', 1, '2023-06-01', ?)`, created)
+	assert.NoError(t, err)
+
+	// Monday 1 to Wednesday 3 January 2024, clocking in at 09:30 each day
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	for i, day := range []int{1, 2, 3} {
+		clockIn := time.Date(2024, 1, day, 9, 30, 0, 0, time.Local)
+		clockOut := clockIn.Add(time.Duration(4+i) * time.Hour)
+		attendanceID := []string{"att-1", "att-2", "att-3"}[i]
+		_, err = tx.Exec(`INSERT INTO attendance (employee_id, attendance_id, clock_in, clock_out, created_at, updated_at)
+			VALUES ('A', ?, ?, ?, ?, ?)`, attendanceID, clockIn, clockOut, clockIn, clockIn)
+		assert.NoError(t, err)
+		eval, err := EvaluatePunchOn(tx, "A", 1, clockIn)
+		assert.NoError(t, err)
+		assert.False(t, eval.IsOnTime)
+		_, err = RecordPunch(db, tx, "A", attendanceID, 1, clockIn, eval, clockIn)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tx.Commit())
+
+	// A backdated part-time contract from Tuesday re-evaluates Tuesday on
+	set := func(contract models.WorkingTimeContract) []models.WorkingTimeContract {
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		contracts, err := SetContract(db, tx, "A", contract, time.Now())
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		return contracts
+	}
+	contracts := set(models.WorkingTimeContract{
+		EffectiveFrom: "2024-01-02", WeeklyHours: 15, WorkingDays: []string{"mon", "tue", "wed"},
+		StartWindowStart: "08:00:00", StartWindowEnd: "10:00:00",
+	})
+	if assert.Len(t, contracts, 1) {
+		assert.Nil(t, contracts[0].EffectiveUntil)
+		assert.Equal(t, 15.0, contracts[0].WeeklyHours)
+	}
+	logs, err := QueryAttendanceLogs(db, models.ExportFilters{StartDate: "2024-01-01", EndDate: "2024-01-03"})
+	assert.NoError(t, err)
+	onTime := map[string]bool{}
+	for _, log := range logs {
+		onTime[log.DateAttendance.Format("2006-01-02")] = log.IsOnTime
+		if log.DateAttendance.Day() == 1 {
+			assert.Nil(t, log.ContractID)
+		} else if assert.NotNil(t, log.ContractID) {
+			assert.Equal(t, contracts[0].ID, *log.ContractID)
+		}
+	}
+	assert.Equal(t, map[string]bool{"2024-01-01": false, "2024-01-02": true, "2024-01-03": true}, onTime)
+
+	// Each working day asks for 15h / 3 = 300 minutes; Thursday is a day off
+	account, err := FlexTime(db, mustEmployee(t, db, "A"), "2024-01-01", "2024-01-04", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.FlexTimeDay{
+		{Date: "2024-01-02", ContractID: contracts[0].ID, TargetMinutes: 300, WorkedMinutes: 300, BalanceMinutes: 0},
+		{Date: "2024-01-03", ContractID: contracts[0].ID, TargetMinutes: 300, WorkedMinutes: 360, BalanceMinutes: 60},
+	}, account.Days)
+	assert.Equal(t, 60, account.BalanceMinutes)
+
+	// Thursday and Friday are days off, so A is not absent
+	absences, err := QueryAbsences(db, models.ExportFilters{StartDate: "2024-01-04", EndDate: "2024-01-05"})
+	assert.NoError(t, err)
+	assert.Empty(t, absences)
+
+	// A later contract ends the first; replacing it keeps one per day
+	contracts = set(models.WorkingTimeContract{
+		EffectiveFrom: "2024-01-03", WeeklyHours: 40, WorkingDays: DefaultWorkingDays,
+		StartWindowStart: "08:00:00", StartWindowEnd: "09:00:00",
+	})
+	contracts = set(models.WorkingTimeContract{
+		EffectiveFrom: "2024-01-03", WeeklyHours: 40, WorkingDays: DefaultWorkingDays,
+		StartWindowStart: "08:00:00", StartWindowEnd: "09:15:00",
+	})
+	if assert.Len(t, contracts, 2) {
+		assert.Equal(t, "2024-01-02", *contracts[0].EffectiveUntil)
+		assert.Equal(t, "09:15:00", contracts[1].StartWindowEnd)
+	}
+	logs, err = QueryAttendanceLogs(db, models.ExportFilters{Date: "2024-01-03"})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.False(t, logs[0].IsOnTime)
+		assert.Equal(t, 15, logs[0].LateMinutes)
+	}
+
+	// Deleting the first contract hands Tuesday back to the department
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	deleted, contracts, err := DeleteContract(db, tx, "A", contracts[0].ID, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, "2024-01-02", deleted.EffectiveFrom)
+	assert.Len(t, contracts, 1)
+	logs, err = QueryAttendanceLogs(db, models.ExportFilters{Date: "2024-01-02"})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.False(t, logs[0].IsOnTime)
+		assert.Nil(t, logs[0].ContractID)
+		assert.Equal(t, "09:00:00", logs[0].MaxClockInTime)
+	}
+
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	_, _, err = DeleteContract(db, tx, "A", deleted.ID, time.Now())
+	assert.ErrorIs(t, err, ErrContractNotFound)
+	tx.Rollback()
+}
+
+// mustEmployee loads an employee by employee ID
+func mustEmployee(t *testing.T, db *sql.DB, employeeID string) models.Employee {
+	emp, err := ScanEmployee(db.QueryRow("SELECT "+EmployeeColumns+" FROM employee e WHERE e.employee_id = ?", employeeID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return emp
+}
@@ -15,7 +15,7 @@ import {
   ArrowDownIcon
 } from '@heroicons/react/24/outline';
 import { employeeApi, departmentApi, attendanceApi } from '@/lib/api';
-import { EmployeeWithDepartment, Department, AttendanceLog } from '@/types';
+import { EmployeeWithDepartment, Department, AttendanceLog, AttendanceEvent } from '@/types';
 import Layout from '@/components/layout/Layout';
 import { formatDate } from '@/lib/utils';
 
@@ -184,6 +184,24 @@ export default function Dashboard() {
     return () => clearInterval(healthInterval);
   }, []);
 
+  // Push clock-ins and clock-outs into recent activity as they happen
+  useEffect(() => {
+    const source = attendanceApi.stream((event: AttendanceEvent) => {
+      setRecentActivity(prev => [
+        {
+          id: event.history_id,
+          employee_name: event.employee_name,
+          action: event.attendance_type === 1 ? 'Clocked In' : 'Clocked Out',
+          time: event.time,
+          status: event.is_on_time ? 'success' : 'warning'
+        } as RecentActivity,
+        ...prev.filter(activity => activity.id !== event.history_id)
+      ].slice(0, 5));
+    });
+
+    return () => source.close();
+  }, []);
+
   const statCards = [
     {
       name: 'Total Employees',
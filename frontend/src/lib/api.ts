@@ -11,10 +11,12 @@ import {
   ClockInRequest,
   ClockOutRequest,
   AttendanceFilter,
+  AttendanceEvent,
   EmployeesResponse,
   DepartmentsResponse,
   AttendanceLogsResponse,
-  ApiResponse
+  ApiResponse,
+  ErrorResponse
 } from '@/types';
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
@@ -26,6 +28,14 @@ const api = axios.create({
   },
 });
 
+// errorCode returns the API error code of a failed request, if any
+const errorCode = (error: unknown): string | undefined =>
+  (error as AxiosError<ErrorResponse>).response?.data?.error?.code;
+
+// errorMessage returns the API error message of a failed request, or fallback
+const errorMessage = (error: unknown, fallback: string): string =>
+  (error as AxiosError<ErrorResponse>).response?.data?.error?.message || fallback;
+
 // Employee API
 export const employeeApi = {
   // Get all employees
@@ -105,11 +115,10 @@ export const attendanceApi = {
       const response = await api.post('/api/v1/attendance/clock-in', data);
       return response.data;
     } catch (error) {
-      const axiosError = error as AxiosError<{ error: string }>;
-      if (axiosError.response?.status === 409) {
+      if (errorCode(error) === 'ALREADY_CLOCKED_IN') {
         throw new Error('Employee has already clocked in today');
       }
-      throw new Error(axiosError.response?.data?.error || 'Failed to clock in');
+      throw new Error(errorMessage(error, 'Failed to clock in'));
     }
   },
 
@@ -125,14 +134,13 @@ export const attendanceApi = {
       const response = await api.put('/api/v1/attendance/clock-out', data);
       return response.data;
     } catch (error) {
-      const axiosError = error as AxiosError<{ error: string }>;
-      if (axiosError.response?.status === 409) {
-        throw new Error('Employee has already clocked out today');
+      switch (errorCode(error)) {
+        case 'ALREADY_CLOCKED_OUT':
+          throw new Error('Employee has already clocked out today');
+        case 'NOT_CLOCKED_IN':
+          throw new Error('No active clock in found for today');
       }
-      if (axiosError.response?.status === 400) {
-        throw new Error('No active clock in found for today');
-      }
-      throw new Error(axiosError.response?.data?.error || 'Failed to clock out');
+      throw new Error(errorMessage(error, 'Failed to clock out'));
     }
   },
 
@@ -146,6 +154,19 @@ export const attendanceApi = {
     return response.data;
   },
 
+  // Subscribe to clock-ins and clock-outs as they happen. EventSource
+  // reconnects on its own and resumes from the last event received.
+  stream: (onEvent: (event: AttendanceEvent) => void, departmentId?: number): EventSource => {
+    const params = new URLSearchParams();
+    if (departmentId) params.append('department_id', departmentId.toString());
+
+    const source = new EventSource(`${API_BASE_URL}/api/v1/attendance/stream?${params.toString()}`);
+    const handle = (message: MessageEvent) => onEvent(JSON.parse(message.data));
+    source.addEventListener('attendance.clock_in', handle);
+    source.addEventListener('attendance.clock_out', handle);
+    return source;
+  },
+
   // Get current attendance status for an employee
   getCurrentStatus: async (employeeId: string): Promise<{
     has_clocked_in: boolean;
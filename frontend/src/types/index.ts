@@ -4,6 +4,18 @@ export interface Employee {
   departement_id: number;
   name: string;
   address: string;
+  email: string;
+  phone: string;
+  job_title: string;
+  employment_type: 'full_time' | 'part_time' | 'contractor';
+  work_location: string;
+  photo_url: string | null;
+  custom_fields: Record<string, string | number | boolean>;
+  employment_status: 'active' | 'suspended' | 'terminated';
+  hire_date: string | null;
+  termination_date: string | null;
+  manager_id: string | null;
+  deleted_at?: string;
   created_at: string;
   updated_at: string;
 }
@@ -12,6 +24,21 @@ export interface EmployeeWithDepartment extends Employee {
   department: Department;
 }
 
+export interface OrgChartNode {
+  employee: EmployeeWithDepartment;
+  reports: OrgChartNode[];
+}
+
+export interface CustomField {
+  id: number;
+  name: string;
+  label: string;
+  field_type: 'text' | 'number' | 'date' | 'boolean' | 'select';
+  options: string[] | null;
+  created_at: string;
+  updated_at: string;
+}
+
 export interface Department {
   id: number;
   departement_name: string;
@@ -19,6 +46,51 @@ export interface Department {
   max_clock_out_time: string;
 }
 
+export interface DepartmentAssignment {
+  id: number;
+  employee_id: string;
+  departement_id: number;
+  departement_name: string;
+  effective_from: string;
+  effective_until: string | null;
+  created_at: string;
+}
+
+export type Weekday = 'mon' | 'tue' | 'wed' | 'thu' | 'fri' | 'sat' | 'sun';
+
+export interface WorkingTimeContract {
+  id: number;
+  employee_id: string;
+  effective_from: string;
+  effective_until: string | null;
+  weekly_hours: number;
+  working_days: Weekday[];
+  start_window_start: string;
+  start_window_end: string;
+  core_start: string | null;
+  core_end: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface FlexTimeDay {
+  date: string;
+  contract_id: number;
+  target_minutes: number;
+  worked_minutes: number;
+  balance_minutes: number;
+}
+
+export interface FlexTimeAccount {
+  employee_id: string;
+  start_date: string;
+  end_date: string;
+  target_minutes: number;
+  worked_minutes: number;
+  balance_minutes: number;
+  days: FlexTimeDay[];
+}
+
 export interface Attendance {
   id: number;
   employee_id: string;
@@ -41,7 +113,10 @@ export interface AttendanceLog {
   description: string;
   max_clock_in_time: string;
   max_clock_out_time: string;
+  contract_id: number | null;
   is_on_time: boolean;
+  late_minutes: number;
+  early_minutes: number;
   created_at: string;
 }
 
@@ -78,6 +153,21 @@ export interface ClockOutRequest {
   employee_id: string;
 }
 
+export interface AttendanceEvent {
+  history_id: number;
+  attendance_id: string;
+  attendance_type: number; // 1 = In, 2 = Out
+  employee_id: string;
+  employee_name: string;
+  departement_id: number;
+  departement_name: string;
+  time: string;
+  is_on_time: boolean;
+  late_minutes: number;
+  early_minutes: number;
+  description: string;
+}
+
 export interface AttendanceFilter {
   date?: string;
   department_id?: number;
@@ -87,7 +177,20 @@ export interface ApiResponse<T> {
   message?: string;
   data?: T;
   count?: number;
-  error?: string;
+  error?: ApiErrorBody;
+}
+
+// Error envelope returned by every failing request. code is stable (see
+// backend/errcodes); message is for display.
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: { field: string; rule?: string; message: string }[];
+  request_id: string;
+}
+
+export interface ErrorResponse {
+  error: ApiErrorBody;
 }
 
 export interface EmployeesResponse extends ApiResponse<EmployeeWithDepartment[]> {